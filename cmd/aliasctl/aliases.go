@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func createAliases(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	request := &aliasapi.CreateRequest{}
+	flags.Func("max-uses", "the alias expires after this many redirects, permanent if not set", func(value string) error {
+		maxUses, err := strconv.ParseUint(value, 10, 64)
+		request.MaxUsageCount = &maxUses
+		return err
+	})
+	flags.StringVar(&request.Key, "key", "", "key of the alias instead of a generated one, only with a single url")
+	flags.StringVar(&request.Campaign, "campaign", "", "campaign whose parameters are added to the urls")
+	redirectType := flags.Int("redirect-type", 0, "301 | 302 | 307 | 308, the configured default if not set")
+	passthrough := flags.String("passthrough", "", "none | query | path | all")
+	queryConflict := flags.String("query-conflict", "", "keep-target | override | append")
+	flags.StringVar(&request.Password, "password", "", "visitors must enter the password before they are redirected")
+	flags.BoolVar(&request.Interstitial, "interstitial", false, "every visit shows the preview page before the redirect")
+	activatesAt := flags.String("activates-at", "", "RFC 3339 time the aliases start redirecting at, immediately if not set")
+	expiresAt := flags.String("expires-at", "", "RFC 3339 time the aliases stop redirecting at, never if not set")
+	if err := parseFlags(flags, "URL...", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, -1); err != nil {
+		return err
+	}
+
+	request.Urls = flags.Args()
+	request.RedirectType = aliasapi.RedirectType(*redirectType)
+	var err error
+	if request.Passthrough, err = parseEnum[aliasapi.PassthroughMode](aliasapi.PassthroughMode_value,
+		"PASSTHROUGH_MODE_", *passthrough); err != nil {
+		return fmt.Errorf("passthrough: %w", err)
+	}
+	if request.QueryConflict, err = parseEnum[aliasapi.QueryConflict](aliasapi.QueryConflict_value,
+		"QUERY_CONFLICT_", *queryConflict); err != nil {
+		return fmt.Errorf("query-conflict: %w", err)
+	}
+	if request.ActivatesAt, err = parseTimestamp(*activatesAt); err != nil {
+		return fmt.Errorf("activates-at: %w", err)
+	}
+	if request.ExpiresAt, err = parseTimestamp(*expiresAt); err != nil {
+		return fmt.Errorf("expires-at: %w", err)
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	response, err := aliasClient.Api.Create(ctx, request)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		fmt.Fprintln(w, "TARGET\tALIAS")
+		for index, target := range request.Urls {
+			fmt.Fprintf(w, "%s\t%s\n", target, response.GetUrls()[index])
+		}
+	})
+}
+
+func getAlias(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	if err := parseFlags(flags, "KEY", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, 1); err != nil {
+		return err
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	alias, err := aliasClient.Api.GetAlias(ctx, &aliasapi.KeyRequest{Key: flags.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return c.print(alias, func(w io.Writer) {
+		triesLeft := "unlimited"
+		if !alias.GetIsPermanent() {
+			triesLeft = strconv.FormatInt(alias.GetTriesLeft(), 10)
+		}
+		fmt.Fprintf(w, "key\t%s\n", alias.GetKey())
+		fmt.Fprintf(w, "url\t%s\n", alias.GetUrl())
+		fmt.Fprintf(w, "target\t%s\n", orDash(alias.GetTarget()))
+		fmt.Fprintf(w, "status\t%s\n", alias.GetStatus())
+		fmt.Fprintf(w, "tries left\t%s\n", triesLeft)
+		fmt.Fprintf(w, "redirect type\t%s\n", redirectTypeName(alias.GetRedirectType()))
+		fmt.Fprintf(w, "campaign\t%s\n", orDash(alias.GetCampaign()))
+		fmt.Fprintf(w, "protected\t%t\n", alias.GetProtected())
+		fmt.Fprintf(w, "interstitial\t%t\n", alias.GetInterstitial())
+		fmt.Fprintf(w, "created at\t%s\n", formatTime(alias.GetCreatedAt()))
+		fmt.Fprintf(w, "activates at\t%s\n", orDash(formatTime(alias.GetActivatesAt())))
+		fmt.Fprintf(w, "expires at\t%s\n", orDash(formatTime(alias.GetExpiresAt())))
+		fmt.Fprintf(w, "expired at\t%s\n", orDash(formatTime(alias.GetExpiredAt())))
+	})
+}
+
+func listAliases(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	request := &aliasapi.ListAliasesRequest{}
+	flags.StringVar(&request.Status, "status", "", "active | scheduled | disabled | expired | deleted, any status but deleted if empty")
+	flags.StringVar(&request.Campaign, "campaign", "", "aliases of the campaign only")
+	limit := flags.Int("limit", 0, "aliases of a page, the server default if not set")
+	flags.StringVar(&request.Cursor, "cursor", "", "next cursor printed by the previous page")
+	all := flags.Bool("all", false, "follow the pages up to the last one")
+	if err := parseFlags(flags, "", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 0, 0); err != nil {
+		return err
+	}
+	request.Limit = int32(*limit)
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	list := &aliasapi.AliasList{}
+	for {
+		callCtx, cancel := c.call(ctx)
+		page, err := aliasClient.Api.ListAliases(callCtx, request)
+		cancel()
+		if err != nil {
+			return err
+		}
+		list.Aliases = append(list.Aliases, page.GetAliases()...)
+		list.NextCursor = page.GetNextCursor()
+		if !*all || page.GetNextCursor() == "" {
+			break
+		}
+		request.Cursor = page.GetNextCursor()
+	}
+
+	return c.print(list, func(w io.Writer) {
+		fmt.Fprintln(w, "KEY\tSTATUS\tTRIES LEFT\tCAMPAIGN\tCREATED AT\tTARGET")
+		for _, alias := range list.GetAliases() {
+			triesLeft := "-"
+			if !alias.GetIsPermanent() {
+				triesLeft = strconv.FormatInt(alias.GetTriesLeft(), 10)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", alias.GetKey(), alias.GetStatus(), triesLeft,
+				orDash(alias.GetCampaign()), formatTime(alias.GetCreatedAt()), orDash(alias.GetTarget()))
+		}
+		if list.GetNextCursor() != "" {
+			fmt.Fprintf(w, "\nnext cursor: %s\n", list.GetNextCursor())
+		}
+	})
+}
+
+func updateAlias(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("update", flag.ContinueOnError)
+	disable := flags.Bool("disable", false, "stop the alias from redirecting")
+	enable := flags.Bool("enable", false, "let a disabled alias redirect again")
+	activatesAt := flags.String("activates-at", "", "RFC 3339 time the alias starts redirecting at")
+	activateNow := flags.Bool("activate-now", false, "let a scheduled alias redirect immediately")
+	rulesFile := flags.String("rules-file", "", "JSON array of the redirect rules replacing the current ones, [] removes them")
+	variantsFile := flags.String("variants-file", "", "JSON array of the A/B variants replacing the current ones, [] turns the split off")
+	if err := parseFlags(flags, "KEY", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, 1); err != nil {
+		return err
+	}
+	if *disable && *enable {
+		return errors.New("-disable and -enable are exclusive")
+	}
+	if *activatesAt != "" && *activateNow {
+		return errors.New("-activates-at and -activate-now are exclusive")
+	}
+	if !*disable && !*enable && *activatesAt == "" && !*activateNow && *rulesFile == "" && *variantsFile == "" {
+		flags.Usage()
+		return errors.New("nothing to update")
+	}
+	key := flags.Arg(0)
+
+	// the files are read before any change, so a malformed one changes nothing
+	var rules *aliasapi.SetRulesRequest
+	if *rulesFile != "" {
+		rules = &aliasapi.SetRulesRequest{}
+		if err := readListFile(*rulesFile, "rules", rules); err != nil {
+			return err
+		}
+		rules.Key = key
+	}
+	var variants *aliasapi.SetVariantsRequest
+	if *variantsFile != "" {
+		variants = &aliasapi.SetVariantsRequest{}
+		if err := readListFile(*variantsFile, "variants", variants); err != nil {
+			return err
+		}
+		variants.Key = key
+	}
+	activation, err := parseTimestamp(*activatesAt)
+	if err != nil {
+		return fmt.Errorf("activates-at: %w", err)
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	if rules != nil {
+		if _, err := aliasClient.Api.SetRules(ctx, rules); err != nil {
+			return fmt.Errorf("rules: %w", err)
+		}
+	}
+	if variants != nil {
+		if _, err := aliasClient.Api.SetVariants(ctx, variants); err != nil {
+			return fmt.Errorf("variants: %w", err)
+		}
+	}
+	if activation != nil || *activateNow {
+		request := &aliasapi.SetActivationRequest{Key: key, ActivatesAt: activation}
+		if _, err := aliasClient.Api.SetActivation(ctx, request); err != nil {
+			return fmt.Errorf("activation: %w", err)
+		}
+	}
+	switch {
+	case *disable:
+		_, err = aliasClient.Api.DisableAlias(ctx, &aliasapi.KeyRequest{Key: key})
+	case *enable:
+		_, err = aliasClient.Api.EnableAlias(ctx, &aliasapi.KeyRequest{Key: key})
+	}
+	if err != nil {
+		return err
+	}
+
+	alias, err := aliasClient.Api.GetAlias(ctx, &aliasapi.KeyRequest{Key: key})
+	if err != nil {
+		return err
+	}
+	return c.print(alias, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\t%s\n", alias.GetKey(), alias.GetStatus())
+	})
+}
+
+func removeAliases(ctx context.Context, c *cli, args []string) error {
+	return forEachKey(ctx, c, "remove", args, func(ctx context.Context, aliasClient aliasapi.AliasAPIClient, key string) error {
+		_, err := aliasClient.Remove(ctx, &aliasapi.KeyRequest{Key: key})
+		return err
+	})
+}
+
+func restoreAliases(ctx context.Context, c *cli, args []string) error {
+	return forEachKey(ctx, c, "restore", args, func(ctx context.Context, aliasClient aliasapi.AliasAPIClient, key string) error {
+		_, err := aliasClient.Restore(ctx, &aliasapi.KeyRequest{Key: key})
+		return err
+	})
+}
+
+// forEachKey calls fn with every key of the arguments, a failed key does not stop the others
+func forEachKey(ctx context.Context, c *cli, name string, args []string,
+	fn func(ctx context.Context, aliasClient aliasapi.AliasAPIClient, key string) error) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := parseFlags(flags, "KEY...", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, -1); err != nil {
+		return err
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, key := range flags.Args() {
+		callCtx, cancel := c.call(ctx)
+		err := fn(callCtx, aliasClient.Api, key)
+		cancel()
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %s\n", key, describeError(err))
+			continue
+		}
+		fmt.Fprintf(c.out, "%s: done\n", key)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d aliases failed", failed, flags.NArg())
+	}
+	return nil
+}
+
+func aliasStats(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	if err := parseFlags(flags, "KEY", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, 1); err != nil {
+		return err
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	stats, err := aliasClient.Api.GetStats(ctx, &aliasapi.KeyRequest{Key: flags.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return c.print(stats, func(w io.Writer) {
+		fmt.Fprintf(w, "key\t%s\n", stats.GetKey())
+		fmt.Fprintf(w, "clicks\t%d\n", stats.GetClicks())
+		fmt.Fprintf(w, "last click at\t%s\n", orDash(formatTime(stats.GetLastClickAt())))
+		printCounters(w, "rule", stats.GetRules())
+		printCounters(w, "variant", stats.GetVariants())
+	})
+}
+
+// printCounters prints the clicks per rule or variant ordered by name
+func printCounters(w io.Writer, kind string, counters map[string]int64) {
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %s\t%d\n", kind, name, counters[name])
+	}
+}
+
+// parseEnum parses the lower case name of an enum value without its prefix, e.g. keep-target, empty means unspecified
+func parseEnum[T ~int32](values map[string]int32, prefix, name string) (T, error) {
+	if name == "" {
+		return 0, nil
+	}
+	value, ok := values[prefix+strings.ToUpper(strings.ReplaceAll(name, "-", "_"))]
+	if !ok || value == 0 {
+		return 0, fmt.Errorf("unknown value %q", name)
+	}
+	return T(value), nil
+}
+
+// parseTimestamp parses an RFC 3339 time, empty means not set
+func parseTimestamp(value string) (*timestamppb.Timestamp, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return timestamppb.New(parsed), nil
+}
+
+// readListFile reads a JSON array of the file into the named list field of the request
+func readListFile(path, field string, request proto.Message) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	document := fmt.Sprintf(`{%q: %s}`, field, data)
+	if err := protojson.Unmarshal([]byte(document), request); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func redirectTypeName(redirectType aliasapi.RedirectType) string {
+	if redirectType == aliasapi.RedirectType_REDIRECT_TYPE_UNSPECIFIED {
+		return "default"
+	}
+	return strconv.Itoa(int(redirectType))
+}
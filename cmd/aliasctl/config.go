@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/xloki21/alias/internal/config"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func configCommand(_ context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	configPath := flags.String("config", "", fmt.Sprintf("configuration file of the service, %s_CONFIG or the one found "+
+		"by the server if empty", config.EnvPrefix))
+	if err := parseFlags(flags, "check", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, 1); err != nil {
+		return err
+	}
+	if flags.Arg(0) != "check" {
+		flags.Usage()
+		return fmt.Errorf("unknown config command %q", flags.Arg(0))
+	}
+	return checkConfig(c, *configPath)
+}
+
+// checkConfig prints the effective configuration of the server with the secrets redacted, then the problems found in it
+func checkConfig(c *cli, path string) error {
+	if path == "" {
+		path = os.Getenv(config.EnvPrefix + "_CONFIG")
+	}
+	cfg, file, err := config.Read(path)
+	if err != nil {
+		return err
+	}
+	validationErr := cfg.Validate()
+	var problems []string
+	if validationErr != nil {
+		problems = strings.Split(validationErr.Error(), "\n")
+	}
+
+	if err := writeConfig(c, file, cfg.Settings(), problems); err != nil {
+		return err
+	}
+	if validationErr != nil {
+		return errors.New("the configuration is invalid")
+	}
+	return nil
+}
+
+func writeConfig(c *cli, file string, settings []config.Setting, problems []string) error {
+	if c.settings.Output == outputJSON {
+		values := make(map[string]string, len(settings))
+		for _, setting := range settings {
+			values[setting.Key] = setting.Value
+		}
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			File     string            `json:"file"`
+			Settings map[string]string `json:"settings"`
+			Problems []string          `json:"problems"`
+		}{file, values, problems})
+	}
+
+	if file == "" {
+		fmt.Fprintln(c.out, "no config file found, the defaults and the environment are used")
+	} else {
+		fmt.Fprintf(c.out, "config file %s\n", file)
+	}
+	writer := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tVALUE\tENVIRONMENT")
+	for _, setting := range settings {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", setting.Key, orDash(setting.Value), config.EnvVar(setting.Key))
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		fmt.Fprintln(c.out, "\nproblems:")
+		for _, problem := range problems {
+			fmt.Fprintf(c.out, "  %s\n", problem)
+		}
+	}
+	return nil
+}
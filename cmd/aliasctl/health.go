@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
+)
+
+func checkHealth(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("health", flag.ContinueOnError)
+	if err := parseFlags(flags, "", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 0, 0); err != nil {
+		return err
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+	if _, err := aliasClient.Api.HealthCheck(ctx, &emptypb.Empty{}); err != nil {
+		return err
+	}
+	// the serving status reflects the readiness of the storage
+	response, err := aliasClient.Health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	err = c.print(response, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\t%s\n", c.settings.Address, response.GetStatus())
+	})
+	if err == nil && response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		err = errors.New("the service is not serving")
+	}
+	return err
+}
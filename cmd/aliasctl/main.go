@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/xloki21/alias/internal/client"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"google.golang.org/grpc/status"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// command is a subcommand of aliasctl, args are the arguments following the name of the command
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = []command{
+	{name: "create", summary: "create aliases of the urls", run: createAliases},
+	{name: "get", summary: "show an alias", run: getAlias},
+	{name: "list", summary: "list the aliases", run: listAliases},
+	{name: "update", summary: "change the rules, variants, activation time or state of an alias", run: updateAlias},
+	{name: "remove", summary: "move aliases to the trash", run: removeAliases},
+	{name: "restore", summary: "take aliases back from the trash", run: restoreAliases},
+	{name: "stats", summary: "show the click counters of an alias", run: aliasStats},
+	{name: "import", summary: "import aliases from a CSV or NDJSON file", run: importAliases},
+	{name: "export", summary: "export the aliases or the clicks as CSV or NDJSON", run: exportData},
+	{name: "health", summary: "check the service is up and ready", run: checkHealth},
+	{name: "config", summary: "check the configuration of the server", run: configCommand},
+	{name: "migrate", summary: "apply or revert the schema migrations of the storage", run: migrateSchema},
+	{name: "migrate-storage", summary: "copy the aliases and the statistics from one storage to another", run: migrateStorage},
+}
+
+// cli is the state shared by the commands
+type cli struct {
+	settings settings
+	out      io.Writer
+	client   *client.Client
+}
+
+// connect returns the client of the configured service, connecting on the first call
+func (c *cli) connect() (*client.Client, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+	options := []client.Option{client.WithToken(c.settings.Token)}
+	if c.settings.TLS {
+		options = append(options, client.WithTLS(c.settings.CAFile))
+	}
+	aliasClient, err := client.New(c.settings.Address, options...)
+	if err != nil {
+		return nil, err
+	}
+	c.client = aliasClient
+	return aliasClient, nil
+}
+
+// call limits a single call by the configured timeout, the streams are not limited
+func (c *cli) call(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.settings.Timeout)
+}
+
+func usage() {
+	output := flag.CommandLine.Output()
+	fmt.Fprintf(output, "usage: aliasctl [flags] <command> [command flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(output, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(output, "\nflags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(output, "\nthe flags are read from the %s_* environment variables and from the config file too, "+
+		"run aliasctl <command> -h for the flags of the command\n", envPrefix)
+}
+
+func main() {
+	configPath := flag.String("config", "", "config file, "+defaultConfigHint())
+	registerSettingsFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	settings, err := loadSettings(flag.CommandLine, *configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aliasctl: %s\n", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		c := &cli{settings: settings, out: os.Stdout}
+		err := cmd.run(ctx, c, flag.Args()[1:])
+		if c.client != nil {
+			_ = c.client.Close()
+		}
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			os.Exit(0)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "aliasctl %s: %s\n", name, describeError(err))
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "aliasctl: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// describeError shows the code and the field violations of the API errors
+func describeError(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+	apiErr := apierror.FromGRPCStatus(st, 0)
+	message := fmt.Sprintf("%s: %s", apiErr.Code, apiErr.Message)
+	for _, violation := range apiErr.Details {
+		message += fmt.Sprintf("\n  %s: %s", violation.Field, violation.Description)
+	}
+	return message
+}
+
+// parseFlags parses the flags of the command, the usage lists the arguments before the flags
+func parseFlags(flags *flag.FlagSet, arguments string, args []string) error {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: aliasctl %s [flags] %s\n", flags.Name(), arguments)
+		flags.PrintDefaults()
+	}
+	return flags.Parse(args)
+}
+
+// requireArgs checks the number of the positional arguments of the command
+func requireArgs(flags *flag.FlagSet, min, max int) error {
+	if flags.NArg() < min || max >= 0 && flags.NArg() > max {
+		flags.Usage()
+		return errors.New("wrong number of arguments")
+	}
+	return nil
+}
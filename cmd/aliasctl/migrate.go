@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/xloki21/alias/internal/config"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/repository"
+	"github.com/xloki21/alias/internal/repository/mongodb"
+	"github.com/xloki21/alias/migrations"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func migrateSchema(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configPath := flags.String("config", "", "configuration file of the service")
+	lockTimeout := flags.Duration("lock-timeout", 0, "wait for another replica applying the migrations, "+
+		"migrations.lock-timeout of the configuration by default")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: aliasctl migrate --config <config> up | down [steps] | status | force <version>\n\n")
+		fmt.Fprintf(flags.Output(), "applies the migrations embedded into aliasctl to the storage of the service: "+
+			"up applies the pending ones, down reverts the given number of the applied ones or all of them, "+
+			"force sets the version without running the migrations, e.g. after a failed one\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *configPath == "" {
+		flags.Usage()
+		return errors.New("--config is required")
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing migration command")
+	}
+
+	action, err := migrationAction(flags.Arg(0), flags.Args()[1:])
+	if err != nil {
+		flags.Usage()
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if *lockTimeout <= 0 {
+		*lockTimeout = cfg.Migrations.LockTimeout
+	}
+	migrator, locker, disconnect, err := openMigrator(ctx, cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer disconnect(context.Background())
+
+	if action != nil {
+		if err := migrations.Exclusive(ctx, locker, *lockTimeout, func() error { return action(migrator) }); err != nil {
+			return err
+		}
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	return printMigrationStatus(c, status)
+}
+
+// migrationAction parses the migration command, status changes nothing so its action is nil
+func migrationAction(name string, args []string) (func(migrator *migrations.Migrator) error, error) {
+	switch {
+	case name == "up" && len(args) == 0:
+		return (*migrations.Migrator).Up, nil
+	case name == "down" && len(args) <= 1:
+		steps := 0
+		if len(args) == 1 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid number of steps %q", args[0])
+			}
+			steps = parsed
+		}
+		return func(migrator *migrations.Migrator) error { return migrator.Down(steps) }, nil
+	case name == "status" && len(args) == 0:
+		return nil, nil
+	case name == "force" && len(args) == 1:
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", args[0])
+		}
+		return func(migrator *migrations.Migrator) error { return migrator.Force(version) }, nil
+	case name == "up" || name == "down" || name == "status" || name == "force":
+		return nil, errors.New("wrong number of arguments")
+	default:
+		return nil, fmt.Errorf("unknown migration command %q", name)
+	}
+}
+
+// openMigrator connects to the configured storage, the locker grants the migrations lease shared with the servers
+func openMigrator(ctx context.Context, cfg config.StorageConfig) (*migrations.Migrator, migrations.Locker,
+	func(context.Context) error, error) {
+	switch cfg.Type {
+	case repository.MongoDB:
+		client, err := mongodb.Connect(ctx, cfg.MongoDB.URI, options.Credential{
+			AuthSource: cfg.MongoDB.Credentials.AuthSource,
+			Username:   cfg.MongoDB.Credentials.User,
+			Password:   cfg.MongoDB.Credentials.Password,
+		}, mongoDBServerSelectionTimeout)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		migrator, err := migrations.NewMongoDB(client, cfg.MongoDB.Database)
+		if err != nil {
+			_ = client.Disconnect(context.Background())
+			return nil, nil, nil, err
+		}
+		locker := mongodb.NewLockRepository(client.Database(cfg.MongoDB.Database).Collection(mongodb.LockCollectionName),
+			lockOwner())
+		return migrator, locker, client.Disconnect, nil
+	case repository.InMemory:
+		return nil, nil, nil, fmt.Errorf("%w: %s", migrations.ErrNoMigrations, cfg.Type)
+	default:
+		return nil, nil, nil, fmt.Errorf("%w: %s", domain.ErrUnknownStorageType, cfg.Type)
+	}
+}
+
+// lockOwner identifies aliasctl as the holder of the migrations lease
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("aliasctl-%s-%d", host, os.Getpid())
+}
+
+func printMigrationStatus(c *cli, status migrations.Status) error {
+	if err := writeMigrationStatus(c, status); err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("migration %d failed halfway, fix the schema and run aliasctl migrate force", status.Version)
+	}
+	return nil
+}
+
+func writeMigrationStatus(c *cli, status migrations.Status) error {
+	if c.settings.Output == outputJSON {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Version   uint   `json:"version"`
+			Dirty     bool   `json:"dirty"`
+			Available []uint `json:"available"`
+			Pending   []uint `json:"pending"`
+		}{status.Version, status.Dirty, status.Available, status.Pending})
+	}
+
+	writer := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "VERSION\t%d\n", status.Version)
+	fmt.Fprintf(writer, "DIRTY\t%t\n", status.Dirty)
+	fmt.Fprintf(writer, "AVAILABLE\t%s\n", joinVersions(status.Available))
+	fmt.Fprintf(writer, "PENDING\t%s\n", joinVersions(status.Pending))
+	return writer.Flush()
+}
+
+// joinVersions lists the versions, a dash if there are none
+func joinVersions(versions []uint) string {
+	if len(versions) == 0 {
+		return "-"
+	}
+	formatted := make([]string, len(versions))
+	for index, version := range versions {
+		formatted[index] = strconv.FormatUint(uint64(version), 10)
+	}
+	return strings.Join(formatted, ", ")
+}
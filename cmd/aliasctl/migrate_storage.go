@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/xloki21/alias/internal/config"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/repository"
+	"github.com/xloki21/alias/internal/repository/inmemory"
+	"github.com/xloki21/alias/internal/repository/mongodb"
+	"github.com/xloki21/alias/internal/services/transfersvc"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+const mongoDBServerSelectionTimeout = 5 * time.Second
+
+// checkpointFile is the content of the checkpoint file, the storages guard against resuming another transfer
+type checkpointFile struct {
+	Source     string                 `json:"source"`
+	Target     string                 `json:"target"`
+	Checkpoint transfersvc.Checkpoint `json:"checkpoint"`
+}
+
+func migrateStorage(ctx context.Context, _ *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate-storage", flag.ContinueOnError)
+	from := flags.String("from", "", "configuration file of the source storage")
+	to := flags.String("to", "", "configuration file of the target storage")
+	checkpointPath := flags.String("checkpoint", "migrate-storage.checkpoint.json", "file the progress is saved to and resumed from")
+	batchSize := flags.Int("batch-size", 500, "records copied at once")
+	verifyOnly := flags.Bool("verify-only", false, "compare the storages without copying")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: aliasctl migrate-storage --from <config> --to <config> [flags]\n\n")
+		fmt.Fprintf(flags.Output(), "copies the aliases, the campaigns and the statistics of the source storage to the target one, "+
+			"then compares the storages\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		flags.Usage()
+		return errors.New("both --from and --to are required")
+	}
+	if *batchSize <= 0 {
+		return errors.New("--batch-size must be positive")
+	}
+
+	sourceConfig, err := config.Load(*from)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	targetConfig, err := config.Load(*to)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	sourceName, targetName := storageName(sourceConfig.Storage), storageName(targetConfig.Storage)
+	if sourceName == targetName {
+		return fmt.Errorf("source and target are the same storage %s", sourceName)
+	}
+
+	source, closeSource, err := openStorage(ctx, sourceConfig.Storage, false)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	defer closeSource(context.Background())
+	target, closeTarget, err := openStorage(ctx, targetConfig.Storage, true)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer closeTarget(context.Background())
+
+	transfer := transfersvc.NewTransfer(source, target, *batchSize)
+	if !*verifyOnly {
+		checkpoint, err := loadCheckpoint(*checkpointPath, sourceName, targetName)
+		if err != nil {
+			return err
+		}
+		if checkpoint.Stage != "" {
+			fmt.Printf("resuming from %s, stage %s\n", *checkpointPath, checkpoint.Stage)
+		}
+		fmt.Printf("copying %s to %s\n", sourceName, targetName)
+		checkpoint, err = transfer.Copy(ctx, checkpoint, func(checkpoint transfersvc.Checkpoint) error {
+			return saveCheckpoint(*checkpointPath, checkpointFile{Source: sourceName, Target: targetName, Checkpoint: checkpoint})
+		})
+		if err != nil {
+			return fmt.Errorf("%w, rerun the command to resume from %s", err, *checkpointPath)
+		}
+		printCopied(checkpoint)
+	}
+
+	comparisons, err := transfer.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	if !printComparisons(comparisons) {
+		return errors.New("verification failed: the storages differ")
+	}
+	if !*verifyOnly {
+		if err := os.Remove(*checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageName identifies the storage without its credentials
+func storageName(cfg config.StorageConfig) string {
+	if cfg.Type == repository.InMemory {
+		snapshot, err := filepath.Abs(cfg.Snapshot)
+		if err != nil {
+			snapshot = cfg.Snapshot
+		}
+		return fmt.Sprintf("%s %s", cfg.Type, snapshot)
+	}
+	if cfg.MongoDB == nil {
+		return string(cfg.Type)
+	}
+	uri := cfg.MongoDB.URI
+	if parsed, err := url.Parse(uri); err == nil {
+		uri = parsed.Redacted()
+	}
+	return fmt.Sprintf("%s %s/%s", cfg.Type, uri, cfg.MongoDB.Database)
+}
+
+// openStorage connects to the configured storage, close disconnects from it. The in-memory storage is read from
+// the snapshot saved by the stopped server and may be the source only.
+func openStorage(ctx context.Context, cfg config.StorageConfig, target bool) (transfersvc.Storage, func(context.Context) error, error) {
+	switch cfg.Type {
+	case repository.MongoDB:
+		client, err := mongodb.Connect(ctx, cfg.MongoDB.URI, options.Credential{
+			AuthSource: cfg.MongoDB.Credentials.AuthSource,
+			Username:   cfg.MongoDB.Credentials.User,
+			Password:   cfg.MongoDB.Credentials.Password,
+		}, mongoDBServerSelectionTimeout)
+		if err != nil {
+			return transfersvc.Storage{}, nil, err
+		}
+		db := client.Database(cfg.MongoDB.Database)
+		return transfersvc.Storage{
+			Aliases:   mongodb.NewAliasRepository(db.Collection(mongodb.AliasCollectionName)),
+			Campaigns: mongodb.NewCampaignRepository(db.Collection(mongodb.CampaignCollectionName)),
+			Stats: mongodb.NewStatisticsRepository(
+				db.Collection(mongodb.StatsCollectionName),
+				db.Collection(mongodb.ClicksCollectionName),
+				db.Collection(mongodb.ClickEventsCollectionName),
+			),
+		}, client.Disconnect, nil
+	case repository.InMemory:
+		if target {
+			return transfersvc.Storage{}, nil, errors.New("in-memory storage may be the source only, " +
+				"start the server with the storage to fill it")
+		}
+		if cfg.Snapshot == "" {
+			return transfersvc.Storage{}, nil, errors.New("in-memory storage keeps no data outside of the server process " +
+				"unless storage.snapshot is set")
+		}
+		snapshot := inmemory.Snapshot{
+			Aliases:   inmemory.NewAliasRepository(),
+			Campaigns: inmemory.NewCampaignRepository(),
+			Stats:     inmemory.NewStatisticsRepository(),
+		}
+		if err := snapshot.Load(ctx, cfg.Snapshot); err != nil {
+			return transfersvc.Storage{}, nil, err
+		}
+		return transfersvc.Storage{Aliases: snapshot.Aliases, Campaigns: snapshot.Campaigns, Stats: snapshot.Stats},
+			func(context.Context) error { return nil }, nil
+	default:
+		return transfersvc.Storage{}, nil, fmt.Errorf("%w: %s", domain.ErrUnknownStorageType, cfg.Type)
+	}
+}
+
+// loadCheckpoint reads the checkpoint of the transfer between the storages, a missing file starts the transfer anew
+func loadCheckpoint(path string, source, target string) (transfersvc.Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return transfersvc.Checkpoint{}, nil
+	}
+	if err != nil {
+		return transfersvc.Checkpoint{}, err
+	}
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return transfersvc.Checkpoint{}, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	if file.Source != source || file.Target != target {
+		return transfersvc.Checkpoint{}, fmt.Errorf("checkpoint %s belongs to the transfer from %s to %s, remove it to start anew",
+			path, file.Source, file.Target)
+	}
+	return file.Checkpoint, nil
+}
+
+// saveCheckpoint replaces the checkpoint file at once, so an interrupted write leaves the previous checkpoint
+func saveCheckpoint(path string, file checkpointFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+func printCopied(checkpoint transfersvc.Checkpoint) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STAGE\tCOPIED\tSKIPPED")
+	for _, stage := range transfersvc.Stages {
+		fmt.Fprintf(writer, "%s\t%d\t%d\n", stage, checkpoint.Copied[stage], checkpoint.Skipped[stage])
+	}
+	_ = writer.Flush()
+}
+
+// printComparisons prints the comparisons, reports whether all the stages match
+func printComparisons(comparisons []transfersvc.Comparison) bool {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "STAGE\tSOURCE\tTARGET\tCHECKSUM\tRESULT")
+	match := true
+	for _, comparison := range comparisons {
+		result := "ok"
+		if !comparison.Match() {
+			result, match = "MISMATCH", false
+		}
+		checksum := comparison.Source.Checksum[:16]
+		if comparison.Source.Checksum != comparison.Target.Checksum {
+			checksum += "/" + comparison.Target.Checksum[:16]
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\n",
+			comparison.Stage, comparison.Source.Count, comparison.Target.Count, checksum, result)
+	}
+	_ = writer.Flush()
+	return match
+}
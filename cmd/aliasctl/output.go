@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// print writes the response as JSON or as the table written by table
+func (c *cli) print(response proto.Message, table func(w io.Writer)) error {
+	if c.settings.Output == outputJSON {
+		data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(response)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.out, string(data))
+		return err
+	}
+	writer := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	table(writer)
+	return writer.Flush()
+}
+
+// formatTime formats the timestamp for the tables, empty if it is not set
+func formatTime(value *timestamppb.Timestamp) string {
+	if value == nil {
+		return ""
+	}
+	return value.AsTime().Local().Format(time.DateTime)
+}
+
+// orDash fills the empty cells of the tables
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
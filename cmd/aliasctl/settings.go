@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// envPrefix is the prefix of the environment variables overriding the config file, e.g. ALIASCTL_ADDRESS
+const envPrefix = "ALIASCTL"
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// settings are the connection and output settings shared by the commands
+type settings struct {
+	// Address is the address of the gRPC server
+	Address string `mapstructure:"address"`
+	// Token is sent as a bearer token with every call
+	Token string `mapstructure:"token"`
+	TLS   bool   `mapstructure:"tls"`
+	// CAFile verifies the certificate of the server instead of the system certificates
+	CAFile  string        `mapstructure:"ca-file"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	Output  string        `mapstructure:"output"`
+}
+
+// registerSettingsFlags defines the flags of the settings, the defaults are applied by loadSettings
+func registerSettingsFlags(fs *flag.FlagSet) {
+	fs.String("address", "localhost:8081", "address of the gRPC server")
+	fs.String("token", "", "bearer token sent with every call")
+	fs.Bool("tls", false, "connect over TLS")
+	fs.String("ca-file", "", "CA certificates verifying the server, the system ones if empty")
+	fs.Duration("timeout", 30*time.Second, "timeout of a single call")
+	fs.String("output", outputTable, "output format: table | json")
+}
+
+// defaultConfigPath is the config file read if neither -config nor ALIASCTL_CONFIG is set
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aliasctl", "config.yaml")
+}
+
+func defaultConfigHint() string {
+	return fmt.Sprintf("%s_CONFIG or %s if it exists", envPrefix, defaultConfigPath())
+}
+
+// loadSettings merges the settings in the order of precedence: the flags set on the command line,
+// the environment variables, the config file, the defaults of the flags
+func loadSettings(fs *flag.FlagSet, configPath string) (settings, error) {
+	v := viper.New()
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name != "config" {
+			v.SetDefault(f.Name, f.DefValue)
+		}
+	})
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	if configPath == "" {
+		configPath = os.Getenv(envPrefix + "_CONFIG")
+	}
+	if configPath == "" {
+		if path := defaultConfigPath(); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				configPath = path
+			}
+		}
+	}
+	if configPath != "" {
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return settings{}, fmt.Errorf("config %s: %w", configPath, err)
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			v.Set(f.Name, f.Value.String())
+		}
+	})
+
+	var s settings
+	if err := v.Unmarshal(&s); err != nil {
+		return settings{}, err
+	}
+	if s.Output != outputTable && s.Output != outputJSON {
+		return settings{}, fmt.Errorf("unknown output format %q, must be one of table, json", s.Output)
+	}
+	if s.Timeout <= 0 {
+		return settings{}, errors.New("timeout must be positive")
+	}
+	return s, nil
+}
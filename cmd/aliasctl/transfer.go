@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/controller/importer"
+	"github.com/xloki21/alias/internal/domain"
+	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	// importBatchSize is the number of the rows sent in a message of the import stream
+	importBatchSize = 500
+)
+
+var (
+	aliasCSVHeader = []string{"key", "url", "target", "status", "isPermanent", "triesLeft", "redirectType", "campaign",
+		"protected", "interstitial", "createdAt", "activatesAt", "deletedAt"}
+	clickCSVHeader = []string{"id", "key", "campaign", "rule", "variant", "occurredAt"}
+)
+
+func importAliases(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "csv | ndjson, by the extension of the file if not set")
+	dryRun := flags.Bool("dry-run", false, "validate the rows without saving the aliases")
+	failuresOnly := flags.Bool("failures-only", false, "report the failed rows only")
+	if err := parseFlags(flags, "FILE", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 1, 1); err != nil {
+		return err
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var source domain.ImportSource
+	switch *format {
+	case formatCSV:
+		source = importer.NewCSVSource(bufio.NewReader(file))
+	case formatNDJSON, "jsonl":
+		source = importer.NewNDJSONSource(bufio.NewReader(file))
+	default:
+		return fmt.Errorf("unknown format %q, must be one of csv, ndjson", *format)
+	}
+
+	// the rows failed to be read are reported by the line of the file, the others are sent to the server
+	// which numbers them by the position in the stream
+	var rows []*aliasapi.ImportRow
+	var lines []int
+	response := &aliasapi.ImportResponse{DryRun: *dryRun}
+	for {
+		row, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if row.Err != nil {
+			apiErr := apierror.FromError(row.Err)
+			response.Failed++
+			response.Results = append(response.Results, &aliasapi.ImportResult{
+				Line: int32(row.Line), Key: row.Key, Status: string(domain.ImportFailed),
+				Code: string(apiErr.Code), Message: apiErr.Message,
+			})
+			continue
+		}
+		rows = append(rows, importRow(row))
+		lines = append(lines, row.Line)
+	}
+	response.Rows = int32(len(rows)) + response.Failed
+
+	if len(rows) > 0 {
+		aliasClient, err := c.connect()
+		if err != nil {
+			return err
+		}
+		stream, err := aliasClient.Api.ImportAliases(ctx)
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(rows); start += importBatchSize {
+			request := &aliasapi.ImportRequest{Rows: rows[start:min(start+importBatchSize, len(rows))]}
+			if start == 0 {
+				request.DryRun, request.FailuresOnly = *dryRun, *failuresOnly
+			}
+			if err := stream.Send(request); err != nil {
+				break // the error is returned by CloseAndRecv
+			}
+		}
+		imported, err := stream.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		response.Imported = imported.GetImported()
+		response.Failed += imported.GetFailed()
+		for _, result := range imported.GetResults() {
+			if position := int(result.GetLine()) - 1; position >= 0 && position < len(lines) {
+				result.Line = int32(lines[position])
+			}
+			response.Results = append(response.Results, result)
+		}
+		sort.SliceStable(response.Results, func(i, j int) bool {
+			return response.Results[i].GetLine() < response.Results[j].GetLine()
+		})
+	}
+
+	err = c.print(response, func(w io.Writer) {
+		fmt.Fprintln(w, "LINE\tKEY\tSTATUS\tCODE\tMESSAGE")
+		for _, result := range response.GetResults() {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", result.GetLine(), orDash(result.GetKey()), result.GetStatus(),
+				orDash(result.GetCode()), result.GetMessage())
+		}
+		fmt.Fprintf(w, "\nrows: %d, imported: %d, failed: %d", response.GetRows(), response.GetImported(), response.GetFailed())
+		if response.GetDryRun() {
+			fmt.Fprint(w, " (dry run)")
+		}
+		fmt.Fprintln(w)
+	})
+	if err == nil && response.GetFailed() > 0 {
+		err = fmt.Errorf("%d of %d rows failed", response.GetFailed(), response.GetRows())
+	}
+	return err
+}
+
+// importRow converts a row read from the file to the row of the import stream
+func importRow(row domain.ImportRow) *aliasapi.ImportRow {
+	message := &aliasapi.ImportRow{
+		Key:          row.Key,
+		Url:          row.Request.URL.String(),
+		RedirectType: aliasapi.RedirectType(row.Request.RedirectType),
+		Campaign:     row.Request.Campaign,
+	}
+	if !row.Request.Params.IsPermanent {
+		maxUsageCount := uint64(row.Request.Params.TriesLeft)
+		message.MaxUsageCount = &maxUsageCount
+	}
+	return message
+}
+
+func exportData(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	request := &aliasapi.ExportRequest{}
+	flags.StringVar(&request.Data, "data", "aliases", "aliases | clicks")
+	format := flags.String("format", formatNDJSON, "ndjson | csv")
+	flags.StringVar(&request.Status, "status", "", "aliases: active | scheduled | disabled | expired | deleted, any status but deleted if empty")
+	flags.StringVar(&request.Campaign, "campaign", "", "the records of the campaign only")
+	flags.StringVar(&request.Key, "key", "", "clicks: the clicks of the alias only")
+	from := flags.String("from", "", "clicks: RFC 3339 time of the first click, inclusive")
+	to := flags.String("to", "", "clicks: RFC 3339 time of the last click, exclusive")
+	flags.StringVar(&request.Cursor, "cursor", "", "key or click id the export continues after")
+	output := flags.String("o", "", "file the records are written to, standard output if not set")
+	if err := parseFlags(flags, "", args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags, 0, 0); err != nil {
+		return err
+	}
+	if *format != formatNDJSON && *format != formatCSV {
+		return fmt.Errorf("unknown format %q, must be one of ndjson, csv", *format)
+	}
+	var err error
+	if request.From, err = parseTimestamp(*from); err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	if request.To, err = parseTimestamp(*to); err != nil {
+		return fmt.Errorf("to: %w", err)
+	}
+
+	aliasClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	stream, err := aliasClient.Api.Export(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	out := c.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	buffered := bufio.NewWriter(out)
+	writer := &recordWriter{out: buffered}
+	if *format == formatCSV {
+		writer.csv = csv.NewWriter(buffered)
+		header := aliasCSVHeader
+		if request.GetData() == "clicks" {
+			header = clickCSVHeader
+		}
+		if err := writer.csv.Write(header); err != nil {
+			return err
+		}
+	}
+
+	exported := 0
+	for {
+		record, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// the records written so far are kept, the export continues after the last of them with -cursor
+			_ = writer.flush()
+			return fmt.Errorf("%w, %d records exported, continue with -cursor %s", err, exported, writer.last)
+		}
+		if err := writer.write(record); err != nil {
+			return err
+		}
+		exported++
+	}
+	if err := writer.flush(); err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(c.out, "%d records exported to %s\n", exported, *output)
+	}
+	return nil
+}
+
+// recordWriter writes the exported records as NDJSON or CSV, last is the key or the id of the last record written
+type recordWriter struct {
+	out  *bufio.Writer
+	csv  *csv.Writer
+	last string
+}
+
+func (w *recordWriter) write(record *aliasapi.ExportRecord) error {
+	if alias := record.GetAlias(); alias != nil {
+		w.last = alias.GetKey()
+	} else {
+		w.last = record.GetClick().GetId()
+	}
+
+	if w.csv != nil {
+		return w.csv.Write(csvRecord(record))
+	}
+	var message proto.Message = record.GetAlias()
+	if record.GetClick() != nil {
+		message = record.GetClick()
+	}
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := w.out.Write(data); err != nil {
+		return err
+	}
+	return w.out.WriteByte('\n')
+}
+
+func (w *recordWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return w.out.Flush()
+}
+
+// csvRecord returns the columns of the record in the order of its CSV header
+func csvRecord(record *aliasapi.ExportRecord) []string {
+	if click := record.GetClick(); click != nil {
+		return []string{click.GetId(), click.GetKey(), click.GetCampaign(), click.GetRule(), click.GetVariant(),
+			csvTime(click.GetOccurredAt())}
+	}
+	alias := record.GetAlias()
+	return []string{alias.GetKey(), alias.GetUrl(), alias.GetTarget(), alias.GetStatus(),
+		strconv.FormatBool(alias.GetIsPermanent()), strconv.FormatInt(alias.GetTriesLeft(), 10),
+		strconv.Itoa(int(alias.GetRedirectType())), alias.GetCampaign(), strconv.FormatBool(alias.GetProtected()),
+		strconv.FormatBool(alias.GetInterstitial()), csvTime(alias.GetCreatedAt()), csvTime(alias.GetActivatesAt()),
+		csvTime(alias.GetDeletedAt())}
+}
+
+func csvTime(value *timestamppb.Timestamp) string {
+	if value == nil {
+		return ""
+	}
+	return value.AsTime().UTC().Format(time.RFC3339Nano)
+}
@@ -20,16 +20,19 @@ func main() {
 		}
 	}
 
-	cfg, err := config.MustLoad()
+	runtime, err := config.MustLoad()
 	if err != nil {
 		log.Fatal("failed to init application config: " + err.Error())
 	}
 	zap.S().Infow("core", zap.String("state", "application config loaded"))
 
-	application, err := app.New(cfg)
+	application, err := app.New(runtime.Config())
 	if err != nil {
 		zap.S().Fatal("failed to start application", zap.Error(err))
 	}
+	// the settings safe to change are applied on every change of the config file, the others require a restart
+	runtime.OnReload(application.ApplyConfig)
+	runtime.Watch()
 
 	if err := application.Run(context.Background()); err != nil {
 		zap.S().Fatal("failed to start application", zap.Error(err))
@@ -2,34 +2,49 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"expvar"
 	"fmt"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/xloki21/alias/internal/config"
+	"github.com/xloki21/alias/internal/controller/apierror"
 	"github.com/xloki21/alias/internal/controller/grpcc"
 	"github.com/xloki21/alias/internal/controller/grpcc/interceptors"
 	"github.com/xloki21/alias/internal/controller/httpc"
 	"github.com/xloki21/alias/internal/controller/httpc/mw"
+	"github.com/xloki21/alias/internal/controller/httpc/pages"
 	"github.com/xloki21/alias/internal/domain"
 	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
+	"github.com/xloki21/alias/internal/infrastructure/health"
+	"github.com/xloki21/alias/internal/infrastructure/lifecycle"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/periodic"
 	"github.com/xloki21/alias/internal/infrastructure/squeue"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
 	"github.com/xloki21/alias/internal/repository"
 	"github.com/xloki21/alias/internal/repository/inmemory"
 	"github.com/xloki21/alias/internal/repository/mongodb"
 	"github.com/xloki21/alias/internal/services/aliassvc"
+	"github.com/xloki21/alias/internal/services/campaignsvc"
 	"github.com/xloki21/alias/internal/services/managersvc"
 	"github.com/xloki21/alias/internal/services/statssvc"
+	"github.com/xloki21/alias/migrations"
 	"github.com/xloki21/alias/pkg/keygen"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -46,89 +61,233 @@ const (
 const (
 	apiV1               = "/api/v1"
 	endpointAlias       = apiV1 + "/alias"
+	endpointCampaign    = apiV1 + "/campaign"
 	endpointHealthcheck = apiV1 + "/healthcheck"
 	endpointRedirect    = ""
+	endpointLivez       = "/livez"
+	endpointReadyz      = "/readyz"
+	endpointMetrics     = "/debug/vars"
 )
 
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	defaultDrainTimeout    = 10 * time.Second
+)
+
+const (
+	consumerHeartbeatMaxAge = 5 * time.Second
+	grpcHealthCheckInterval = 5 * time.Second
+)
+
+const (
+	reaperLock = "alias reaper"
+	// reaperLeaseIntervals is the lease of the reaper in reaper intervals, the holder extends it on every run
+	reaperLeaseIntervals = 2
+)
+
+// lockRepository grants the leases of the jobs run by a single replica at a time
+type lockRepository interface {
+	periodic.Locker
+	// Release gives up the lease held by this replica, the lease held by another replica is kept
+	Release(ctx context.Context, name string) error
+}
+
 type Application struct {
 	HTTPServer        *http.Server
 	GRPCGatewayServer *http.Server
+	DebugServer       *http.Server // serves the metrics to the internal network, nil if turned off
 	GRPCServer        *grpc.Server
 	grpcListener      net.Listener
+	lifecycle         *lifecycle.Manager
+	shutdownTimeout   time.Duration
+	aliasService      *aliassvc.Alias
+	httpController    *httpc.Controller
 }
 
 func New(cfg config.AppConfig) (*Application, error) {
 	ctx := context.Background()
 
-	aliasUsedQ := squeue.New()
-	aliasExpiredQ := squeue.New()
+	if cfg.Shutdown.Timeout <= 0 {
+		cfg.Shutdown.Timeout = defaultShutdownTimeout
+	}
+	if cfg.Shutdown.DrainTimeout <= 0 {
+		cfg.Shutdown.DrainTimeout = defaultDrainTimeout
+	}
+
+	exporter, err := tracing.NewExporter(ctx, cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	if err != nil {
+		zap.S().Fatalw("core", zap.String("application error", err.Error()))
+		return nil, err
+	}
+	tracerProvider := tracing.NewTracerProvider(exporter, cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio)
+
+	aliasUsedQ := squeue.NewWithCapacity(cfg.Events.QueueCapacity)
+	aliasExpiredQ := squeue.NewWithCapacity(cfg.Events.QueueCapacity)
+	aliasClickedQ := squeue.NewWithCapacity(cfg.Events.QueueCapacity)
+	// every alias usage both updates the alias and is counted in the statistics
+	aliasUsedFanout := squeue.NewFanout(aliasUsedQ, aliasClickedQ)
 
 	var managerService *managersvc.Manager
 	var statsService *statssvc.Statistics
 	var aliasService *aliassvc.Alias
+	var campaignService *campaignsvc.Campaign
+
+	var lockRepo lockRepository
+	var mongoClient *mongo.Client
+	// saveSnapshot saves the in-memory storage on shutdown, nil if it is not kept
+	var saveSnapshot func(ctx context.Context) error
 
 	keyGen := keygen.NewURLSafeRandomStringGenerator()
+	appLifecycle := lifecycle.New()
+	appHealth := health.New(appLifecycle)
 
 	switch cfg.Storage.Type {
 	case repository.MongoDB:
-
-		clientOptions := options.Client().
-			ApplyURI(cfg.Storage.MongoDB.URI).
-			SetServerSelectionTimeout(mongoDBServerSelectionTimeout)
-
-		if cfg.Storage.MongoDB.Credentials.AuthSource != "" {
-			credential := options.Credential{
-				AuthSource: cfg.Storage.MongoDB.Credentials.AuthSource,
-				Username:   cfg.Storage.MongoDB.Credentials.User,
-				Password:   cfg.Storage.MongoDB.Credentials.Password,
-			}
-			clientOptions.SetAuth(credential)
-		}
-
-		client, err := mongo.Connect(ctx, clientOptions)
-
+		client, err := mongodb.Connect(ctx, cfg.Storage.MongoDB.URI, options.Credential{
+			AuthSource: cfg.Storage.MongoDB.Credentials.AuthSource,
+			Username:   cfg.Storage.MongoDB.Credentials.User,
+			Password:   cfg.Storage.MongoDB.Credentials.Password,
+		}, mongoDBServerSelectionTimeout)
 		if err != nil {
-			zap.S().Fatalf("cannot connect to mongodb: %s", cfg.Storage.MongoDB.URI)
+			zap.S().Fatalf("mongodb: %s", err.Error())
 			return nil, err
 		}
-
-		if err := client.Ping(ctx, nil); err != nil {
-			zap.S().Fatalf("mongodb: ping failed: %s", err.Error())
-			return nil, err
-		}
-
 		db := client.Database(cfg.Storage.MongoDB.Database)
 
-		if db == nil {
-			zap.S().Fatalf("cannot connect to mongodb: %s", cfg.Storage.MongoDB.URI)
-			return nil, err
-		}
+		mongoClient = client
+		appHealth.Register(health.NewMongoDBChecker(client))
 
 		aliasRepo := mongodb.NewAliasRepository(db.Collection(mongodb.AliasCollectionName))
-		statsRepo := mongodb.NewStatisticsRepository(db.Collection(mongodb.StatsCollectionName))
+		statsRepo := mongodb.NewStatisticsRepository(
+			db.Collection(mongodb.StatsCollectionName),
+			db.Collection(mongodb.ClicksCollectionName),
+			db.Collection(mongodb.ClickEventsCollectionName),
+		)
+		campaignRepo := mongodb.NewCampaignRepository(db.Collection(mongodb.CampaignCollectionName))
+		lockRepo = mongodb.NewLockRepository(db.Collection(mongodb.LockCollectionName), instanceID())
+		if cfg.Migrations.Auto {
+			migrator, err := migrations.NewMongoDB(client, cfg.Storage.MongoDB.Database)
+			if err == nil {
+				err = applyMigrations(ctx, migrator, lockRepo, cfg.Migrations.LockTimeout)
+			}
+			if err != nil {
+				zap.S().Errorw("core", zap.String("state", "failed to migrate the storage"), zap.Error(err))
+				return nil, err
+			}
+		}
 		managerService = managersvc.NewManager(aliasRepo, aliasUsedQ)
-		statsService = statssvc.NewStatistics(statsRepo, aliasExpiredQ)
-		aliasService = aliassvc.NewAlias(aliasExpiredQ, aliasUsedQ, aliasRepo, keyGen)
+		statsService = statssvc.NewStatistics(statsRepo, aliasExpiredQ, aliasClickedQ)
+		aliasService = aliassvc.NewAlias(aliasExpiredQ, aliasUsedFanout, aliasRepo, keyGen, campaignRepo)
+		campaignService = campaignsvc.NewCampaign(campaignRepo)
 
 	case repository.InMemory:
 		aliasRepo := inmemory.NewAliasRepository()
 		statsRepo := inmemory.NewStatisticsRepository()
+		campaignRepo := inmemory.NewCampaignRepository()
+		lockRepo = inmemory.NewLockRepository()
+		if cfg.Storage.Snapshot != "" {
+			snapshot := inmemory.Snapshot{Aliases: aliasRepo, Campaigns: campaignRepo, Stats: statsRepo}
+			// the first start has no snapshot yet
+			if err := snapshot.Load(ctx, cfg.Storage.Snapshot); err != nil && !errors.Is(err, os.ErrNotExist) {
+				zap.S().Errorw("core", zap.String("state", "failed to load the snapshot"), zap.Error(err))
+				return nil, err
+			}
+			saveSnapshot = func(ctx context.Context) error {
+				return snapshot.Save(ctx, cfg.Storage.Snapshot)
+			}
+		}
 		managerService = managersvc.NewManager(aliasRepo, aliasUsedQ)
-		statsService = statssvc.NewStatistics(statsRepo, aliasExpiredQ)
-		aliasService = aliassvc.NewAlias(aliasExpiredQ, aliasUsedQ, aliasRepo, keyGen)
+		statsService = statssvc.NewStatistics(statsRepo, aliasExpiredQ, aliasClickedQ)
+		aliasService = aliassvc.NewAlias(aliasExpiredQ, aliasUsedFanout, aliasRepo, keyGen, campaignRepo)
+		campaignService = campaignsvc.NewCampaign(campaignRepo)
 
 	default:
 		zap.S().Fatalf("unknown storage type: %s", cfg.Storage.Type)
 		return nil, domain.ErrUnknownStorageType
 	}
-	managerService.Process(ctx)
-	statsService.Process(ctx)
+	ctrlHTTP := httpc.NewController(aliasService, campaignService, statsService, appHealth, cfg.Service.BaseURL)
+	if err := applyConfig(cfg, aliasService, ctrlHTTP); err != nil {
+		zap.S().Errorw("core", zap.String("state", "invalid redirect configuration"), zap.Error(err))
+		return nil, err
+	}
+
+	// consumers are stopped explicitly on shutdown, after the queues are drained
+	consumersCtx, stopConsumers := context.WithCancel(ctx)
+	managerService.Process(consumersCtx)
+	statsService.Process(consumersCtx)
+	appHealth.Register(health.NewHeartbeatChecker("manager", managerService, consumerHeartbeatMaxAge))
+	appHealth.Register(health.NewHeartbeatChecker("statistics", statsService, consumerHeartbeatMaxAge))
+
+	// the aliases removed longer than the retention ago are deleted for good, zero retention keeps them forever
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	var jobs []*periodic.Job
+	if cfg.Trash.Retention > 0 && cfg.Trash.PurgeInterval > 0 {
+		jobs = append(jobs, periodic.New("trash purge", cfg.Trash.PurgeInterval, func(ctx context.Context) error {
+			purged, err := aliasService.PurgeTrash(ctx, cfg.Trash.Retention)
+			if err == nil && purged > 0 {
+				zap.S().Infow("core", zap.String("state", "trash purged"), zap.Int64("aliases", purged))
+			}
+			return err
+		}))
+	}
+	// the clicks of the log older than the retention are deleted, the counters are kept
+	if cfg.Clicks.Retention > 0 && cfg.Clicks.PurgeInterval > 0 {
+		jobs = append(jobs, periodic.New("click log purge", cfg.Clicks.PurgeInterval, func(ctx context.Context) error {
+			purged, err := statsService.PurgeClicks(ctx, cfg.Clicks.Retention)
+			if err == nil && purged > 0 {
+				zap.S().Infow("core", zap.String("state", "click log purged"), zap.Int64("clicks", purged))
+			}
+			return err
+		}))
+	}
+	// a single replica deactivates the aliases with no uses left or past their expiration time, reporting them expired
+	// to the statistics
+	// releaseReaper gives the reaper lease up on shutdown, nil if the reaper is turned off
+	var releaseReaper func(ctx context.Context) error
+	if cfg.Reaper.Interval > 0 {
+		lease := reaperLeaseIntervals * cfg.Reaper.Interval
+		var leased atomic.Bool
+		releaseReaper = func(ctx context.Context) error {
+			if !leased.Load() {
+				return nil
+			}
+			return lockRepo.Release(ctx, reaperLock)
+		}
+		jobs = append(jobs, periodic.New("alias reaper", cfg.Reaper.Interval, periodic.Exclusive(lockRepo, reaperLock, lease,
+			func(ctx context.Context) error {
+				leased.Store(true)
+				reaped, err := aliasService.ReapExpired(ctx, cfg.Reaper.BatchSize)
+				if reaped > 0 {
+					zap.S().Infow("core", zap.String("state", "expired aliases reaped"), zap.Int("aliases", reaped))
+				}
+				return err
+			})))
+	}
+	for _, job := range jobs {
+		job.Start(jobsCtx)
+	}
 
 	zap.S().Infow("core", zap.String("state", "selected storage type"), zap.String("type", string(cfg.Storage.Type)))
 
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(interceptors.LoggingInterceptor))
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			interceptors.TracingInterceptor,
+			interceptors.RequestIDInterceptor,
+			interceptors.LoggingInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.TracingStreamInterceptor,
+			interceptors.RequestIDStreamInterceptor,
+			interceptors.LoggingStreamInterceptor,
+		),
+	)
 	reflection.Register(grpcServer)
-	aliasapi.RegisterAliasAPIServer(grpcServer, grpcc.NewController(aliasService, cfg.Service.BaseURL))
+	aliasapi.RegisterAliasAPIServer(grpcServer, grpcc.NewController(aliasService, campaignService, statsService, appHealth, cfg.Service.BaseURL))
+
+	grpcHealthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, grpcHealthServer)
+	grpcHealthCtx, stopGRPCHealth := context.WithCancel(ctx)
+	appHealth.ServeGRPC(grpcHealthCtx, grpcHealthServer, grpcHealthCheckInterval, aliasapi.AliasAPI_ServiceDesc.ServiceName)
 
 	listener, err := net.Listen("tcp", cfg.Service.GRPC)
 	if err != nil {
@@ -136,8 +295,15 @@ func New(cfg config.AppConfig) (*Application, error) {
 		return nil, err
 	}
 
-	gwmux := runtime.NewServeMux()
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	gwmux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithErrorHandler(apierror.GatewayErrorHandler),
+	)
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(interceptors.TracingClientInterceptor),
+		grpc.WithStreamInterceptor(interceptors.TracingStreamClientInterceptor),
+	}
 
 	if err := aliasapi.RegisterAliasAPIHandlerFromEndpoint(ctx, gwmux, cfg.Service.GRPC, opts); err != nil {
 		zap.S().Fatalw("core", zap.String("application error", err.Error()))
@@ -154,16 +320,132 @@ func New(cfg config.AppConfig) (*Application, error) {
 		GRPCServer: grpcServer,
 		GRPCGatewayServer: &http.Server{
 			Addr:    cfg.Service.GRPCGateway,
-			Handler: gwmux,
+			Handler: mw.Use(gwmux.ServeHTTP, mw.Tracing, mw.RequestID),
 		},
-		grpcListener: listener,
+		DebugServer:     newDebugServer(cfg.Service.Debug),
+		grpcListener:    listener,
+		lifecycle:       appLifecycle,
+		shutdownTimeout: cfg.Shutdown.Timeout,
+		aliasService:    aliasService,
+		httpController:  ctrlHTTP,
 	}
-	ctrlHTTP := httpc.NewController(aliasService, cfg.Service.BaseURL)
+
+	// shutdown steps run in the order of registration: stop accepting traffic first,
+	// then drain the event queues and release the storage
+	appLifecycle.OnShutdown("gRPC health service", func(ctx context.Context) error {
+		stopGRPCHealth()
+		return nil
+	})
+	appLifecycle.OnShutdown("HTTP server", app.HTTPServer.Shutdown)
+	appLifecycle.OnShutdown("gRPC-gateway server", app.GRPCGatewayServer.Shutdown)
+	if app.DebugServer != nil {
+		appLifecycle.OnShutdown("debug server", app.DebugServer.Shutdown)
+	}
+	appLifecycle.OnShutdown("gRPC server", func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+	appLifecycle.OnShutdown("background jobs", func(ctx context.Context) error {
+		stopJobs()
+		var errs []error
+		for _, job := range jobs {
+			if err := job.Wait(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", job.Name(), err))
+			}
+		}
+		// another replica takes the reaping over without waiting for the lease to expire
+		if releaseReaper != nil {
+			if err := releaseReaper(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+	appLifecycle.OnShutdown("event consumers", func(ctx context.Context) error {
+		defer stopConsumers()
+
+		drainCtx, cancel := context.WithTimeout(ctx, cfg.Shutdown.DrainTimeout)
+		defer cancel()
+
+		reports := []lifecycle.DrainReport{
+			lifecycle.Drain(drainCtx, "alias used", aliasUsedQ, managerService),
+		}
+		reports = append(reports, lifecycle.DrainAll(drainCtx, statsService,
+			lifecycle.NamedQueue{Name: "alias expired", Queue: aliasExpiredQ},
+			lifecycle.NamedQueue{Name: "alias clicked", Queue: aliasClickedQ},
+		)...)
+		for _, report := range reports {
+			zap.S().Infow("core",
+				zap.String("state", "event queue drained"),
+				zap.String("queue", report.Queue),
+				zap.Int("flushed", report.Flushed),
+				zap.Int("dropped", report.Dropped))
+		}
+		return nil
+	})
+	if mongoClient != nil {
+		appLifecycle.OnShutdown("mongodb client", mongoClient.Disconnect)
+	}
+	if saveSnapshot != nil {
+		appLifecycle.OnShutdown("in-memory snapshot", saveSnapshot)
+	}
+	if tracerProvider != nil {
+		appLifecycle.OnShutdown("tracer provider", tracerProvider.Shutdown)
+	}
+	renderer, err := pages.NewRenderer(cfg.Pages.Dir)
+	if err != nil {
+		zap.S().Errorw("core", zap.String("state", "invalid pages configuration"), zap.Error(err))
+		return nil, err
+	}
+	ctrlHTTP.SetPages(renderer)
 	app.initializeRoutes(ctrlHTTP)
 
 	return app, nil
 }
 
+// ApplyConfig applies the settings which are safe to change on the running application: the redirect defaults,
+// the password attempt limits, the blocked domains and the request throttling. The other settings are read by New only.
+func (a *Application) ApplyConfig(cfg config.AppConfig) error {
+	return applyConfig(cfg, a.aliasService, a.httpController)
+}
+
+// applyConfig parses all the settings before changing any, so that a rejected configuration is not applied in part
+func applyConfig(cfg config.AppConfig, aliasService *aliassvc.Alias, ctrlHTTP *httpc.Controller) error {
+	redirectType := domain.RedirectType(cfg.Redirect.DefaultType)
+	if !redirectType.IsValid() {
+		return fmt.Errorf("%w: %d", domain.ErrInvalidRedirectType, redirectType)
+	}
+	notActive, err := domain.ParseNotActiveResponse(cfg.Redirect.NotActive)
+	if err != nil {
+		return err
+	}
+	if cfg.Throttle.MaxRequests <= 0 {
+		return fmt.Errorf("invalid request limit %d", cfg.Throttle.MaxRequests)
+	}
+
+	if err := aliasService.SetDefaultRedirectType(redirectType); err != nil {
+		return err
+	}
+	mw.SetMaxIncomingRequests(cfg.Throttle.MaxRequests)
+	aliasService.SetBlockedDomains(cfg.Policy.BlockedDomains)
+	ctrlHTTP.SetRedirectCacheMaxAge(cfg.Redirect.CacheMaxAge)
+	ctrlHTTP.SetCountryHeader(cfg.Redirect.CountryHeader)
+	ctrlHTTP.SetPasswordAttemptLimit(cfg.Password.MaxAttempts, cfg.Password.Window)
+	ctrlHTTP.SetClientIPHeader(cfg.Password.ClientIPHeader)
+	ctrlHTTP.SetNotActiveResponse(notActive)
+	return nil
+}
+
 func (a *Application) Run(ctx context.Context) error {
 	ctx, cancelFn := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer cancelFn()
@@ -187,6 +469,17 @@ func (a *Application) Run(ctx context.Context) error {
 		}
 	}()
 
+	if a.DebugServer != nil {
+		go func() {
+			zap.S().Infow("debug", zap.String("state", fmt.Sprintf("start server, listening on %s", a.DebugServer.Addr)))
+			if err := a.DebugServer.ListenAndServe(); err != nil {
+				if !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}
+		}()
+	}
+
 	go func() {
 		zap.S().Infow("gRPC", zap.String("state", fmt.Sprintf("start server, listening on %s", a.grpcListener.Addr())))
 		if err := a.GRPCServer.Serve(a.grpcListener); err != nil {
@@ -197,36 +490,95 @@ func (a *Application) Run(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
 		zap.S().Infow("core", zap.String("state", "application graceful shutdown begins"))
-		zap.S().Infow("core", zap.String("state", "shutting down http server"))
-		err := a.HTTPServer.Shutdown(ctx)
-		if err != nil {
-			return err
-		}
-		zap.S().Infow("core", zap.String("state", "HTTP server stopped"))
-		zap.S().Infow("core", zap.String("state", "shutting down gRPC-gateway server"))
-
-		err = a.GRPCGatewayServer.Shutdown(ctx)
-		if err != nil {
-			return err
-		}
-		zap.S().Infow("core", zap.String("state", "gRPC-gateway server stopped"))
-
-		zap.S().Infow("core", zap.String("state", "shutting down gRPC-server"))
-		a.GRPCServer.GracefulStop()
-		zap.S().Infow("core", zap.String("state", "gRPC-server stopped"))
-		return nil
+		return a.Shutdown(context.Background())
 	case err := <-errChan:
 		zap.S().Fatalw("core", zap.String("application error", err.Error()))
 		return err
 	}
 }
 
+// Shutdown stops the application within the configured shutdown timeout
+func (a *Application) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, a.shutdownTimeout)
+	defer cancel()
+
+	if err := a.lifecycle.Shutdown(ctx); err != nil {
+		return err
+	}
+	zap.S().Infow("core", zap.String("state", "application stopped"))
+	return nil
+}
+
+// newDebugServer serves the expvar metrics apart from the public routes, they expose the command line and the memory
+// statistics of the process. The server is nil if the address is empty.
+func newDebugServer(address string) *http.Server {
+	if address == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(endpointMetrics, mw.Use(expvar.Handler().ServeHTTP, mw.PanicRecovery))
+	return &http.Server{
+		Addr:         address,
+		ReadTimeout:  httpServerReadTimeout,
+		WriteTimeout: httpServerWriteTimeout,
+		Handler:      mux,
+	}
+}
+
 func (a *Application) initializeRoutes(ctrl *httpc.Controller) {
 	zap.S().Infow("core", zap.String("state", "initialize http-routes"))
 	mux := http.NewServeMux()
-	mux.HandleFunc(endpointAlias, mw.Use(ctrl.CreateAlias, mw.RequestThrottler, mw.Logging, mw.PanicRecovery))
-	mux.HandleFunc(endpointHealthcheck, mw.Use(ctrl.Healthcheck, mw.RequestThrottler, mw.Logging, mw.PanicRecovery))
-	mux.HandleFunc(endpointAlias+"/{key}", mw.Use(ctrl.RemoveAlias, mw.RequestThrottler, mw.Logging, mw.PanicRecovery))
-	mux.HandleFunc(endpointRedirect+"/{key}", mw.Use(ctrl.Redirect, mw.RequestThrottler, mw.Logging, mw.PanicRecovery))
+	mux.HandleFunc(endpointAlias, mw.Use(ctrl.Aliases, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointHealthcheck, mw.Use(ctrl.Healthcheck, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointLivez, mw.Use(ctrl.Livez, mw.PanicRecovery))
+	mux.HandleFunc(endpointReadyz, mw.Use(ctrl.Readyz, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/export", mw.Use(ctrl.Export, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/import", mw.Use(ctrl.Import, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/rules", mw.Use(ctrl.Rules, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/variants", mw.Use(ctrl.Variants, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/restore", mw.Use(ctrl.Restore, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/disable", mw.Use(ctrl.Disable, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/enable", mw.Use(ctrl.Enable, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/info", mw.Use(ctrl.Info, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/activation", mw.Use(ctrl.Activation, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/qr", mw.Use(ctrl.QRCode, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}/stats", mw.Use(ctrl.Stats, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointAlias+"/{key}", mw.Use(ctrl.RemoveAlias, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointCampaign, mw.Use(ctrl.Campaigns, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointCampaign+"/{name}", mw.Use(ctrl.Campaign, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointRedirect+"/{key}", mw.Use(ctrl.Redirect, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
+	mux.HandleFunc(endpointRedirect+"/{key}/{path...}", mw.Use(ctrl.Redirect, mw.RequestThrottler, mw.Logging, mw.Tracing, mw.PanicRecovery, mw.RequestID))
 	a.HTTPServer.Handler = mux
 }
+
+// gatewayHeaderMatcher forwards the request ID header to the gRPC-server as is
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, logging.HeaderRequestID) {
+		return logging.MetadataRequestID, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// applyMigrations applies the pending migrations of the storage, a single replica at a time
+func applyMigrations(ctx context.Context, migrator *migrations.Migrator, locker migrations.Locker, wait time.Duration) error {
+	if err := migrations.Exclusive(ctx, locker, wait, migrator.Up); err != nil {
+		return err
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	zap.S().Infow("core", zap.String("state", "storage migrated"), zap.Uint("version", status.Version))
+	return nil
+}
+
+// instanceID identifies the replica holding the leases
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%x", host, os.Getpid(), suffix)
+}
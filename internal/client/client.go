@@ -1,22 +1,100 @@
 package client
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"os"
 )
 
 type Client struct {
-	Api aliasapi.AliasAPIClient
+	Api    aliasapi.AliasAPIClient
+	Health healthpb.HealthClient
+	conn   *grpc.ClientConn
 }
 
-func New(target string) (*Client, error) {
-	cc, err := grpc.NewClient(
-		target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+type settings struct {
+	token   string
+	tls     bool
+	caFile  string
+	options []grpc.DialOption
+}
+
+// Option configures the connection of the client
+type Option func(*settings)
+
+// WithToken sends the token as a bearer token with every call
+func WithToken(token string) Option {
+	return func(s *settings) { s.token = token }
+}
+
+// WithTLS connects over TLS, the server certificate is verified by the certificates of caFile
+// or by the system ones if caFile is empty
+func WithTLS(caFile string) Option {
+	return func(s *settings) { s.tls, s.caFile = true, caFile }
+}
+
+// WithDialOptions adds the options to the ones of the connection
+func WithDialOptions(options ...grpc.DialOption) Option {
+	return func(s *settings) { s.options = append(s.options, options...) }
+}
+
+func New(target string, options ...Option) (*Client, error) {
+	var s settings
+	for _, option := range options {
+		option(&s)
+	}
+
+	transport := insecure.NewCredentials()
+	if s.tls {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if s.caFile != "" {
+			pem, err := os.ReadFile(s.caFile)
+			if err != nil {
+				return nil, err
+			}
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", s.caFile)
+			}
+		}
+		transport = credentials.NewTLS(config)
+	}
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(transport)}, s.options...)
+	if s.token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(bearerToken{token: s.token, secure: s.tls}))
+	}
+
+	cc, err := grpc.NewClient(target, dialOptions...)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{Api: aliasapi.NewAliasAPIClient(cc)}, nil
+	return &Client{Api: aliasapi.NewAliasAPIClient(cc), Health: healthpb.NewHealthClient(cc), conn: cc}, nil
+}
+
+// Close closes the connection of the client
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// bearerToken passes the token in the authorization metadata
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+func (b bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + b.token}, nil
+}
+
+// RequireTransportSecurity lets the token go over plaintext connections only if TLS is not asked for,
+// e.g. to a proxy in the same network
+func (b bearerToken) RequireTransportSecurity() bool {
+	return b.secure
 }
@@ -5,19 +5,25 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
 	"github.com/xloki21/alias/internal/repository"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// EnvPrefix is the prefix of the environment variables overriding the settings, e.g. ALIAS_SERVICE_HTTP
+const EnvPrefix = "ALIAS"
+
 type Service struct {
 	HTTP        string `mapstructure:"http"`
 	GRPC        string `mapstructure:"grpc"`
 	GRPCGateway string `mapstructure:"grpc-gateway"`
 	BaseURL     string `mapstructure:"base-url"`
+	Debug       string `mapstructure:"debug"` // internal listener of /debug/vars, turned off if empty
 }
 
 type LoggerConfig struct {
@@ -25,10 +31,71 @@ type LoggerConfig struct {
 	Encoding string `mapstructure:"encoding"`
 }
 
+type TracingConfig struct {
+	Exporter    tracing.ExporterType `mapstructure:"exporter"` // none/stdout/otlp
+	Endpoint    string               `mapstructure:"endpoint"`
+	ServiceName string               `mapstructure:"service-name"`
+	SampleRatio float64              `mapstructure:"sample-ratio"`
+}
+
+type ShutdownConfig struct {
+	Timeout      time.Duration `mapstructure:"timeout"`
+	DrainTimeout time.Duration `mapstructure:"drain-timeout"`
+}
+
+type EventsConfig struct {
+	QueueCapacity int `mapstructure:"queue-capacity"`
+}
+
+type RedirectConfig struct {
+	DefaultType   int           `mapstructure:"default-type"`   // 301/302/307/308
+	CacheMaxAge   time.Duration `mapstructure:"cache-max-age"`  // max-age of permanent redirects
+	CountryHeader string        `mapstructure:"country-header"` // header with the visitor country set by the proxy
+	NotActive     string        `mapstructure:"not-active"`     // not-found/coming-soon response to the visitors of the scheduled aliases
+}
+
+type ThrottleConfig struct {
+	MaxRequests int `mapstructure:"max-requests"` // HTTP requests processed at once, the others get 429
+}
+
+type PasswordConfig struct {
+	MaxAttempts    int           `mapstructure:"max-attempts"`     // wrong passwords a client may enter for an alias within the window
+	Window         time.Duration `mapstructure:"window"`           // window started by the first wrong password
+	ClientIPHeader string        `mapstructure:"client-ip-header"` // header with the visitor IP set by the proxy, remote address if empty
+}
+
+type PagesConfig struct {
+	Dir string `mapstructure:"dir"` // directory with the templates overriding the embedded pages
+}
+
+type PolicyConfig struct {
+	BlockedDomains []string `mapstructure:"blocked-domains"` // the aliases must not lead to these domains and their subdomains
+}
+
+type TrashConfig struct {
+	Retention     time.Duration `mapstructure:"retention"`      // time the removed aliases can be restored for, 0 keeps them forever
+	PurgeInterval time.Duration `mapstructure:"purge-interval"` // interval of deleting the aliases kept longer than the retention
+}
+
+type ClicksConfig struct {
+	Retention     time.Duration `mapstructure:"retention"`      // time the clicks are kept in the log for, 0 keeps them forever
+	PurgeInterval time.Duration `mapstructure:"purge-interval"` // interval of deleting the clicks kept longer than the retention
+}
+
+type ReaperConfig struct {
+	Interval  time.Duration `mapstructure:"interval"`   // interval of deactivating the expired aliases, 0 turns the reaper off
+	BatchSize int           `mapstructure:"batch-size"` // aliases reaped at once
+}
+
+type MigrationsConfig struct {
+	Auto        bool          `mapstructure:"auto"`         // apply the pending migrations of the storage at startup
+	LockTimeout time.Duration `mapstructure:"lock-timeout"` // wait for another replica applying the migrations
+}
+
 type Credentials struct {
-	AuthSource string
-	User       string
-	Password   string
+	AuthSource string `mapstructure:"auth-source"`
+	User       string `mapstructure:"user"`
+	Password   string `mapstructure:"password"`
 }
 
 type MongoDBStorageConfig struct {
@@ -40,15 +107,31 @@ type MongoDBStorageConfig struct {
 type StorageConfig struct {
 	Type    repository.Type       `yaml:"type"` // mongodb/postgres/inmemory
 	MongoDB *MongoDBStorageConfig `mapstructure:"config" yaml:"config"`
+	// Snapshot is the file the in-memory storage is loaded from at startup and saved to on shutdown, none if empty
+	Snapshot string `mapstructure:"snapshot" yaml:"snapshot"`
 }
 
 type AppConfig struct {
-	Service      Service       `mapstructure:"service"`
-	Storage      StorageConfig `mapstructure:"storage"`
-	LoggerConfig LoggerConfig  `mapstructure:"logger"`
+	Service      Service          `mapstructure:"service"`
+	Storage      StorageConfig    `mapstructure:"storage"`
+	Migrations   MigrationsConfig `mapstructure:"migrations"`
+	LoggerConfig LoggerConfig     `mapstructure:"logger"`
+	Tracing      TracingConfig    `mapstructure:"tracing"`
+	Shutdown     ShutdownConfig   `mapstructure:"shutdown"`
+	Events       EventsConfig     `mapstructure:"events"`
+	Redirect     RedirectConfig   `mapstructure:"redirect"`
+	Throttle     ThrottleConfig   `mapstructure:"throttle"`
+	Password     PasswordConfig   `mapstructure:"password"`
+	Pages        PagesConfig      `mapstructure:"pages"`
+	Policy       PolicyConfig     `mapstructure:"policy"`
+	Trash        TrashConfig      `mapstructure:"trash"`
+	Reaper       ReaperConfig     `mapstructure:"reaper"`
+	Clicks       ClicksConfig     `mapstructure:"clicks"`
 }
 
-func NewZapLogger(cfg LoggerConfig) (*zap.Logger, error) {
+// NewZapLogger builds the logger, the level of the configuration is set to level, which changes the level
+// of the logger later on
+func NewZapLogger(cfg LoggerConfig, level zap.AtomicLevel) (*zap.Logger, error) {
 	zcfg := zap.NewProductionConfig()
 	zcfg.EncoderConfig = zap.NewProductionEncoderConfig()
 	zcfg.EncoderConfig.CallerKey = zapcore.OmitKey
@@ -61,42 +144,132 @@ func NewZapLogger(cfg LoggerConfig) (*zap.Logger, error) {
 		return nil, err
 	}
 
-	zcfg.Level = zap.NewAtomicLevelAt(parsedLevel)
+	level.SetLevel(parsedLevel)
+	zcfg.Level = level
 
 	return zcfg.Build()
 }
 
-func MustLoad() (AppConfig, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("/etc/alias/")
-	viper.AddConfigPath("$HOME/.alias")
-	viper.AddConfigPath("./config")
-	viper.AddConfigPath(".")
+// setDefaults sets the defaults of the settings missing in the configuration file and in the environment
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("service.http", "localhost:8080")
+	v.SetDefault("service.grpc", "localhost:8081")
+	v.SetDefault("service.grpc-gateway", "localhost:8082")
+	v.SetDefault("service.base-url", "http://localhost:8080")
+	v.SetDefault("service.debug", "localhost:8083")
+	v.SetDefault("storage.type", repository.InMemory)
+	v.SetDefault("logger.level", "info")
+	v.SetDefault("logger.encoding", "json")
+	v.SetDefault("tracing.exporter", tracing.ExporterNone)
+	v.SetDefault("tracing.service-name", "alias")
+	v.SetDefault("tracing.sample-ratio", 1.0)
+	v.SetDefault("shutdown.timeout", 30*time.Second)
+	v.SetDefault("shutdown.drain-timeout", 10*time.Second)
+	v.SetDefault("events.queue-capacity", 64)
+	v.SetDefault("redirect.default-type", 307)
+	v.SetDefault("redirect.cache-max-age", 24*time.Hour)
+	v.SetDefault("redirect.country-header", "X-Country-Code")
+	v.SetDefault("redirect.not-active", "not-found")
+	v.SetDefault("throttle.max-requests", 100)
+	v.SetDefault("password.max-attempts", 5)
+	v.SetDefault("password.window", 15*time.Minute)
+	v.SetDefault("trash.retention", 30*24*time.Hour)
+	v.SetDefault("trash.purge-interval", time.Hour)
+	v.SetDefault("reaper.interval", 5*time.Minute)
+	v.SetDefault("reaper.batch-size", 500)
+	v.SetDefault("clicks.retention", 90*24*time.Hour)
+	v.SetDefault("clicks.purge-interval", time.Hour)
+	v.SetDefault("migrations.lock-timeout", 5*time.Minute)
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("no config file found, using defaults\n")
+// bindEnv lets the environment variables override every setting, e.g. ALIAS_SERVICE_HTTP overrides service.http.
+// The keys are bound one by one, so that the settings missing in the file are overridden too.
+func bindEnv(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	for _, key := range Keys() {
+		_ = v.BindEnv(key)
+	}
+}
+
+// legacyMongoDBCredentials fills the MongoDB credentials missing in the configuration from the MONGO_* variables
+// read by the previous versions and by docker-compose
+func legacyMongoDBCredentials(credentials *Credentials) {
+	for variable, field := range map[string]*string{
+		"MONGO_USERNAME":   &credentials.User,
+		"MONGO_PASSWORD":   &credentials.Password,
+		"MONGO_AUTHSOURCE": &credentials.AuthSource,
+	} {
+		if *field == "" {
+			*field = os.Getenv(variable)
+		}
+	}
+}
+
+// Read reads the configuration from the file at path, or from the first config.yaml found in /etc/alias/,
+// $HOME/.alias, ./config and the working directory if path is empty, the environment variables override the file.
+// It returns the file read, empty if none was found. The configuration is not validated.
+func Read(path string) (AppConfig, string, error) {
+	v, err := newViper(path)
+	if err != nil {
+		return AppConfig{}, "", err
+	}
+	cfg, err := decode(v)
+	if err != nil {
+		return AppConfig{}, "", err
+	}
+	return cfg, v.ConfigFileUsed(), nil
+}
+
+// newViper reads the settings as described in Read
+func newViper(path string) (*viper.Viper, error) {
+	v := viper.New()
+	setDefaults(v)
+	bindEnv(v)
+
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath("/etc/alias/")
+		v.AddConfigPath("$HOME/.alias")
+		v.AddConfigPath("./config")
+		v.AddConfigPath(".")
+	}
+	if err := v.ReadInConfig(); err != nil {
 		var configFileNotFoundError viper.ConfigFileNotFoundError
-		if errors.As(err, &configFileNotFoundError) {
-			viper.SetDefault("service.http", "localhost:8080")
-			viper.SetDefault("service.grpc", "localhost:8081")
-			viper.SetDefault("service.grpc-gateway", "localhost:8082")
-			viper.SetDefault("service.base-url", "http://localhost:8080")
-			viper.SetDefault("storage.type", repository.InMemory)
-			viper.SetDefault("logger.level", "info")
-			viper.SetDefault("logger.encoding", "json")
-
-		} else {
-			return AppConfig{}, err
+		if path != "" || !errors.As(err, &configFileNotFoundError) {
+			return nil, err
 		}
 	}
+	return v, nil
+}
 
+// decode unmarshals the settings of the viper instance
+func decode(v *viper.Viper) (AppConfig, error) {
 	cfg := AppConfig{}
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return AppConfig{}, err
 	}
+	if cfg.Storage.Type != repository.MongoDB {
+		cfg.Storage.MongoDB = nil
+	} else if cfg.Storage.MongoDB != nil {
+		legacyMongoDBCredentials(&cfg.Storage.MongoDB.Credentials)
+	}
+	return cfg, nil
+}
+
+// MustLoad reads and validates the configuration of the server and replaces the global logger.
+// The file is taken from ALIAS_CONFIG, or searched for as described in Read; with no file found
+// the configuration consists of the defaults and the environment variables.
+func MustLoad() (*Runtime, error) {
+	runtime, err := NewRuntime(os.Getenv(EnvPrefix + "_CONFIG"))
+	if err != nil {
+		return nil, err
+	}
 
-	logger, err := NewZapLogger(cfg.LoggerConfig)
+	logger, err := NewZapLogger(runtime.Config().LoggerConfig, runtime.Level())
 	if err != nil {
 		log.Fatal("failed to init logger: " + err.Error())
 	}
@@ -104,25 +277,23 @@ func MustLoad() (AppConfig, error) {
 	// create a new one global logger instance
 	zap.ReplaceGlobals(logger)
 
-	if cfg.Storage.Type == repository.MongoDB {
-		requiredEnvVars := []string{
-			"MONGO_USERNAME", "MONGO_PASSWORD", "MONGO_AUTHSOURCE",
-		}
-
-		for _, requiredEnvVar := range requiredEnvVars {
-			if _, ok := os.LookupEnv(requiredEnvVar); !ok {
-				zap.S().Errorf("missing environment variable %s", requiredEnvVar)
-				return AppConfig{}, fmt.Errorf("missing environment variable %s", requiredEnvVar)
-			}
-		}
-		cfg.Storage.MongoDB.Credentials = Credentials{
-			User:       os.Getenv("MONGO_USERNAME"),
-			Password:   os.Getenv("MONGO_PASSWORD"),
-			AuthSource: os.Getenv("MONGO_AUTHSOURCE"),
-		}
+	if file := runtime.File(); file == "" {
+		zap.S().Warnw("core", zap.String("state", "no config file found, using the defaults and the environment"))
 	} else {
-		cfg.Storage.MongoDB = nil
+		zap.S().Infow("core", zap.String("state", "config file read"), zap.String("file", file))
 	}
+	return runtime, nil
+}
 
+// Load reads and validates the configuration from the file at path, the file must exist. Unlike MustLoad it leaves
+// the global logger alone.
+func Load(path string) (AppConfig, error) {
+	cfg, _, err := Read(path)
+	if err != nil {
+		return AppConfig{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return AppConfig{}, fmt.Errorf("%s:\n%w", path, err)
+	}
 	return cfg, nil
 }
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// reloadable are the settings applied to the running server, a key ending with a dot covers its section.
+// The changes of the others, e.g. the listen addresses and the storage, take effect after a restart.
+var reloadable = []string{"logger.level", "password.", "policy.", "redirect.", "throttle."}
+
+// reloadMetrics are published by expvar: the numbers of the applied and the failed reloads, the number
+// of the changed settings waiting for a restart and the unix time of the last reload
+var reloadMetrics = expvar.NewMap("config_reloads")
+
+// ReloadReport is the outcome of a reload
+type ReloadReport struct {
+	// Applied are the keys of the settings changed by the reload and applied to the running server
+	Applied []string
+	// RestartRequired are the keys of the settings changed since the start which take effect after a restart
+	RestartRequired []string
+}
+
+// Runtime holds the configuration of the running server. It reloads the configuration file when it changes,
+// applying the settings safe to change live and reporting the others as requiring a restart.
+type Runtime struct {
+	v     *viper.Viper
+	level zap.AtomicLevel
+
+	mu      sync.Mutex
+	started AppConfig
+	current AppConfig
+	apply   []func(cfg AppConfig) error
+}
+
+// NewRuntime reads and validates the configuration as described in Read
+func NewRuntime(path string) (*Runtime, error) {
+	v, err := newViper(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decode(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	level := zap.NewAtomicLevel()
+	if parsed, err := zapcore.ParseLevel(cfg.LoggerConfig.Level); err == nil {
+		level.SetLevel(parsed)
+	}
+	return &Runtime{v: v, level: level, started: cfg, current: cfg}, nil
+}
+
+// Config returns the configuration the reloadable settings of the running server were last applied from,
+// the settings requiring a restart may differ from the ones in effect
+func (r *Runtime) Config() AppConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// File returns the configuration file, empty if none was found
+func (r *Runtime) File() string {
+	return r.v.ConfigFileUsed()
+}
+
+// Level returns the level of the logger built by MustLoad, the reloads change it
+func (r *Runtime) Level() zap.AtomicLevel {
+	return r.level
+}
+
+// OnReload registers the function applying the reloadable settings of the reloaded configuration
+func (r *Runtime) OnReload(apply func(cfg AppConfig) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply = append(r.apply, apply)
+}
+
+// Watch reloads the configuration on every change of the file until the process exits
+func (r *Runtime) Watch() {
+	if r.File() == "" {
+		zap.S().Infow("config", zap.String("state", "no config file to watch"))
+		return
+	}
+	r.v.OnConfigChange(func(fsnotify.Event) {
+		_, _ = r.Reload()
+	})
+	r.v.WatchConfig()
+	zap.S().Infow("config", zap.String("state", "watching config file"), zap.String("file", r.File()))
+}
+
+// Reload reads the configuration file again and applies the changed reloadable settings. An invalid configuration
+// is rejected as a whole, the running server keeps the current one.
+func (r *Runtime) Reload() (ReloadReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reloadMetrics.Set("last_reload", expvarInt(time.Now().Unix()))
+
+	report, err := r.reload()
+	if err != nil {
+		reloadMetrics.Add("failed", 1)
+		zap.S().Errorw("config", zap.String("state", "config reload failed"), zap.String("file", r.File()),
+			zap.Error(err))
+		return report, err
+	}
+	reloadMetrics.Add("applied", 1)
+	reloadMetrics.Set("restart_required", expvarInt(int64(len(report.RestartRequired))))
+	zap.S().Infow("config", zap.String("state", "config reloaded"), zap.String("file", r.File()),
+		zap.Strings("applied", report.Applied))
+	if len(report.RestartRequired) > 0 {
+		zap.S().Warnw("config", zap.String("state", "changed settings require a restart"),
+			zap.Strings("settings", report.RestartRequired))
+	}
+	return report, nil
+}
+
+func (r *Runtime) reload() (ReloadReport, error) {
+	if r.File() != "" {
+		// a file being rewritten in place is empty for a moment, its defaults must not replace the settings
+		if info, err := os.Stat(r.File()); err != nil {
+			return ReloadReport{}, err
+		} else if info.Size() == 0 {
+			return ReloadReport{}, errors.New("config file is empty")
+		}
+		if err := r.v.ReadInConfig(); err != nil {
+			return ReloadReport{}, err
+		}
+	}
+	cfg, err := decode(r.v)
+	if err != nil {
+		return ReloadReport{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return ReloadReport{}, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	var report ReloadReport
+	for _, key := range changedKeys(r.current, cfg) {
+		if isReloadable(key) {
+			report.Applied = append(report.Applied, key)
+		}
+	}
+	for _, key := range changedKeys(r.started, cfg) {
+		if !isReloadable(key) {
+			report.RestartRequired = append(report.RestartRequired, key)
+		}
+	}
+	if len(report.Applied) == 0 {
+		return report, nil
+	}
+
+	level, err := zapcore.ParseLevel(cfg.LoggerConfig.Level)
+	if err != nil {
+		return ReloadReport{}, err
+	}
+	for _, apply := range r.apply {
+		if err := apply(cfg); err != nil {
+			return ReloadReport{}, err
+		}
+	}
+	r.level.SetLevel(level)
+	r.current = cfg
+	return report, nil
+}
+
+// changedKeys lists the keys of the settings which differ in the configurations in the order of the configuration
+func changedKeys(previous, next AppConfig) []string {
+	previousValues, nextValues := previous.values(), next.values()
+	var keys []string
+	for _, key := range Keys() {
+		previousValue, inPrevious := previousValues[key]
+		nextValue, inNext := nextValues[key]
+		if inPrevious != inNext || previousValue != nextValue {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func isReloadable(key string) bool {
+	for _, prefix := range reloadable {
+		if key == prefix || strings.HasSuffix(prefix, ".") && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func expvarInt(value int64) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(value)
+	return v
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+const runtimeTestConfig = `
+service:
+  http: localhost:8080
+logger:
+  level: info
+policy:
+  blocked-domains: [evil.test]
+`
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestRuntime_Reload(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, runtimeTestConfig)
+
+	runtime, err := NewRuntime(path)
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.InfoLevel, runtime.Level().Level())
+	var applied []AppConfig
+	runtime.OnReload(func(cfg AppConfig) error {
+		applied = append(applied, cfg)
+		return nil
+	})
+
+	// nothing changed
+	report, err := runtime.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, report.Applied)
+	assert.Empty(t, applied)
+
+	writeConfig(t, path, `
+service:
+  http: localhost:9090
+logger:
+  level: debug
+policy:
+  blocked-domains: [evil.test, bad.test]
+`)
+	report, err = runtime.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logger.level", "policy.blocked-domains"}, report.Applied)
+	assert.Equal(t, []string{"service.http"}, report.RestartRequired)
+	assert.Equal(t, zapcore.DebugLevel, runtime.Level().Level())
+	require.Len(t, applied, 1)
+	assert.Equal(t, []string{"evil.test", "bad.test"}, applied[0].Policy.BlockedDomains)
+	assert.Equal(t, []string{"evil.test", "bad.test"}, runtime.Config().Policy.BlockedDomains)
+
+	// an invalid configuration is rejected as a whole
+	writeConfig(t, path, `
+logger:
+  level: loud
+policy:
+  blocked-domains: []
+`)
+	_, err = runtime.Reload()
+	assert.ErrorContains(t, err, "logger.level")
+	assert.Equal(t, zapcore.DebugLevel, runtime.Level().Level())
+	assert.Equal(t, []string{"evil.test", "bad.test"}, runtime.Config().Policy.BlockedDomains)
+
+	// the reloadable settings cannot be turned off, a reload would report them applied while the old values stay
+	writeConfig(t, path, runtimeTestConfig+`
+redirect:
+  cache-max-age: 0s
+  country-header: ""
+password:
+  max-attempts: 0
+  window: 0s
+`)
+	_, err = runtime.Reload()
+	require.Error(t, err)
+	for _, key := range []string{"redirect.cache-max-age", "redirect.country-header", "password.max-attempts", "password.window"} {
+		assert.ErrorContains(t, err, key)
+	}
+	require.Len(t, applied, 1)
+
+	writeConfig(t, path, runtimeTestConfig+`
+redirect:
+  cache-max-age: 1h
+  country-header: CF-IPCountry
+password:
+  max-attempts: 3
+  window: 1m
+`)
+	report, err = runtime.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"logger.level", "redirect.cache-max-age", "redirect.country-header",
+		"password.max-attempts", "password.window", "policy.blocked-domains",
+	}, report.Applied)
+	require.Len(t, applied, 2)
+	assert.Equal(t, time.Hour, applied[1].Redirect.CacheMaxAge)
+	assert.Equal(t, "CF-IPCountry", applied[1].Redirect.CountryHeader)
+	assert.Equal(t, PasswordConfig{MaxAttempts: 3, Window: time.Minute}, applied[1].Password)
+
+	// the settings requiring a restart are reported until they are back to the ones the server was started with
+	writeConfig(t, path, runtimeTestConfig)
+	report, err = runtime.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"redirect.cache-max-age", "redirect.country-header", "password.max-attempts", "password.window",
+	}, report.Applied)
+	assert.Empty(t, report.RestartRequired)
+}
+
+func TestRuntime_Watch(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, runtimeTestConfig)
+
+	runtime, err := NewRuntime(path)
+	require.NoError(t, err)
+	var reloads atomic.Int32
+	runtime.OnReload(func(cfg AppConfig) error {
+		reloads.Add(1)
+		return nil
+	})
+	runtime.Watch()
+
+	writeConfig(t, path, runtimeTestConfig+"redirect:\n  default-type: 302\n")
+	assert.Eventually(t, func() bool { return reloads.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 302, runtime.Config().Redirect.DefaultType)
+}
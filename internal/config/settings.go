@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces the secrets in the listed settings
+const redacted = "xxxxx"
+
+// Setting is a single effective setting of the configuration
+type Setting struct {
+	// Key is the path of the setting in the configuration file, e.g. service.http
+	Key   string
+	Value string
+}
+
+// EnvVar returns the environment variable overriding the setting, e.g. ALIAS_SERVICE_HTTP for service.http
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// Keys lists the keys of all the settings in the order of the configuration
+func Keys() []string {
+	var keys []string
+	walkType(reflect.TypeOf(AppConfig{}), "", func(key string) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// Settings lists the settings in the order of the configuration with the passwords and the credentials
+// of the URIs redacted. The sections missing in the configuration, e.g. storage.config of the in-memory storage, are skipped.
+func (c AppConfig) Settings() []Setting {
+	var settings []Setting
+	walkValue(reflect.ValueOf(c), "", func(key string, value reflect.Value) {
+		settings = append(settings, Setting{Key: key, Value: redact(key, fmt.Sprint(value.Interface()))})
+	})
+	return settings
+}
+
+// values maps the keys of the settings to their values as is, the sections missing in the configuration are skipped
+func (c AppConfig) values() map[string]string {
+	values := make(map[string]string)
+	walkValue(reflect.ValueOf(c), "", func(key string, value reflect.Value) {
+		values[key] = fmt.Sprint(value.Interface())
+	})
+	return values
+}
+
+// fieldKey is the key of the struct field in the configuration, mapstructure matches the untagged fields by name
+func fieldKey(field reflect.StructField, prefix string) string {
+	name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func walkType(t reflect.Type, prefix string, fn func(key string)) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		fn(prefix)
+		return
+	}
+	for index := 0; index < t.NumField(); index++ {
+		walkType(t.Field(index).Type, fieldKey(t.Field(index), prefix), fn)
+	}
+}
+
+func walkValue(value reflect.Value, prefix string, fn func(key string, value reflect.Value)) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		fn(prefix, value)
+		return
+	}
+	for index := 0; index < value.NumField(); index++ {
+		walkValue(value.Field(index), fieldKey(value.Type().Field(index), prefix), fn)
+	}
+}
+
+func redact(key, value string) string {
+	switch {
+	case value == "":
+		return value
+	case strings.HasSuffix(key, ".password"):
+		return redacted
+	case strings.HasSuffix(key, ".uri"):
+		if parsed, err := url.Parse(value); err == nil {
+			return parsed.Redacted()
+		}
+		// an unparsable URI could still carry a password
+		return redacted
+	}
+	return value
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"github.com/xloki21/alias/internal/repository"
+	"go.uber.org/zap/zapcore"
+)
+
+// Validate checks the configuration, all the problems found are joined into the error, one per line
+func (c AppConfig) Validate() error {
+	var problems []error
+	problem := func(key string, err error) {
+		problems = append(problems, fmt.Errorf("%s (%s): %w", key, EnvVar(key), err))
+	}
+
+	for _, address := range []struct {
+		key, value string
+	}{
+		{key: "service.http", value: c.Service.HTTP},
+		{key: "service.grpc", value: c.Service.GRPC},
+		{key: "service.grpc-gateway", value: c.Service.GRPCGateway},
+	} {
+		if err := validateAddress(address.value); err != nil {
+			problem(address.key, err)
+		}
+	}
+	// the debug listener is optional
+	if c.Service.Debug != "" {
+		if err := validateAddress(c.Service.Debug); err != nil {
+			problem("service.debug", err)
+		}
+	}
+	if err := validateBaseURL(c.Service.BaseURL); err != nil {
+		problem("service.base-url", err)
+	}
+
+	if _, err := zapcore.ParseLevel(c.LoggerConfig.Level); err != nil {
+		problem("logger.level", err)
+	}
+	if c.LoggerConfig.Encoding != "json" && c.LoggerConfig.Encoding != "console" {
+		problem("logger.encoding", fmt.Errorf("unknown encoding %q, must be one of json, console", c.LoggerConfig.Encoding))
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP, "":
+	default:
+		problem("tracing.exporter", fmt.Errorf("unknown exporter %q, must be one of %s, %s, %s",
+			c.Tracing.Exporter, tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problem("tracing.sample-ratio", fmt.Errorf("ratio %v must be within 0 and 1", c.Tracing.SampleRatio))
+	}
+
+	if c.Events.QueueCapacity < 0 {
+		problem("events.queue-capacity", fmt.Errorf("capacity %d must not be negative", c.Events.QueueCapacity))
+	}
+
+	if redirectType := domain.RedirectType(c.Redirect.DefaultType); !redirectType.IsValid() {
+		problem("redirect.default-type", fmt.Errorf("unknown redirect type %d, must be one of %d, %d, %d, %d",
+			c.Redirect.DefaultType, domain.RedirectMovedPermanently, domain.RedirectFound,
+			domain.RedirectTemporary, domain.RedirectPermanent))
+	}
+	if _, err := domain.ParseNotActiveResponse(c.Redirect.NotActive); err != nil {
+		problem("redirect.not-active", err)
+	}
+
+	// the reloadable settings have no value turning them off, so that a reload never leaves the old value in effect
+	if c.Redirect.CacheMaxAge <= 0 {
+		problem("redirect.cache-max-age", fmt.Errorf("duration %s must be positive", c.Redirect.CacheMaxAge))
+	}
+	if c.Redirect.CountryHeader == "" {
+		problem("redirect.country-header", errors.New("missing value"))
+	}
+	if c.Throttle.MaxRequests <= 0 {
+		problem("throttle.max-requests", fmt.Errorf("limit %d must be positive", c.Throttle.MaxRequests))
+	}
+	if c.Password.MaxAttempts <= 0 {
+		problem("password.max-attempts", fmt.Errorf("limit %d must be positive", c.Password.MaxAttempts))
+	}
+	if c.Password.Window <= 0 {
+		problem("password.window", fmt.Errorf("duration %s must be positive", c.Password.Window))
+	}
+
+	for _, duration := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{key: "trash.retention", value: c.Trash.Retention},
+		{key: "trash.purge-interval", value: c.Trash.PurgeInterval},
+		{key: "reaper.interval", value: c.Reaper.Interval},
+		{key: "clicks.retention", value: c.Clicks.Retention},
+		{key: "clicks.purge-interval", value: c.Clicks.PurgeInterval},
+	} {
+		if duration.value < 0 {
+			problem(duration.key, fmt.Errorf("duration %s must not be negative", duration.value))
+		}
+	}
+	// the reaper turned off reads no batch size
+	if c.Reaper.Interval > 0 && c.Reaper.BatchSize <= 0 {
+		problem("reaper.batch-size", fmt.Errorf("batch size %d must be positive", c.Reaper.BatchSize))
+	}
+
+	switch c.Storage.Type {
+	case repository.InMemory:
+	case repository.MongoDB:
+		mongoDB := c.Storage.MongoDB
+		if mongoDB == nil {
+			mongoDB = &MongoDBStorageConfig{}
+		}
+		if err := validateMongoDBURI(mongoDB.URI); err != nil {
+			problem("storage.config.uri", err)
+		}
+		for _, setting := range []struct {
+			key, value string
+		}{
+			{key: "storage.config.database", value: mongoDB.Database},
+			{key: "storage.config.credentials.user", value: mongoDB.Credentials.User},
+			{key: "storage.config.credentials.password", value: mongoDB.Credentials.Password},
+			{key: "storage.config.credentials.auth-source", value: mongoDB.Credentials.AuthSource},
+		} {
+			if setting.value == "" {
+				problem(setting.key, errors.New("missing value"))
+			}
+		}
+	default:
+		problem("storage.type", fmt.Errorf("unknown storage type %q, must be one of %s, %s",
+			c.Storage.Type, repository.InMemory, repository.MongoDB))
+	}
+	return errors.Join(problems...)
+}
+
+// validateAddress checks the listen address is a host and a port, the host may be empty to listen on all interfaces
+func validateAddress(address string) error {
+	if address == "" {
+		return errors.New("missing address")
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	if number, err := strconv.ParseUint(port, 10, 16); err != nil || port == "" {
+		return fmt.Errorf("invalid port %q", port)
+	} else if number == 0 {
+		return errors.New("port must not be zero, the clients could not find the server")
+	}
+	return nil
+}
+
+// validateBaseURL checks the base url the short links are made of, the keys are appended to it after a slash
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return errors.New("missing base url")
+	}
+	parsed, err := url.Parse(baseURL)
+	switch {
+	case err != nil:
+		return err
+	case parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "":
+		return fmt.Errorf("base url %q must be an absolute http or https url", baseURL)
+	case parsed.RawQuery != "" || parsed.Fragment != "":
+		return fmt.Errorf("base url %q must not have a query or a fragment", baseURL)
+	case strings.HasSuffix(parsed.Path, "/"):
+		return fmt.Errorf("base url %q must not end with a slash", baseURL)
+	}
+	return nil
+}
+
+func validateMongoDBURI(uri string) error {
+	if uri == "" {
+		return errors.New("missing uri")
+	}
+	parsed, err := url.Parse(uri)
+	switch {
+	case err != nil:
+		// the error repeats the uri, which could carry a password
+		return errors.New("malformed uri")
+	case parsed.Scheme != "mongodb" && parsed.Scheme != "mongodb+srv" || parsed.Host == "":
+		return fmt.Errorf("uri %q must be a mongodb:// or mongodb+srv:// uri", parsed.Redacted())
+	}
+	return nil
+}
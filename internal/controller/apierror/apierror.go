@@ -0,0 +1,261 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// Domain is reported in the gRPC ErrorInfo details
+const Domain = "alias"
+
+// Code is a machine-readable error code
+type Code string
+
+const (
+	CodeInvalidArgument    Code = "INVALID_ARGUMENT"
+	CodeInvalidJSON        Code = "INVALID_JSON"
+	CodeInvalidURL         Code = "INVALID_URL"
+	CodeEmptyRequest       Code = "EMPTY_REQUEST"
+	CodeInvalidRedirect    Code = "INVALID_REDIRECT_TYPE"
+	CodeInvalidPassthrough Code = "INVALID_PASSTHROUGH_POLICY"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeAliasNotFound      Code = "ALIAS_NOT_FOUND"
+	CodeAliasExpired       Code = "ALIAS_EXPIRED"
+	CodeAliasDisabled      Code = "ALIAS_DISABLED"
+	CodeAliasNotActive     Code = "ALIAS_NOT_ACTIVE"
+	CodeInvalidExpiration  Code = "INVALID_EXPIRATION"
+	CodeInvalidFilter      Code = "INVALID_FILTER"
+	CodeBlocked            Code = "DESTINATION_BLOCKED"
+	CodeInvalidCampaign    Code = "INVALID_CAMPAIGN"
+	CodeInvalidRule        Code = "INVALID_RULE"
+	CodeInvalidVariant     Code = "INVALID_VARIANT"
+	CodeInvalidPassword    Code = "INVALID_PASSWORD"
+	CodeInvalidQRCode      Code = "INVALID_QR_CODE_OPTIONS"
+	CodeInvalidKey         Code = "INVALID_KEY"
+	CodeInvalidImport      Code = "INVALID_IMPORT"
+	CodeUnsupportedMedia   Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeWrongPassword      Code = "WRONG_PASSWORD"
+	CodeTooManyAttempts    Code = "TOO_MANY_ATTEMPTS"
+	CodeCampaignNotFound   Code = "CAMPAIGN_NOT_FOUND"
+	CodeCampaignExists     Code = "CAMPAIGN_ALREADY_EXISTS"
+	CodeKeyExists          Code = "KEY_ALREADY_EXISTS"
+	CodeAlreadyExists      Code = "ALREADY_EXISTS"
+	CodeMethodNotAllowed   Code = "METHOD_NOT_ALLOWED"
+	CodeTooManyRequests    Code = "TOO_MANY_REQUESTS"
+	CodeUnavailable        Code = "UNAVAILABLE"
+	CodeInternal           Code = "INTERNAL"
+)
+
+// FieldViolation describes a problem with a single field of the request
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Error is an API error rendered the same way by every transport
+type Error struct {
+	Status    int              `json:"-"`
+	Code      Code             `json:"code"`
+	Message   string           `json:"message"`
+	Details   []FieldViolation `json:"details,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+}
+
+var (
+	ErrMethodNotAllowed = New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+	ErrTooManyRequests  = New(http.StatusTooManyRequests, CodeTooManyRequests, "too many requests")
+	ErrInternal         = New(http.StatusInternalServerError, CodeInternal, "internal error")
+	ErrUnavailable      = New(http.StatusServiceUnavailable, CodeUnavailable, "service unavailable")
+)
+
+// New creates a new API error
+func New(status int, code Code, message string, details ...FieldViolation) *Error {
+	return &Error{Status: status, Code: code, Message: message, Details: details}
+}
+
+// InvalidArgument creates a new API error for a malformed request
+func InvalidArgument(code Code, message string, details ...FieldViolation) *Error {
+	return New(http.StatusBadRequest, code, message, details...)
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// FromError maps an error returned by the services to an API error
+func FromError(err error) *Error {
+	var apiErr *Error
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, domain.ErrAliasNotFound):
+		return New(http.StatusNotFound, CodeAliasNotFound, domain.ErrAliasNotFound.Error())
+	case errors.Is(err, domain.ErrInvalidRedirectType),
+		errors.Is(err, domain.ErrCacheableRedirectForLimitedAlias):
+		return InvalidArgument(CodeInvalidRedirect, err.Error())
+	case errors.Is(err, domain.ErrInvalidPassthroughPolicy):
+		return InvalidArgument(CodeInvalidPassthrough, err.Error())
+	case errors.Is(err, domain.ErrInvalidRule):
+		return InvalidArgument(CodeInvalidRule, err.Error())
+	case errors.Is(err, domain.ErrInvalidVariant):
+		return InvalidArgument(CodeInvalidVariant, err.Error())
+	case errors.Is(err, domain.ErrInvalidPassword):
+		return InvalidArgument(CodeInvalidPassword, err.Error())
+	case errors.Is(err, domain.ErrWrongPassword):
+		return New(http.StatusForbidden, CodeWrongPassword, domain.ErrWrongPassword.Error())
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		return New(http.StatusTooManyRequests, CodeTooManyAttempts, domain.ErrTooManyAttempts.Error())
+	case errors.Is(err, domain.ErrInvalidCampaign):
+		return InvalidArgument(CodeInvalidCampaign, err.Error())
+	case errors.Is(err, domain.ErrCampaignNotFound):
+		return New(http.StatusNotFound, CodeCampaignNotFound, domain.ErrCampaignNotFound.Error())
+	case errors.Is(err, domain.ErrCampaignAlreadyExists):
+		return New(http.StatusConflict, CodeCampaignExists, domain.ErrCampaignAlreadyExists.Error())
+	case errors.Is(err, domain.ErrAliasExpired):
+		return New(http.StatusGone, CodeAliasExpired, domain.ErrAliasExpired.Error())
+	case errors.Is(err, domain.ErrAliasNotActive):
+		return New(http.StatusNotFound, CodeAliasNotActive, domain.ErrAliasNotActive.Error())
+	case errors.Is(err, domain.ErrInvalidExpiration):
+		return InvalidArgument(CodeInvalidExpiration, err.Error())
+	case errors.Is(err, domain.ErrInvalidListFilter):
+		return InvalidArgument(CodeInvalidFilter, err.Error())
+	case errors.Is(err, domain.ErrAliasDisabled):
+		return New(http.StatusForbidden, CodeAliasDisabled, domain.ErrAliasDisabled.Error())
+	case errors.Is(err, domain.ErrDestinationBlocked):
+		return New(http.StatusForbidden, CodeBlocked, domain.ErrDestinationBlocked.Error())
+	case errors.Is(err, domain.ErrInvalidKey):
+		return InvalidArgument(CodeInvalidKey, err.Error())
+	case errors.Is(err, domain.ErrKeyAlreadyExists):
+		return New(http.StatusConflict, CodeKeyExists, domain.ErrKeyAlreadyExists.Error())
+	default:
+		return ErrInternal
+	}
+}
+
+// grpcCodes maps HTTP statuses of API errors to gRPC codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:           codes.InvalidArgument,
+	http.StatusForbidden:            codes.PermissionDenied,
+	http.StatusNotFound:             codes.NotFound,
+	http.StatusGone:                 codes.NotFound,
+	http.StatusConflict:             codes.AlreadyExists,
+	http.StatusMethodNotAllowed:     codes.Unimplemented,
+	http.StatusUnsupportedMediaType: codes.InvalidArgument,
+	http.StatusTooManyRequests:      codes.ResourceExhausted,
+	http.StatusServiceUnavailable:   codes.Unavailable,
+	http.StatusInternalServerError:  codes.Internal,
+}
+
+// GRPCStatus converts the error to a gRPC status carrying the code and field violations in its details
+func (e *Error) GRPCStatus() *status.Status {
+	grpcCode, ok := grpcCodes[e.Status]
+	if !ok {
+		grpcCode = codes.Unknown
+	}
+
+	st := status.New(grpcCode, e.Message)
+
+	info := &errdetails.ErrorInfo{Reason: string(e.Code), Domain: Domain}
+	if len(e.Details) == 0 {
+		if withDetails, err := st.WithDetails(info); err == nil {
+			return withDetails
+		}
+		return st
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, detail := range e.Details {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       detail.Field,
+			Description: detail.Description,
+		})
+	}
+	if withDetails, err := st.WithDetails(info, badRequest); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// GRPC maps an error returned by the services to a gRPC status error
+func GRPC(err error) error {
+	return FromError(err).GRPCStatus().Err()
+}
+
+// FromGRPCStatus restores an API error from a gRPC status
+func FromGRPCStatus(st *status.Status, httpStatus int) *Error {
+	apiErr := &Error{Status: httpStatus, Code: CodeInternal, Message: st.Message()}
+	if fallback, ok := fallbackCodes[st.Code()]; ok {
+		apiErr.Code = fallback
+	}
+
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.GetDomain() == Domain {
+				apiErr.Code = Code(detail.GetReason())
+				if override, ok := httpStatusOverrides[apiErr.Code]; ok {
+					apiErr.Status = override
+				}
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range detail.GetFieldViolations() {
+				apiErr.Details = append(apiErr.Details, FieldViolation{
+					Field:       violation.GetField(),
+					Description: violation.GetDescription(),
+				})
+			}
+		}
+	}
+	return apiErr
+}
+
+// httpStatusOverrides keeps HTTP statuses which are lost in the mapping to gRPC codes
+var httpStatusOverrides = map[Code]int{
+	CodeAliasExpired:     http.StatusGone,
+	CodeUnsupportedMedia: http.StatusUnsupportedMediaType,
+}
+
+// fallbackCodes maps gRPC codes of errors raised outside the controllers (e.g. by the gateway itself)
+var fallbackCodes = map[codes.Code]Code{
+	codes.InvalidArgument:   CodeInvalidArgument,
+	codes.NotFound:          CodeNotFound,
+	codes.AlreadyExists:     CodeAlreadyExists,
+	codes.Unimplemented:     CodeMethodNotAllowed,
+	codes.ResourceExhausted: CodeTooManyRequests,
+	codes.Unavailable:       CodeUnavailable,
+}
+
+// WriteHTTP renders the error as a JSON response annotated with the request ID
+func WriteHTTP(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := *FromError(err)
+	apiErr.RequestID = logging.RequestID(r.Context())
+
+	answer, marshalErr := json.Marshal(apiErr)
+	if marshalErr != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	if _, err := w.Write(answer); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+	}
+}
+
+// GatewayErrorHandler renders errors returned through the gRPC-gateway the same way as the HTTP controller does
+func GatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	WriteHTTP(w, r, FromGRPCStatus(st, runtime.HTTPStatusFromCode(st.Code())))
+}
@@ -0,0 +1,100 @@
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   Code
+	}{
+		{name: "alias not found", err: fmt.Errorf("Find: %w", domain.ErrAliasNotFound), wantStatus: http.StatusNotFound, wantCode: CodeAliasNotFound},
+		{name: "alias expired", err: domain.ErrAliasExpired, wantStatus: http.StatusGone, wantCode: CodeAliasExpired},
+		{name: "alias not active", err: fmt.Errorf("Use: %w: activates at 2030-01-01T00:00:00Z", domain.ErrAliasNotActive), wantStatus: http.StatusNotFound, wantCode: CodeAliasNotActive},
+		{name: "invalid list filter", err: fmt.Errorf("List: %w: unknown status", domain.ErrInvalidListFilter), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidFilter},
+		{name: "alias disabled", err: fmt.Errorf("Use: %w", domain.ErrAliasDisabled), wantStatus: http.StatusForbidden, wantCode: CodeAliasDisabled},
+		{name: "destination blocked", err: fmt.Errorf("Use: %w: evil.test", domain.ErrDestinationBlocked), wantStatus: http.StatusForbidden, wantCode: CodeBlocked},
+		{name: "wrong password", err: fmt.Errorf("CheckPassword: %w", domain.ErrWrongPassword), wantStatus: http.StatusForbidden, wantCode: CodeWrongPassword},
+		{name: "too many attempts", err: domain.ErrTooManyAttempts, wantStatus: http.StatusTooManyRequests, wantCode: CodeTooManyAttempts},
+		{name: "invalid expiration", err: fmt.Errorf("Create: %w: 2024-01-01T00:00:00Z is in the past", domain.ErrInvalidExpiration), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidExpiration},
+		{name: "invalid key", err: fmt.Errorf("Import: %w: \"api\" is reserved", domain.ErrInvalidKey), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidKey},
+		{name: "key already exists", err: domain.ErrKeyAlreadyExists, wantStatus: http.StatusConflict, wantCode: CodeKeyExists},
+		{name: "api error is kept", err: InvalidArgument(CodeInvalidJSON, "bad json"), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidJSON},
+		{name: "unknown error is internal", err: assert.AnError, wantStatus: http.StatusInternalServerError, wantCode: CodeInternal},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			got := FromError(testCase.err)
+			assert.Equal(t, testCase.wantStatus, got.Status)
+			assert.Equal(t, testCase.wantCode, got.Code)
+		})
+	}
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	t.Parallel()
+	violation := FieldViolation{Field: "urls[3]", Description: "invalid url"}
+
+	testCases := []struct {
+		name     string
+		err      *Error
+		wantCode codes.Code
+	}{
+		{name: "field violations are kept", err: InvalidArgument(CodeInvalidURL, "request contains invalid urls", violation), wantCode: codes.InvalidArgument},
+		{name: "gone status is restored", err: FromError(domain.ErrAliasExpired), wantCode: codes.NotFound},
+		{name: "unsupported media type is restored", err: New(http.StatusUnsupportedMediaType, CodeUnsupportedMedia, "text/plain is not supported"), wantCode: codes.InvalidArgument},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			st, ok := status.FromError(GRPC(testCase.err))
+			require.True(t, ok)
+			assert.Equal(t, testCase.wantCode, st.Code())
+
+			got := FromGRPCStatus(st, runtime.HTTPStatusFromCode(st.Code()))
+			assert.Equal(t, testCase.err, got)
+		})
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	t.Parallel()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/alias", nil)
+	request = request.WithContext(logging.WithRequestID(request.Context(), "req-1"))
+	recorder := httptest.NewRecorder()
+
+	WriteHTTP(recorder, request, InvalidArgument(CodeInvalidURL, "request contains invalid urls",
+		FieldViolation{Field: "urls[3]", Description: "invalid url: host is missing"}))
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, map[string]any{
+		"code":       "INVALID_URL",
+		"message":    "request contains invalid urls",
+		"request_id": "req-1",
+		"details": []any{
+			map[string]any{"field": "urls[3]", "description": "invalid url: host is missing"},
+		},
+	}, body)
+}
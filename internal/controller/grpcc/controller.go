@@ -2,15 +2,24 @@ package grpcc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/controller/importer"
 	"github.com/xloki21/alias/internal/domain"
 	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
+	"github.com/xloki21/alias/internal/infrastructure/health"
+	"github.com/xloki21/alias/pkg/qrcode"
 	"github.com/xloki21/alias/pkg/urlparser"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"net/url"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"net/http"
+	"sort"
 	"strings"
+	"time"
 )
 
 var _ aliasapi.AliasAPIServer = (*Controller)(nil)
@@ -18,18 +27,48 @@ var _ aliasapi.AliasAPIServer = (*Controller)(nil)
 type aliasService interface {
 	Create(ctx context.Context, requests []domain.CreateRequest) ([]domain.Alias, error)
 	FindOriginalURL(ctx context.Context, key string) (*domain.Alias, error)
-	Use(ctx context.Context, alias *domain.Alias) (*domain.Alias, error)
+	Use(ctx context.Context, alias *domain.Alias, visitor domain.Visitor) (*domain.Visit, error)
+	Rules(ctx context.Context, key string) ([]domain.RedirectRule, error)
+	SetRules(ctx context.Context, key string, rules []domain.RedirectRule) error
+	Variants(ctx context.Context, key string) ([]domain.Variant, error)
+	SetVariants(ctx context.Context, key string, variants []domain.Variant) error
+	SetDisabled(ctx context.Context, key string, disabled bool) error
+	SetActivation(ctx context.Context, key string, activatesAt time.Time) error
+	List(ctx context.Context, filter domain.ListFilter) (*domain.AliasPage, error)
 	Remove(ctx context.Context, key string) error
+	Restore(ctx context.Context, key string) error
+	Import(ctx context.Context, source domain.ImportSource, options domain.ImportOptions,
+		report func([]domain.ImportResult) error) (*domain.ImportSummary, error)
+	ExportAliases(ctx context.Context, filter domain.ListFilter, emit func([]domain.Alias) error) (int, error)
+}
+
+type campaignService interface {
+	Create(ctx context.Context, campaign domain.Campaign) (*domain.Campaign, error)
+	Find(ctx context.Context, name string) (*domain.Campaign, error)
+	List(ctx context.Context) ([]domain.Campaign, error)
+	Remove(ctx context.Context, name string) error
+}
+
+type statisticsService interface {
+	Clicks(ctx context.Context, key string) (*domain.ClickStats, error)
+	ExportClicks(ctx context.Context, filter domain.ClickFilter, emit func([]domain.Click) error) (int, error)
+}
+
+type readinessChecker interface {
+	Readiness(ctx context.Context) health.Report
 }
 
 type Controller struct {
 	aliasapi.UnimplementedAliasAPIServer
-	address string
-	service aliasService
+	address    string
+	service    aliasService
+	campaigns  campaignService
+	statistics statisticsService
+	health     readinessChecker
 }
 
-func NewController(service aliasService, address string) *Controller {
-	return &Controller{service: service, address: address}
+func NewController(service aliasService, campaigns campaignService, statistics statisticsService, health readinessChecker, address string) *Controller {
+	return &Controller{service: service, campaigns: campaigns, statistics: statistics, health: health, address: address}
 }
 
 func (c *Controller) Create(ctx context.Context, data *aliasapi.CreateRequest) (*aliasapi.CreateResponse, error) {
@@ -37,61 +76,662 @@ func (c *Controller) Create(ctx context.Context, data *aliasapi.CreateRequest) (
 	isPermanent := data.MaxUsageCount == nil
 	triesLeft := data.GetMaxUsageCount()
 
+	if len(data.Urls) == 0 {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeEmptyRequest, "request contains no urls",
+			apierror.FieldViolation{Field: "urls", Description: "must contain at least one url"}))
+	}
+
+	if data.GetKey() != "" && len(data.Urls) > 1 {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidKey, "key is given with many urls",
+			apierror.FieldViolation{Field: "key", Description: "may be given with a single url only"}))
+	}
+
+	var violations []apierror.FieldViolation
+	var activatesAt time.Time
+	if data.ActivatesAt != nil {
+		if err := data.GetActivatesAt().CheckValid(); err != nil {
+			violations = append(violations, apierror.FieldViolation{Field: "activates_at", Description: err.Error()})
+		}
+		activatesAt = data.GetActivatesAt().AsTime()
+	}
+	var expiresAt time.Time
+	if data.ExpiresAt != nil {
+		if err := data.GetExpiresAt().CheckValid(); err != nil {
+			violations = append(violations, apierror.FieldViolation{Field: "expires_at", Description: err.Error()})
+		}
+		expiresAt = data.GetExpiresAt().AsTime()
+	}
 	for index, urlString := range data.Urls {
 
-		validURL, err := url.Parse(urlString)
+		validURL, err := urlparser.Validate(urlString)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid url fmt")
+			violations = append(violations, apierror.FieldViolation{
+				Field:       fmt.Sprintf("urls[%d]", index),
+				Description: err.Error(),
+			})
+			continue
 		}
 
 		createRequests[index] = domain.CreateRequest{
+			Key: data.GetKey(),
 			Params: domain.TTLParams{
 				TriesLeft:   int(triesLeft),
 				IsPermanent: isPermanent,
 			},
-			URL: validURL,
+			URL:          validURL,
+			RedirectType: domain.RedirectType(data.GetRedirectType()),
+			Passthrough:  passthroughPolicy(data),
+			Campaign:     data.GetCampaign(),
+			Password:     data.GetPassword(),
+			Interstitial: data.GetInterstitial(),
+			ActivatesAt:  activatesAt,
+			ExpiresAt:    expiresAt,
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidURL, "request contains invalid urls", violations...))
+	}
+
+	var qrOptions qrcode.Options
+	if data.QrCode != nil {
+		if qrOptions, violations = qrCodeOptions(data.GetQrCode(), "qr_code."); len(violations) > 0 {
+			return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidQRCode, "invalid qr code options", violations...))
 		}
 	}
 
 	answer, err := c.service.Create(ctx, createRequests)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, apierror.GRPC(err)
 	}
 	aliases := make([]string, len(answer))
 	for index, alias := range answer {
 		aliases[index] = fmt.Sprintf("%s/%s", c.address, alias.Key)
 	}
 	response := &aliasapi.CreateResponse{Urls: aliases}
+	if data.QrCode != nil {
+		for _, shortURL := range aliases {
+			code, err := qrCodeBody(shortURL, qrOptions)
+			if err != nil {
+				return nil, apierror.GRPC(err)
+			}
+			response.QrCodes = append(response.QrCodes, code)
+		}
+	}
 	return response, nil
 }
 
+// GetQRCode returns the QR code image of the short link
+func (c *Controller) GetQRCode(ctx context.Context, data *aliasapi.QRCodeRequest) (*httpbody.HttpBody, error) {
+	options, violations := qrCodeOptions(&aliasapi.QRCodeOptions{
+		Format:     data.GetFormat(),
+		Size:       data.GetSize(),
+		Margin:     data.Margin,
+		Level:      data.GetLevel(),
+		Foreground: data.GetForeground(),
+		Background: data.GetBackground(),
+	}, "")
+	if len(violations) > 0 {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidQRCode, "invalid qr code options", violations...))
+	}
+
+	alias, err := c.service.FindOriginalURL(ctx, data.GetKey())
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	code, err := qrCodeBody(fmt.Sprintf("%s/%s", c.address, alias.Key), options)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return code, nil
+}
+
+// qrCodeOptions maps the QR code options of the request to the image options, empty fields keep the defaults
+func qrCodeOptions(message *aliasapi.QRCodeOptions, prefix string) (qrcode.Options, []apierror.FieldViolation) {
+	options := qrcode.DefaultOptions()
+	var violations []apierror.FieldViolation
+	violation := func(field string, err error) {
+		violations = append(violations, apierror.FieldViolation{Field: prefix + field, Description: err.Error()})
+	}
+
+	var err error
+	if message.GetFormat() != "" {
+		if options.Format, err = qrcode.ParseFormat(message.GetFormat()); err != nil {
+			violation("format", err)
+		}
+	}
+	if message.GetSize() != 0 {
+		options.Size = int(message.GetSize())
+	}
+	if message.Margin != nil {
+		options.Margin = int(message.GetMargin())
+	}
+	if message.GetLevel() != "" {
+		if options.Level, err = qrcode.ParseLevel(message.GetLevel()); err != nil {
+			violation("level", err)
+		}
+	}
+	if message.GetForeground() != "" {
+		if options.Foreground, err = qrcode.ParseColor(message.GetForeground()); err != nil {
+			violation("foreground", err)
+		}
+	}
+	if message.GetBackground() != "" {
+		if options.Background, err = qrcode.ParseColor(message.GetBackground()); err != nil {
+			violation("background", err)
+		}
+	}
+	if options.Size < qrcode.MinSize || options.Size > qrcode.MaxSize {
+		violation("size", qrcode.ErrInvalidSize)
+	}
+	if options.Margin < 0 || options.Margin > qrcode.MaxMargin {
+		violation("margin", qrcode.ErrInvalidMargin)
+	}
+	return options, violations
+}
+
+func qrCodeBody(content string, options qrcode.Options) (*httpbody.HttpBody, error) {
+	image, err := qrcode.Encode(content, options)
+	if err != nil {
+		return nil, err
+	}
+	return &httpbody.HttpBody{ContentType: options.Format.ContentType(), Data: image}, nil
+}
+
+var passthroughModes = map[aliasapi.PassthroughMode]domain.PassthroughMode{
+	aliasapi.PassthroughMode_PASSTHROUGH_MODE_UNSPECIFIED: domain.PassthroughNone,
+	aliasapi.PassthroughMode_PASSTHROUGH_MODE_NONE:        domain.PassthroughNone,
+	aliasapi.PassthroughMode_PASSTHROUGH_MODE_QUERY:       domain.PassthroughQuery,
+	aliasapi.PassthroughMode_PASSTHROUGH_MODE_PATH:        domain.PassthroughPath,
+	aliasapi.PassthroughMode_PASSTHROUGH_MODE_ALL:         domain.PassthroughAll,
+}
+
+var queryConflicts = map[aliasapi.QueryConflict]domain.QueryConflict{
+	aliasapi.QueryConflict_QUERY_CONFLICT_UNSPECIFIED: domain.QueryConflictKeepTarget,
+	aliasapi.QueryConflict_QUERY_CONFLICT_KEEP_TARGET: domain.QueryConflictKeepTarget,
+	aliasapi.QueryConflict_QUERY_CONFLICT_OVERRIDE:    domain.QueryConflictOverride,
+	aliasapi.QueryConflict_QUERY_CONFLICT_APPEND:      domain.QueryConflictAppend,
+}
+
+// passthroughPolicy maps the policy of the request to the domain one, unknown values are rejected by the service
+func passthroughPolicy(data *aliasapi.CreateRequest) domain.PassthroughPolicy {
+	mode, ok := passthroughModes[data.GetPassthrough()]
+	if !ok {
+		mode = domain.PassthroughMode(data.GetPassthrough().String())
+	}
+	onConflict, ok := queryConflicts[data.GetQueryConflict()]
+	if !ok {
+		onConflict = domain.QueryConflict(data.GetQueryConflict().String())
+	}
+	return domain.PassthroughPolicy{Mode: mode, OnConflict: onConflict}
+}
+
 func (c *Controller) Remove(ctx context.Context, data *aliasapi.KeyRequest) (*emptypb.Empty, error) {
 
 	if err := c.service.Remove(ctx, data.Key); err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, apierror.GRPC(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *Controller) Restore(ctx context.Context, data *aliasapi.KeyRequest) (*emptypb.Empty, error) {
+	if err := c.service.Restore(ctx, data.GetKey()); err != nil {
+		return nil, apierror.GRPC(err)
 	}
-	return nil, nil
+	return &emptypb.Empty{}, nil
+}
+
+func (c *Controller) ListAliases(ctx context.Context, data *aliasapi.ListAliasesRequest) (*aliasapi.AliasList, error) {
+	status, err := domain.ParseAliasStatus(data.GetStatus())
+	if err != nil {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid filter",
+			apierror.FieldViolation{Field: "status", Description: "must be one of active, scheduled, disabled, expired, deleted"}))
+	}
+	page, err := c.service.List(ctx, domain.ListFilter{
+		Status:   status,
+		Campaign: data.GetCampaign(),
+		After:    data.GetCursor(),
+		Limit:    int(data.GetLimit()),
+	})
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	now := time.Now()
+	response := &aliasapi.AliasList{Aliases: make([]*aliasapi.AliasInfo, len(page.Aliases)), NextCursor: page.Next}
+	for index, alias := range page.Aliases {
+		response.Aliases[index] = c.aliasInfo(alias, now)
+	}
+	return response, nil
+}
+
+// GetAlias returns the alias unless it is in the trash
+func (c *Controller) GetAlias(ctx context.Context, data *aliasapi.KeyRequest) (*aliasapi.AliasInfo, error) {
+	alias, err := c.service.FindOriginalURL(ctx, data.GetKey())
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return c.aliasInfo(*alias, time.Now()), nil
+}
+
+// ImportAliases creates the aliases of the streamed rows, the rows are numbered across all the messages.
+// The results are returned once the stream is over, a failure of the storage aborts the import.
+func (c *Controller) ImportAliases(stream grpc.ClientStreamingServer[aliasapi.ImportRequest, aliasapi.ImportResponse]) error {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return stream.SendAndClose(&aliasapi.ImportResponse{})
+	}
+	if err != nil {
+		return err
+	}
+
+	response := &aliasapi.ImportResponse{}
+	source := &streamSource{stream: stream, rows: first.GetRows()}
+	options := domain.ImportOptions{DryRun: first.GetDryRun()}
+	summary, err := c.service.Import(stream.Context(), source, options, func(results []domain.ImportResult) error {
+		for _, result := range results {
+			if first.GetFailuresOnly() && result.Status != domain.ImportFailed {
+				continue
+			}
+			message := &aliasapi.ImportResult{Line: int32(result.Line), Key: result.Key, Status: string(result.Status)}
+			if result.Err != nil {
+				apiErr := apierror.FromError(result.Err)
+				message.Code = string(apiErr.Code)
+				message.Message = apiErr.Message
+			}
+			response.Results = append(response.Results, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return apierror.GRPC(err)
+	}
+
+	response.Rows = int32(summary.Rows)
+	response.Imported = int32(summary.Imported)
+	response.Failed = int32(summary.Failed)
+	response.DryRun = summary.DryRun
+	return stream.SendAndClose(response)
+}
+
+// Export streams all the aliases ordered by key or all the clicks in the order of recording matching the filters
+func (c *Controller) Export(data *aliasapi.ExportRequest, stream grpc.ServerStreamingServer[aliasapi.ExportRecord]) error {
+	ctx := stream.Context()
+	var err error
+	switch data.GetData() {
+	case "", "aliases":
+		status, parseErr := domain.ParseAliasStatus(data.GetStatus())
+		if parseErr != nil {
+			return apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid filter",
+				apierror.FieldViolation{Field: "status", Description: "must be one of active, scheduled, disabled, expired, deleted"}))
+		}
+		filter := domain.ListFilter{Status: status, Campaign: data.GetCampaign(), After: data.GetCursor()}
+		_, err = c.service.ExportAliases(ctx, filter, func(aliases []domain.Alias) error {
+			now := time.Now()
+			for _, alias := range aliases {
+				record := &aliasapi.ExportRecord{Record: &aliasapi.ExportRecord_Alias{Alias: c.aliasInfo(alias, now)}}
+				if err := stream.Send(record); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "clicks":
+		filter := domain.ClickFilter{Key: data.GetKey(), Campaign: data.GetCampaign(), After: data.GetCursor()}
+		var violations []apierror.FieldViolation
+		for name, value := range map[string]*timestamppb.Timestamp{"from": data.GetFrom(), "to": data.GetTo()} {
+			if value == nil {
+				continue
+			}
+			if err := value.CheckValid(); err != nil {
+				violations = append(violations, apierror.FieldViolation{Field: name, Description: err.Error()})
+			}
+		}
+		if len(violations) > 0 {
+			return apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid filter", violations...))
+		}
+		if data.GetFrom() != nil {
+			filter.From = data.GetFrom().AsTime()
+		}
+		if data.GetTo() != nil {
+			filter.To = data.GetTo().AsTime()
+		}
+		_, err = c.statistics.ExportClicks(ctx, filter, func(clicks []domain.Click) error {
+			for _, click := range clicks {
+				record := &aliasapi.ExportRecord{Record: &aliasapi.ExportRecord_Click{Click: &aliasapi.Click{
+					Id:         click.ID,
+					Key:        click.Key,
+					Campaign:   click.Campaign,
+					Rule:       click.Rule,
+					Variant:    click.Variant,
+					OccurredAt: timestamppb.New(click.OccurredAt),
+				}}}
+				if err := stream.Send(record); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		return apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid export",
+			apierror.FieldViolation{Field: "data", Description: "must be one of aliases, clicks"}))
+	}
+	if err != nil {
+		return apierror.GRPC(err)
+	}
+	return nil
+}
+
+// streamSource reads the import rows of a client stream
+type streamSource struct {
+	stream grpc.ClientStreamingServer[aliasapi.ImportRequest, aliasapi.ImportResponse]
+	// rows are the rows of the last message not read yet
+	rows []*aliasapi.ImportRow
+	line int
+}
+
+func (s *streamSource) Next() (domain.ImportRow, error) {
+	for len(s.rows) == 0 {
+		message, err := s.stream.Recv()
+		if err != nil {
+			// io.EOF ends the import
+			return domain.ImportRow{}, err
+		}
+		s.rows = message.GetRows()
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	s.line++
+
+	record := importer.Record{
+		Key:          row.GetKey(),
+		URL:          row.GetUrl(),
+		RedirectType: int(row.GetRedirectType()),
+		Campaign:     row.GetCampaign(),
+	}
+	if row.MaxUsageCount != nil {
+		// too large counts overflow to negative ones and are rejected
+		maxUsageCount := int64(row.GetMaxUsageCount())
+		record.MaxUsageCount = &maxUsageCount
+	}
+	return record.Row(s.line), nil
+}
+
+func (c *Controller) aliasInfo(alias domain.Alias, now time.Time) *aliasapi.AliasInfo {
+	alias = alias.Redacted()
+	info := &aliasapi.AliasInfo{
+		Key:          alias.Key,
+		Url:          fmt.Sprintf("%s/%s", c.address, alias.Key),
+		Target:       alias.URL.String(),
+		Status:       string(alias.Status(now)),
+		IsPermanent:  alias.Params.IsPermanent,
+		TriesLeft:    int64(alias.Params.TriesLeft),
+		RedirectType: aliasapi.RedirectType(alias.Redirect()),
+		Campaign:     alias.Campaign,
+		Protected:    alias.IsProtected(),
+		Interstitial: alias.Interstitial,
+	}
+	if !alias.CreatedAt.IsZero() {
+		info.CreatedAt = timestamppb.New(alias.CreatedAt)
+	}
+	if !alias.ActivatesAt.IsZero() {
+		info.ActivatesAt = timestamppb.New(alias.ActivatesAt)
+	}
+	if !alias.ExpiresAt.IsZero() {
+		info.ExpiresAt = timestamppb.New(alias.ExpiresAt)
+	}
+	if !alias.ExpiredAt.IsZero() {
+		info.ExpiredAt = timestamppb.New(alias.ExpiredAt)
+	}
+	if !alias.DeletedAt.IsZero() {
+		info.DeletedAt = timestamppb.New(alias.DeletedAt)
+	}
+	return info
+}
+
+func (c *Controller) SetActivation(ctx context.Context, data *aliasapi.SetActivationRequest) (*aliasapi.ActivationResponse, error) {
+	var activatesAt time.Time
+	if data.ActivatesAt != nil {
+		if err := data.GetActivatesAt().CheckValid(); err != nil {
+			return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid activation time",
+				apierror.FieldViolation{Field: "activates_at", Description: err.Error()}))
+		}
+		activatesAt = data.GetActivatesAt().AsTime()
+	}
+	if err := c.service.SetActivation(ctx, data.GetKey(), activatesAt); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	response := &aliasapi.ActivationResponse{Key: data.GetKey()}
+	if !activatesAt.IsZero() {
+		response.ActivatesAt = timestamppb.New(activatesAt)
+	}
+	return response, nil
+}
+
+func (c *Controller) DisableAlias(ctx context.Context, data *aliasapi.KeyRequest) (*emptypb.Empty, error) {
+	if err := c.service.SetDisabled(ctx, data.GetKey(), true); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *Controller) EnableAlias(ctx context.Context, data *aliasapi.KeyRequest) (*emptypb.Empty, error) {
+	if err := c.service.SetDisabled(ctx, data.GetKey(), false); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &emptypb.Empty{}, nil
 }
 
 func (c *Controller) FindOriginalURL(ctx context.Context, data *aliasapi.KeyRequest) (*aliasapi.FindResponse, error) {
 	alias, err := c.service.FindOriginalURL(ctx, data.Key)
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, apierror.GRPC(err)
 	}
 	return &aliasapi.FindResponse{Url: fmt.Sprintf("%s/%s", c.address, alias.Key)}, nil
 }
 
+func (c *Controller) GetRules(ctx context.Context, data *aliasapi.KeyRequest) (*aliasapi.RulesResponse, error) {
+	rules, err := c.service.Rules(ctx, data.GetKey())
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &aliasapi.RulesResponse{Key: data.GetKey(), Rules: ruleMessages(rules)}, nil
+}
+
+func (c *Controller) SetRules(ctx context.Context, data *aliasapi.SetRulesRequest) (*aliasapi.RulesResponse, error) {
+	rules := make([]domain.RedirectRule, len(data.GetRules()))
+	var violations []apierror.FieldViolation
+	for index, message := range data.GetRules() {
+		validURL, err := urlparser.Validate(message.GetUrl())
+		if err != nil {
+			violations = append(violations, apierror.FieldViolation{
+				Field:       fmt.Sprintf("rules[%d].url", index),
+				Description: err.Error(),
+			})
+			continue
+		}
+		platforms := make([]domain.Platform, len(message.GetPlatforms()))
+		for i, platform := range message.GetPlatforms() {
+			platforms[i] = domain.Platform(platform)
+		}
+		rules[index] = domain.RedirectRule{
+			Name:      message.GetName(),
+			Platforms: platforms,
+			Languages: message.GetLanguages(),
+			Countries: message.GetCountries(),
+			URL:       validURL,
+		}
+	}
+	if len(violations) > 0 {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidURL, "request contains invalid urls", violations...))
+	}
+
+	if err := c.service.SetRules(ctx, data.GetKey(), rules); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &aliasapi.RulesResponse{Key: data.GetKey(), Rules: ruleMessages(rules)}, nil
+}
+
+func ruleMessages(rules []domain.RedirectRule) []*aliasapi.RedirectRule {
+	messages := make([]*aliasapi.RedirectRule, len(rules))
+	for index, rule := range rules {
+		platforms := make([]string, len(rule.Platforms))
+		for i, platform := range rule.Platforms {
+			platforms[i] = string(platform)
+		}
+		messages[index] = &aliasapi.RedirectRule{
+			Name:      rule.Name,
+			Platforms: platforms,
+			Languages: rule.Languages,
+			Countries: rule.Countries,
+			Url:       rule.URL.String(),
+		}
+	}
+	return messages
+}
+
+func (c *Controller) GetVariants(ctx context.Context, data *aliasapi.KeyRequest) (*aliasapi.VariantsResponse, error) {
+	variants, err := c.service.Variants(ctx, data.GetKey())
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &aliasapi.VariantsResponse{Key: data.GetKey(), Variants: variantMessages(variants)}, nil
+}
+
+func (c *Controller) SetVariants(ctx context.Context, data *aliasapi.SetVariantsRequest) (*aliasapi.VariantsResponse, error) {
+	variants := make([]domain.Variant, len(data.GetVariants()))
+	var violations []apierror.FieldViolation
+	for index, message := range data.GetVariants() {
+		validURL, err := urlparser.Validate(message.GetUrl())
+		if err != nil {
+			violations = append(violations, apierror.FieldViolation{
+				Field:       fmt.Sprintf("variants[%d].url", index),
+				Description: err.Error(),
+			})
+			continue
+		}
+		variants[index] = domain.Variant{Name: message.GetName(), URL: validURL, Weight: int(message.GetWeight())}
+	}
+	if len(violations) > 0 {
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidURL, "request contains invalid urls", violations...))
+	}
+
+	if err := c.service.SetVariants(ctx, data.GetKey(), variants); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &aliasapi.VariantsResponse{Key: data.GetKey(), Variants: variantMessages(variants)}, nil
+}
+
+func variantMessages(variants []domain.Variant) []*aliasapi.Variant {
+	messages := make([]*aliasapi.Variant, len(variants))
+	for index, variant := range variants {
+		messages[index] = &aliasapi.Variant{Name: variant.Name, Url: variant.URL.String(), Weight: int32(variant.Weight)}
+	}
+	return messages
+}
+
+func (c *Controller) GetStats(ctx context.Context, data *aliasapi.KeyRequest) (*aliasapi.StatsResponse, error) {
+	if _, err := c.service.FindOriginalURL(ctx, data.GetKey()); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	stats, err := c.statistics.Clicks(ctx, data.GetKey())
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	response := &aliasapi.StatsResponse{
+		Key:      data.GetKey(),
+		Clicks:   int64(stats.Total),
+		Rules:    make(map[string]int64, len(stats.Rules)),
+		Variants: make(map[string]int64, len(stats.Variants)),
+	}
+	for name, clicks := range stats.Rules {
+		response.Rules[name] = int64(clicks)
+	}
+	for name, clicks := range stats.Variants {
+		response.Variants[name] = int64(clicks)
+	}
+	if !stats.LastClickAt.IsZero() {
+		response.LastClickAt = timestamppb.New(stats.LastClickAt)
+	}
+	return response, nil
+}
+
+func (c *Controller) CreateCampaign(ctx context.Context, data *aliasapi.Campaign) (*aliasapi.Campaign, error) {
+	campaign, err := c.campaigns.Create(ctx, domain.Campaign{Name: data.GetName(), Params: data.GetParams()})
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return campaignMessage(campaign), nil
+}
+
+func (c *Controller) GetCampaign(ctx context.Context, data *aliasapi.CampaignRequest) (*aliasapi.Campaign, error) {
+	campaign, err := c.campaigns.Find(ctx, data.GetName())
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return campaignMessage(campaign), nil
+}
+
+func (c *Controller) ListCampaigns(ctx context.Context, _ *emptypb.Empty) (*aliasapi.CampaignList, error) {
+	campaigns, err := c.campaigns.List(ctx)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	response := &aliasapi.CampaignList{Campaigns: make([]*aliasapi.Campaign, len(campaigns))}
+	for index := range campaigns {
+		response.Campaigns[index] = campaignMessage(&campaigns[index])
+	}
+	return response, nil
+}
+
+func (c *Controller) RemoveCampaign(ctx context.Context, data *aliasapi.CampaignRequest) (*emptypb.Empty, error) {
+	if err := c.campaigns.Remove(ctx, data.GetName()); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func campaignMessage(campaign *domain.Campaign) *aliasapi.Campaign {
+	return &aliasapi.Campaign{
+		Name:      campaign.Name,
+		Params:    campaign.Params,
+		CreatedAt: timestamppb.New(campaign.CreatedAt),
+	}
+}
+
 func (c *Controller) HealthCheck(ctx context.Context, empty *emptypb.Empty) (*emptypb.Empty, error) {
-	return nil, nil
+	report := c.health.Readiness(ctx)
+	if !report.IsReady() {
+		unavailable := make([]string, 0, len(report.Components))
+		for name, component := range report.Components {
+			if component.Status != health.StatusOK {
+				unavailable = append(unavailable, fmt.Sprintf("%s: %s", name, component.Error))
+			}
+		}
+		sort.Strings(unavailable)
+		return nil, apierror.GRPC(apierror.New(http.StatusServiceUnavailable, apierror.CodeUnavailable, strings.Join(unavailable, "; ")))
+	}
+	return &emptypb.Empty{}, nil
 }
 
 func (c *Controller) ProcessMessage(ctx context.Context, data *aliasapi.ProcessMessageRequest) (*aliasapi.ProcessMessageResponse, error) {
 	urls, err := urlparser.FindURLs(data.Message)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, apierror.GRPC(apierror.InvalidArgument(apierror.CodeInvalidArgument, err.Error()))
+	}
+
+	// urls found in the text may omit the scheme, e.g. www.host.test
+	absoluteURLs := make([]string, len(urls))
+	for index, singleURL := range urls {
+		absoluteURLs[index] = singleURL
+		if !strings.Contains(singleURL, "://") {
+			absoluteURLs[index] = "http://" + singleURL
+		}
 	}
+
 	request := &aliasapi.CreateRequest{
-		Urls:          urls,
+		Urls:          absoluteURLs,
 		MaxUsageCount: nil,
 	}
 
@@ -102,7 +742,7 @@ func (c *Controller) ProcessMessage(ctx context.Context, data *aliasapi.ProcessM
 
 	response, err := c.Create(ctx, request)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 	result := data.Message
 
@@ -3,26 +3,192 @@ package interceptors
 import (
 	"context"
 	"fmt"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"time"
 )
 
 // LoggingInterceptor prints log
 func LoggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	zap.S().Infow("gRPC", zap.String("method", info.FullMethod),
+	logger := logging.FromContext(ctx)
+	logger.Infow("gRPC", zap.String("method", info.FullMethod),
 		zap.String("status", "received"))
 	tic := time.Now()
 	resp, err := handler(ctx, req)
-	duration := time.Since(tic)
+	logger.Infow("gRPC", zap.String("method", info.FullMethod),
+		zap.String("status", "processed"),
+		zap.String("duration", formatDuration(time.Since(tic))))
+	return resp, err
+}
 
-	durationString := fmt.Sprintf("%dms", duration.Milliseconds())
+// LoggingStreamInterceptor prints log of the streaming calls
+func LoggingStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	logger := logging.FromContext(stream.Context())
+	logger.Infow("gRPC", zap.String("method", info.FullMethod),
+		zap.String("status", "received"))
+	tic := time.Now()
+	err := handler(srv, stream)
+	logger.Infow("gRPC", zap.String("method", info.FullMethod),
+		zap.String("status", "processed"),
+		zap.String("duration", formatDuration(time.Since(tic))))
+	return err
+}
+
+func formatDuration(duration time.Duration) string {
 	if duration.Milliseconds() < 2 {
-		durationString = fmt.Sprintf("%dμs", duration.Microseconds())
+		return fmt.Sprintf("%dμs", duration.Microseconds())
 	}
+	return fmt.Sprintf("%dms", duration.Milliseconds())
+}
 
-	zap.S().Infow("gRPC", zap.String("method", info.FullMethod),
-		zap.String("status", "processed"),
-		zap.String("duration", durationString))
+// serverStream replaces the context of the wrapped stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// RequestIDInterceptor assigns a request ID to every call, honoring the one sent by the caller,
+// and echoes it in the response header
+func RequestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestID := incomingRequestID(ctx)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(logging.MetadataRequestID, requestID)); err != nil {
+		logging.FromContext(ctx).Errorw("gRPC", zap.String("method", info.FullMethod), zap.Error(err))
+	}
+	return handler(logging.WithRequestID(ctx, requestID), req)
+}
+
+// RequestIDStreamInterceptor is RequestIDInterceptor of the streaming calls
+func RequestIDStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := stream.Context()
+	requestID := incomingRequestID(ctx)
+	if err := stream.SetHeader(metadata.Pairs(logging.MetadataRequestID, requestID)); err != nil {
+		logging.FromContext(ctx).Errorw("gRPC", zap.String("method", info.FullMethod), zap.Error(err))
+	}
+	return handler(srv, &serverStream{ServerStream: stream, ctx: logging.WithRequestID(ctx, requestID)})
+}
+
+// incomingRequestID returns the request ID sent by the caller or a new one
+func incomingRequestID(ctx context.Context) string {
+	var incoming string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.MetadataRequestID); len(values) > 0 {
+			incoming = values[0]
+		}
+	}
+	return logging.EnsureRequestID(incoming)
+}
+
+// metadataCarrier adapts grpc metadata to the otel text map carrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// TracingInterceptor starts a server span for every call, continuing the trace of the caller if any
+func TracingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	ctx, span := tracing.Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCMethod(info.FullMethod)))
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+	st, _ := status.FromError(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, st.Message())
+	}
 	return resp, err
 }
+
+// TracingStreamInterceptor is TracingInterceptor of the streaming calls
+func TracingStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := stream.Context()
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	ctx, span := tracing.Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCMethod(info.FullMethod)))
+	defer span.End()
+
+	err := handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	st, _ := status.FromError(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, st.Message())
+	}
+	return err
+}
+
+// TracingClientInterceptor propagates the trace context of the caller to the called server
+func TracingClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := tracing.Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCMethod(method)))
+	defer span.End()
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+
+	err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// TracingStreamClientInterceptor propagates the trace context of the caller to the streaming calls of the server,
+// the calls are traced by the server only, as a client span could not tell when the stream is over
+func TracingStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return streamer(metadata.NewOutgoingContext(ctx, md), desc, cc, method, opts...)
+}
@@ -0,0 +1,117 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"time"
+)
+
+type campaignService interface {
+	Create(ctx context.Context, campaign domain.Campaign) (*domain.Campaign, error)
+	Find(ctx context.Context, name string) (*domain.Campaign, error)
+	List(ctx context.Context) ([]domain.Campaign, error)
+	Remove(ctx context.Context, name string) error
+}
+
+type campaignPayload struct {
+	Name      string            `json:"name"`
+	Params    map[string]string `json:"params"`
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+}
+
+type campaignList struct {
+	Campaigns []campaignPayload `json:"campaigns"`
+}
+
+func newCampaignPayload(campaign domain.Campaign) campaignPayload {
+	return campaignPayload{Name: campaign.Name, Params: campaign.Params, CreatedAt: &campaign.CreatedAt}
+}
+
+// Campaigns endpoint creates a campaign (POST) or lists all campaigns (GET)
+func (ac *Controller) Campaigns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ac.createCampaign(w, r)
+	case http.MethodGet:
+		ac.listCampaigns(w, r)
+	default:
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+	}
+}
+
+// Campaign endpoint returns (GET) or removes (DELETE) a campaign
+func (ac *Controller) Campaign(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	switch r.Method {
+	case http.MethodGet:
+		campaign, err := ac.campaigns.Find(r.Context(), name)
+		if err != nil {
+			apierror.WriteHTTP(w, r, err)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, newCampaignPayload(*campaign))
+	case http.MethodDelete:
+		if err := ac.campaigns.Remove(r.Context(), name); err != nil {
+			apierror.WriteHTTP(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+	}
+}
+
+func (ac *Controller) createCampaign(w http.ResponseWriter, r *http.Request) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	payload := &campaignPayload{}
+	if err := json.Unmarshal(content, payload); err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidJSON, "request body is not a valid JSON"))
+		return
+	}
+
+	campaign, err := ac.campaigns.Create(r.Context(), domain.Campaign{Name: payload.Name, Params: payload.Params})
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, newCampaignPayload(*campaign))
+}
+
+func (ac *Controller) listCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := ac.campaigns.List(r.Context())
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	response := campaignList{Campaigns: make([]campaignPayload, len(campaigns))}
+	for index, campaign := range campaigns {
+		response.Campaigns[index] = newCampaignPayload(campaign)
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, value any) {
+	answer, err := json.Marshal(value)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(answer); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+	}
+}
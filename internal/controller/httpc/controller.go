@@ -5,13 +5,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/controller/httpc/pages"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/attempts"
+	"github.com/xloki21/alias/internal/infrastructure/health"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/pkg/urlparser"
 	"go.uber.org/zap"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const maxGoroutines = 10
@@ -19,16 +29,44 @@ const maxGoroutines = 10
 type aliasService interface {
 	Create(ctx context.Context, requests []domain.CreateRequest) ([]domain.Alias, error)
 	FindOriginalURL(ctx context.Context, key string) (*domain.Alias, error)
-	Use(ctx context.Context, alias *domain.Alias) (*domain.Alias, error)
+	Use(ctx context.Context, alias *domain.Alias, visitor domain.Visitor) (*domain.Visit, error)
+	Preview(ctx context.Context, alias *domain.Alias, visitor domain.Visitor, extraPath string, query url.Values) (*domain.Preview, error)
+	CheckPassword(ctx context.Context, alias *domain.Alias, password string) error
+	Available(ctx context.Context, alias *domain.Alias) error
+	SetActivation(ctx context.Context, key string, activatesAt time.Time) error
+	List(ctx context.Context, filter domain.ListFilter) (*domain.AliasPage, error)
+	Rules(ctx context.Context, key string) ([]domain.RedirectRule, error)
+	SetRules(ctx context.Context, key string, rules []domain.RedirectRule) error
+	Variants(ctx context.Context, key string) ([]domain.Variant, error)
+	SetVariants(ctx context.Context, key string, variants []domain.Variant) error
+	SetDisabled(ctx context.Context, key string, disabled bool) error
 	Remove(ctx context.Context, key string) error
+	Restore(ctx context.Context, key string) error
+	Import(ctx context.Context, source domain.ImportSource, options domain.ImportOptions,
+		report func([]domain.ImportResult) error) (*domain.ImportSummary, error)
+	ExportAliases(ctx context.Context, filter domain.ListFilter, emit func([]domain.Alias) error) (int, error)
+	RedirectURL(ctx context.Context, alias *domain.Alias, extraPath string, query url.Values) (*url.URL, error)
+}
+
+type statisticsService interface {
+	Clicks(ctx context.Context, key string) (*domain.ClickStats, error)
+	ExportClicks(ctx context.Context, filter domain.ClickFilter, emit func([]domain.Click) error) (int, error)
+}
+
+type readinessChecker interface {
+	Readiness(ctx context.Context) health.Report
 }
 
 type requestURLList struct {
 	URLs []string `json:"urls"`
+	// Password is passed in the body rather than in the query, so it never shows up in the access logs
+	Password string `json:"password,omitempty"`
 }
 
 type responseURLList struct {
 	URLs []string `json:"urls"`
+	// QRCodes are the data URIs of the QR code images, in the order of the urls
+	QRCodes []string `json:"qrCodes,omitempty"`
 }
 
 // helper struct to keep order of the validated URL's
@@ -37,18 +75,48 @@ type indexedResult struct {
 	request domain.CreateRequest
 }
 
+const (
+	defaultRedirectCacheMaxAge = 24 * time.Hour
+	defaultCountryHeader       = "X-Country-Code"
+	// variantCookie keeps the A/B variant of the visitor, the cookie is scoped to the path of the alias
+	variantCookie       = "alias_variant"
+	variantCookieMaxAge = 30 * 24 * time.Hour
+)
+
 type Controller struct {
-	address string
-	service aliasService
+	address        string
+	service        aliasService
+	campaigns      campaignService
+	statistics     statisticsService
+	health         readinessChecker
+	redirectMaxAge atomic.Int64
+	countryHeader  atomic.Value
+	clientIPHeader atomic.Value
+	attempts       *attempts.Limiter
+	pages          atomic.Pointer[pages.Renderer]
+	notActive      atomic.Value
 }
 
-func NewController(service aliasService, address string) *Controller {
-	return &Controller{service: service, address: address}
+func NewController(service aliasService, campaigns campaignService, statistics statisticsService, health readinessChecker, address string) *Controller {
+	ac := &Controller{
+		service:    service,
+		campaigns:  campaigns,
+		statistics: statistics,
+		health:     health,
+		address:    address,
+		attempts:   attempts.NewLimiter(defaultPasswordMaxAttempts, defaultPasswordWindow),
+	}
+	ac.SetRedirectCacheMaxAge(defaultRedirectCacheMaxAge)
+	ac.SetCountryHeader(defaultCountryHeader)
+	ac.SetClientIPHeader("")
+	ac.SetPages(pages.MustDefault())
+	ac.SetNotActiveResponse(domain.NotActiveNotFound)
+	return ac
 }
 
 func (ac *Controller) CreateAlias(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 
@@ -60,51 +128,125 @@ func (ac *Controller) CreateAlias(w http.ResponseWriter, r *http.Request) {
 		isPermanent = true
 	} else {
 		if len(maxUsageCount) != 1 {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+				apierror.FieldViolation{Field: "maxUsageCount", Description: "must be set once"}))
 			return
 		}
 		value, err := strconv.ParseInt(maxUsageCount[0], 10, 64)
-		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		if err != nil || value < 0 {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+				apierror.FieldViolation{Field: "maxUsageCount", Description: "must be a non-negative integer"}))
 			return
 		}
 		triesLeftValue = int(value)
 	}
 
+	redirectType := domain.RedirectDefault
+	if value := query.Get("redirectType"); value != "" {
+		parsed, err := domain.ParseRedirectType(value)
+		if err != nil {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidRedirect, "invalid query parameter",
+				apierror.FieldViolation{Field: "redirectType", Description: "must be one of 301, 302, 307, 308"}))
+			return
+		}
+		redirectType = parsed
+	}
+
+	passthroughMode, err := domain.ParsePassthroughMode(query.Get("passthrough"))
+	if err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidPassthrough, "invalid query parameter",
+			apierror.FieldViolation{Field: "passthrough", Description: "must be one of none, query, path, all"}))
+		return
+	}
+	queryConflict, err := domain.ParseQueryConflict(query.Get("queryConflict"))
+	if err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidPassthrough, "invalid query parameter",
+			apierror.FieldViolation{Field: "queryConflict", Description: "must be one of keep-target, override, append"}))
+		return
+	}
+	passthrough := domain.PassthroughPolicy{Mode: passthroughMode, OnConflict: queryConflict}
+	campaign := query.Get("campaign")
+	key := query.Get("key")
+	var interstitial bool
+	if value := query.Get("interstitial"); value != "" {
+		if interstitial, err = strconv.ParseBool(value); err != nil {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+				apierror.FieldViolation{Field: "interstitial", Description: "must be a boolean"}))
+			return
+		}
+	}
+	var activatesAt time.Time
+	if value := query.Get("activatesAt"); value != "" {
+		if activatesAt, err = time.Parse(time.RFC3339, value); err != nil {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+				apierror.FieldViolation{Field: "activatesAt", Description: "must be an RFC 3339 time"}))
+			return
+		}
+	}
+	var expiresAt time.Time
+	if value := query.Get("expiresAt"); value != "" {
+		if expiresAt, err = time.Parse(time.RFC3339, value); err != nil {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+				apierror.FieldViolation{Field: "expiresAt", Description: "must be an RFC 3339 time"}))
+			return
+		}
+	}
+	_, withQRCodes := query[createQRCodeQuery.format]
+	qrOptions, violations := createQRCodeQuery.parse(query)
+	if len(violations) > 0 {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidQRCode, "invalid query parameter", violations...))
+		return
+	}
+
 	content, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		apierror.WriteHTTP(w, r, err)
 		return
 	}
 
 	payload := &requestURLList{}
 	if err := json.Unmarshal(content, payload); err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidJSON, "request body is not a valid JSON"))
 		return
 	}
 
 	if len(payload.URLs) == 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeEmptyRequest, "request contains no urls",
+			apierror.FieldViolation{Field: "urls", Description: "must contain at least one url"}))
+		return
+	}
+	if key != "" && len(payload.URLs) > 1 {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidKey, "key is given with many urls",
+			apierror.FieldViolation{Field: "key", Description: "may be given with a single url only"}))
 		return
 	}
 
 	// validate request
 	wg := sync.WaitGroup{}
 	semaphore := make(chan struct{}, maxGoroutines)
-	errChan := make(chan error, len(payload.URLs))
+	errChan := make(chan apierror.FieldViolation, len(payload.URLs))
 	resultChan := make(chan indexedResult, len(payload.URLs))
 	for index, urlString := range payload.URLs {
 		semaphore <- struct{}{}
 		wg.Add(1)
 		go func(index int, urlString string) {
 			defer wg.Done()
-			validURL, err := url.Parse(urlString)
+			validURL, err := urlparser.Validate(urlString)
 			if err != nil {
-				errChan <- err
+				errChan <- apierror.FieldViolation{Field: fmt.Sprintf("urls[%d]", index), Description: err.Error()}
+				return
 			}
 			resultChan <- indexedResult{index: index, request: domain.CreateRequest{
-				Params: domain.TTLParams{TriesLeft: triesLeftValue, IsPermanent: isPermanent},
-				URL:    validURL,
+				Key:          key,
+				Params:       domain.TTLParams{TriesLeft: triesLeftValue, IsPermanent: isPermanent},
+				URL:          validURL,
+				RedirectType: redirectType,
+				Passthrough:  passthrough,
+				Campaign:     campaign,
+				Password:     payload.Password,
+				Interstitial: interstitial,
+				ActivatesAt:  activatesAt,
+				ExpiresAt:    expiresAt,
 			}}
 
 		}(index, urlString)
@@ -116,11 +258,16 @@ func (ac *Controller) CreateAlias(w http.ResponseWriter, r *http.Request) {
 	close(resultChan)
 	close(errChan)
 
-	for errVal := range errChan {
-		if errVal != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
+	if len(errChan) > 0 {
+		violations := make([]apierror.FieldViolation, 0, len(errChan))
+		for violation := range errChan {
+			violations = append(violations, violation)
 		}
+		sort.Slice(violations, func(i, j int) bool {
+			return fieldIndex(violations[i].Field) < fieldIndex(violations[j].Field)
+		})
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidURL, "request contains invalid urls", violations...))
+		return
 	}
 	requests := make([]domain.CreateRequest, len(payload.URLs), len(payload.URLs))
 	for entry := range resultChan {
@@ -129,7 +276,7 @@ func (ac *Controller) CreateAlias(w http.ResponseWriter, r *http.Request) {
 
 	aliases, err := ac.service.Create(r.Context(), requests)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		apierror.WriteHTTP(w, r, err)
 		return
 	}
 
@@ -137,73 +284,257 @@ func (ac *Controller) CreateAlias(w http.ResponseWriter, r *http.Request) {
 	for index := range aliases {
 		response.URLs[index] = fmt.Sprintf("%s/%s", ac.address, aliases[index].Key)
 	}
+	if withQRCodes {
+		response.QRCodes = make([]string, len(response.URLs))
+		for index, shortURL := range response.URLs {
+			if response.QRCodes[index], err = qrCodeDataURI(shortURL, qrOptions); err != nil {
+				apierror.WriteHTTP(w, r, err)
+				return
+			}
+		}
+	}
 
 	answer, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		apierror.WriteHTTP(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if _, err := w.Write(answer); err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
 	}
-	return
 }
 
+// fieldIndex extracts the index from the field name in form of "urls[N]"
+func fieldIndex(field string) int {
+	var index int
+	if _, err := fmt.Sscanf(field, "urls[%d]", &index); err != nil {
+		return -1
+	}
+	return index
+}
+
+// Redirect sends the visitor to the destination of the alias.
+// The preview page is shown instead for "/{key}+", "?preview=1" and the interstitial aliases.
+// A protected alias renders the password form on GET and redirects only after the password is POSTed.
 func (ac *Controller) Redirect(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
-	key := r.PathValue("key")
+	key, previewSuffix := strings.CutSuffix(r.PathValue("key"), "+")
+	query := r.URL.Query()
+	previewParam, previewSet := previewMode(query)
 
 	alias, err := ac.service.FindOriginalURL(r.Context(), key)
 
 	if err != nil {
 		if errors.Is(err, domain.ErrAliasNotFound) {
-			zap.S().Error("alias not found", zap.String("key", key))
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		} else {
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("alias not found", zap.String("key", key))
+		}
+		ac.writeVisitError(w, r, key, nil, err)
+		return
+	}
+
+	visitor := domain.Visitor{
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		Country:        r.Header.Get(ac.CountryHeader()),
+	}
+	if cookie, err := r.Cookie(variantCookie); err == nil {
+		visitor.Variant = cookie.Value
+	}
+
+	if alias.IsProtected() {
+		// the password is not asked for the aliases which cannot be followed anyway
+		if err := ac.service.Available(r.Context(), alias); err != nil {
+			ac.writeVisitError(w, r, key, alias, err)
+			return
 		}
+	}
+
+	switch {
+	case r.Method == http.MethodGet && (previewSuffix || previewParam):
+		ac.preview(w, r, alias, visitor, query)
+		return
+	case alias.IsProtected() && r.Method == http.MethodGet:
+		ac.writePasswordForm(w, r, http.StatusOK, "")
+		return
+	case alias.IsProtected():
+		if !ac.unlock(w, r, alias) {
+			return
+		}
+	case r.Method != http.MethodGet:
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	case alias.Interstitial && !previewSet:
+		// "?preview=0" is the link of the interstitial page to continue
+		ac.preview(w, r, alias, visitor, query)
 		return
 	}
 
-	alias, err = ac.service.Use(r.Context(), alias)
+	visit, err := ac.service.Use(r.Context(), alias, visitor)
 
 	if err != nil {
-		if errors.Is(err, domain.ErrAliasExpired) {
-			http.Error(w, "url expired", http.StatusGone)
-		} else {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		}
+		ac.writeVisitError(w, r, key, alias, err)
+		return
+	}
+	alias = visit.Alias
+
+	target, err := ac.service.RedirectURL(r.Context(), alias, extraPath(r), query)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
 		return
 	}
-	http.Redirect(w, r, alias.URL.String(), http.StatusTemporaryRedirect)
+
+	redirectType := alias.Redirect()
+	if len(alias.Rules) > 0 {
+		// the destination depends on the visitor, shared caches must not reuse it for others
+		w.Header().Set("Vary", "User-Agent, Accept-Language, "+ac.CountryHeader())
+	}
+	if visit.Variant != "" {
+		// repeat visits land on the same variant
+		http.SetCookie(w, &http.Cookie{
+			Name:     variantCookie,
+			Value:    visit.Variant,
+			Path:     "/" + key,
+			MaxAge:   int(variantCookieMaxAge.Seconds()),
+			Secure:   r.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	if redirectType.IsCacheable() {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ac.RedirectCacheMaxAge().Seconds())))
+	} else {
+		// usage-limited and tracking aliases must hit the service on every visit
+		w.Header().Set("Cache-Control", "private, no-cache, no-store, must-revalidate")
+	}
+	status := redirectType.StatusCode()
+	if r.Method == http.MethodPost {
+		// 307 and 308 would make the browser post the password to the destination
+		status = http.StatusSeeOther
+	}
+	http.Redirect(w, r, target.String(), status)
+}
+
+// extraPath returns the escaped path following the key in the visited link
+func extraPath(r *http.Request) string {
+	_, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	return rest
+}
+
+// SetRedirectCacheMaxAge sets how long browsers may cache permanent redirects
+func (ac *Controller) SetRedirectCacheMaxAge(maxAge time.Duration) {
+	ac.redirectMaxAge.Store(int64(maxAge))
+}
+
+// RedirectCacheMaxAge returns how long browsers may cache permanent redirects
+func (ac *Controller) RedirectCacheMaxAge() time.Duration {
+	return time.Duration(ac.redirectMaxAge.Load())
+}
+
+// SetCountryHeader sets the header the proxy in front of the service passes the country of the visitor in
+func (ac *Controller) SetCountryHeader(header string) {
+	ac.countryHeader.Store(http.CanonicalHeaderKey(header))
+}
+
+// CountryHeader returns the header the proxy in front of the service passes the country of the visitor in
+func (ac *Controller) CountryHeader() string {
+	return ac.countryHeader.Load().(string)
+}
+
+// Disable endpoint stops the alias from redirecting the visitors until it is enabled again
+func (ac *Controller) Disable(w http.ResponseWriter, r *http.Request) {
+	ac.setDisabled(w, r, true)
+}
+
+// Enable endpoint makes the disabled alias redirect the visitors again
+func (ac *Controller) Enable(w http.ResponseWriter, r *http.Request) {
+	ac.setDisabled(w, r, false)
+}
+
+func (ac *Controller) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	if r.Method != http.MethodPost {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	if err := ac.service.SetDisabled(r.Context(), r.PathValue("key"), disabled); err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Restore endpoint takes the removed alias back from the trash
+func (ac *Controller) Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	if err := ac.service.Restore(r.Context(), r.PathValue("key")); err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (ac *Controller) RemoveAlias(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 	key := r.PathValue("key")
 
 	if err := ac.service.Remove(r.Context(), key); err != nil {
-		if errors.Is(err, domain.ErrAliasNotFound) {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		} else {
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+		apierror.WriteHTTP(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Healthcheck endpoint
+// Healthcheck endpoint reports whether the application is ready to serve requests
 func (ac *Controller) Healthcheck(w http.ResponseWriter, r *http.Request) {
+	if !ac.health.Readiness(r.Context()).IsReady() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// Livez endpoint reports that the application process is alive
+func (ac *Controller) Livez(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, r, health.Report{Status: health.StatusOK})
+}
+
+// Readyz endpoint reports per-component readiness of the application
+func (ac *Controller) Readyz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, r, ac.health.Readiness(r.Context()))
+}
+
+func writeHealthReport(w http.ResponseWriter, r *http.Request, report health.Report) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+
+	answer, err := json.Marshal(report)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !report.IsReady() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if _, err := w.Write(answer); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+	}
+}
@@ -0,0 +1,149 @@
+package httpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/squeue"
+	"github.com/xloki21/alias/internal/repository/inmemory"
+	"github.com/xloki21/alias/internal/services/aliassvc"
+	"github.com/xloki21/alias/pkg/keygen"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestExtraPath(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{name: "key only", target: "/abc", want: ""},
+		{name: "key with a trailing slash", target: "/abc/", want: ""},
+		{name: "single segment", target: "/abc/docs", want: "docs"},
+		{name: "trailing slash is kept", target: "/abc/docs/", want: "docs/"},
+		{name: "nested segments", target: "/abc/docs/v1/index.html", want: "docs/v1/index.html"},
+		{name: "encoded slash stays escaped", target: "/abc/a%2Fb/c", want: "a%2Fb/c"},
+		{name: "encoded space stays escaped", target: "/abc/my%20file", want: "my%20file"},
+		{name: "preview suffix", target: "/abc+/docs", want: "docs"},
+		{name: "query is not a part of the path", target: "/abc/docs?page=2", want: "docs"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.want, extraPath(httptest.NewRequest(http.MethodGet, testCase.target, nil)))
+		})
+	}
+}
+
+// newTestController creates a controller backed by the in-memory storage holding the aliases created by the requests
+func newTestController(t *testing.T, requests ...domain.CreateRequest) *Controller {
+	t.Helper()
+	queue := squeue.NewWithCapacity(100)
+	service := aliassvc.NewAlias(queue, queue, inmemory.NewAliasRepository(), keygen.NewURLSafeRandomStringGenerator(),
+		inmemory.NewCampaignRepository())
+	_, err := service.Create(context.Background(), requests)
+	require.NoError(t, err)
+	return NewController(service, nil, nil, nil, "http://sho.rt")
+}
+
+// newRedirectTestServer routes the visited links to a controller created by newTestController
+func newRedirectTestServer(t *testing.T, requests ...domain.CreateRequest) *httptest.Server {
+	t.Helper()
+	ctrl := newTestController(t, requests...)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{key}", ctrl.Redirect)
+	mux.HandleFunc("/{key}/{path...}", ctrl.Redirect)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestController_Redirect_Passthrough(t *testing.T) {
+	t.Parallel()
+	target, err := url.Parse("https://example.com/base?src=alias")
+	require.NoError(t, err)
+	create := func(key string, mode domain.PassthroughMode) domain.CreateRequest {
+		return domain.CreateRequest{
+			Key:         key,
+			Params:      domain.TTLParams{IsPermanent: true},
+			URL:         target,
+			Passthrough: domain.PassthroughPolicy{Mode: mode, OnConflict: domain.QueryConflictKeepTarget},
+		}
+	}
+	server := newRedirectTestServer(t,
+		create("all", domain.PassthroughAll),
+		create("path", domain.PassthroughPath),
+		create("query", domain.PassthroughQuery),
+		create("none", domain.PassthroughNone),
+	)
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	testCases := []struct {
+		name     string
+		visited  string
+		status   int
+		location string
+	}{
+		{name: "key only", visited: "/all", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base?src=alias"},
+		{name: "path and query", visited: "/all/docs/intro?lang=en", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base/docs/intro?lang=en&src=alias"},
+		{name: "encoded slash is passed escaped", visited: "/all/a%2Fb", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base/a%2Fb?src=alias"},
+		{name: "trailing slash is passed", visited: "/all/docs/", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base/docs/?src=alias"},
+		{name: "conflicting query keeps the target", visited: "/all?src=visitor", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base?src=alias"},
+		{name: "path mode drops the query", visited: "/path/docs?lang=en", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base/docs?src=alias"},
+		{name: "query mode drops the path", visited: "/query/docs?lang=en", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base?lang=en&src=alias"},
+		{name: "none mode passes nothing", visited: "/none/docs?lang=en", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base?src=alias"},
+		{name: "preview parameter is never passed", visited: "/all/docs?preview=0", status: http.StatusTemporaryRedirect,
+			location: "https://example.com/base/docs?src=alias"},
+		{name: "preview suffix with a path shows the preview", visited: "/all+/docs?lang=en", status: http.StatusOK},
+		{name: "unknown key", visited: "/missing/docs", status: http.StatusNotFound},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			response, err := client.Get(server.URL + testCase.visited)
+			require.NoError(t, err)
+			defer response.Body.Close()
+			assert.Equal(t, testCase.status, response.StatusCode)
+			assert.Equal(t, testCase.location, response.Header.Get("Location"))
+		})
+	}
+}
+
+func TestController_Redirect_PreviewWithPath(t *testing.T) {
+	t.Parallel()
+	target, err := url.Parse("https://example.com/base")
+	require.NoError(t, err)
+	server := newRedirectTestServer(t, domain.CreateRequest{
+		Key:         "all",
+		Params:      domain.TTLParams{IsPermanent: true},
+		URL:         target,
+		Passthrough: domain.PassthroughPolicy{Mode: domain.PassthroughAll, OnConflict: domain.QueryConflictKeepTarget},
+	})
+
+	response, err := server.Client().Get(server.URL + "/all+/a%2Fb/docs?lang=en")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	page := string(body)
+	// the preview shows the destination the visited link leads to and continues with the same path
+	assert.Contains(t, page, "https://example.com/base/a%2Fb/docs?lang=en")
+	assert.Contains(t, page, "/all/a%2Fb/docs?lang=en&amp;preview=0")
+}
@@ -0,0 +1,68 @@
+package httpc
+
+import (
+	"errors"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/controller/httpc/pages"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"net/http"
+)
+
+// errorPages maps the errors of the visited links to the pages explaining them to the browsers
+var errorPages = map[apierror.Code]pages.Name{
+	apierror.CodeAliasNotFound:  pages.NotFound,
+	apierror.CodeAliasExpired:   pages.Expired,
+	apierror.CodeAliasDisabled:  pages.Disabled,
+	apierror.CodeBlocked:        pages.Blocked,
+	apierror.CodeAliasNotActive: pages.ComingSoon,
+}
+
+// writeVisitError reports why the visitor of the alias cannot be redirected:
+// browsers get the page of the error, API clients and the errors without a page get JSON.
+// alias is nil if it is not found.
+func (ac *Controller) writeVisitError(w http.ResponseWriter, r *http.Request, key string, alias *domain.Alias, err error) {
+	if errors.Is(err, domain.ErrAliasNotActive) && ac.NotActiveResponse() == domain.NotActiveNotFound {
+		err = domain.ErrAliasNotFound
+	}
+	apiErr := apierror.FromError(err)
+	name, ok := errorPages[apiErr.Code]
+	if ok {
+		w.Header().Add("Vary", "Accept")
+	}
+	if !ok || !pages.WantsHTML(r) {
+		apierror.WriteHTTP(w, r, apiErr)
+		return
+	}
+	data := pages.Error{
+		Key:       key,
+		Status:    apiErr.Status,
+		Code:      string(apiErr.Code),
+		Message:   apiErr.Message,
+		RequestID: logging.RequestID(r.Context()),
+	}
+	if alias != nil && apiErr.Code == apierror.CodeAliasNotActive {
+		data.ActivatesAt = alias.ActivatesAt
+	}
+	ac.Pages().Render(w, r, name, apiErr.Status, data)
+}
+
+// SetPages sets the renderer of the pages shown to the visitors
+func (ac *Controller) SetPages(renderer *pages.Renderer) {
+	ac.pages.Store(renderer)
+}
+
+// Pages returns the renderer of the pages shown to the visitors
+func (ac *Controller) Pages() *pages.Renderer {
+	return ac.pages.Load()
+}
+
+// SetNotActiveResponse sets how the visitors of the scheduled aliases are answered
+func (ac *Controller) SetNotActiveResponse(response domain.NotActiveResponse) {
+	ac.notActive.Store(response)
+}
+
+// NotActiveResponse returns how the visitors of the scheduled aliases are answered
+func (ac *Controller) NotActiveResponse() domain.NotActiveResponse {
+	return ac.notActive.Load().(domain.NotActiveResponse)
+}
@@ -0,0 +1,203 @@
+package httpc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	exportAliases = "aliases"
+	exportClicks  = "clicks"
+	formatNDJSON  = "ndjson"
+	formatCSV     = "csv"
+)
+
+var (
+	aliasCSVHeader = []string{"key", "url", "target", "status", "isPermanent", "triesLeft", "redirectType", "campaign",
+		"protected", "interstitial", "createdAt", "activatesAt", "expiresAt", "expiredAt",
+		"deletedAt"}
+	clickCSVHeader = []string{"id", "key", "campaign", "rule", "variant", "occurredAt"}
+)
+
+type clickPayload struct {
+	ID         string    `json:"id"`
+	Key        string    `json:"key"`
+	Campaign   string    `json:"campaign,omitempty"`
+	Rule       string    `json:"rule,omitempty"`
+	Variant    string    `json:"variant,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+func (p aliasPayload) csvRecord() []string {
+	return []string{p.Key, p.URL, p.Target, p.Status, strconv.FormatBool(p.IsPermanent), strconv.Itoa(p.TriesLeft),
+		strconv.Itoa(p.RedirectType), p.Campaign, strconv.FormatBool(p.Protected), strconv.FormatBool(p.Interstitial),
+		csvTime(p.CreatedAt), csvTime(p.ActivatesAt), csvTime(p.ExpiresAt), csvTime(p.ExpiredAt),
+		csvTime(p.DeletedAt)}
+}
+
+func (p clickPayload) csvRecord() []string {
+	return []string{p.ID, p.Key, p.Campaign, p.Rule, p.Variant, csvTime(&p.OccurredAt)}
+}
+
+func csvTime(value *time.Time) string {
+	if value == nil || value.IsZero() {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+// exportWriter writes the exported records as NDJSON or CSV, the records are flushed to the client page by page
+type exportWriter struct {
+	controller *http.ResponseController
+	json       *json.Encoder
+	csv        *csv.Writer
+}
+
+func (e *exportWriter) write(record interface{ csvRecord() []string }) error {
+	if e.csv != nil {
+		return e.csv.Write(record.csvRecord())
+	}
+	return e.json.Encode(record)
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := e.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	extendDeadlines(e.controller)
+	return nil
+}
+
+// Export endpoint streams all the aliases (data=aliases) or the clicks (data=clicks) matching the filters
+// as NDJSON or CSV (format=ndjson|csv). The aliases are ordered by key, the clicks in the order of recording,
+// cursor is the key or the click ID the export continues after.
+func (ac *Controller) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = formatNDJSON
+	}
+	if format != formatNDJSON && format != formatCSV {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+			apierror.FieldViolation{Field: "format", Description: "must be one of ndjson, csv"}))
+		return
+	}
+
+	data := query.Get("data")
+	if data == "" {
+		data = exportAliases
+	}
+	var header []string
+	var export func(writer *exportWriter) (int, error)
+	switch data {
+	case exportAliases:
+		status, err := domain.ParseAliasStatus(query.Get("status"))
+		if err != nil {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid query parameter",
+				apierror.FieldViolation{Field: "status", Description: "must be one of active, scheduled, disabled, expired, deleted"}))
+			return
+		}
+		filter := domain.ListFilter{Status: status, Campaign: query.Get("campaign"), After: query.Get("cursor")}
+		header = aliasCSVHeader
+		export = func(writer *exportWriter) (int, error) {
+			return ac.service.ExportAliases(r.Context(), filter, func(aliases []domain.Alias) error {
+				now := time.Now()
+				for _, alias := range aliases {
+					if err := writer.write(ac.newAliasPayload(alias, now)); err != nil {
+						return err
+					}
+				}
+				return writer.flush()
+			})
+		}
+	case exportClicks:
+		filter := domain.ClickFilter{Key: query.Get("key"), Campaign: query.Get("campaign"), After: query.Get("cursor")}
+		for name, value := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			if raw := query.Get(name); raw != "" {
+				parsed, err := time.Parse(time.RFC3339, raw)
+				if err != nil {
+					apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid query parameter",
+						apierror.FieldViolation{Field: name, Description: "must be an RFC 3339 time"}))
+					return
+				}
+				*value = parsed
+			}
+		}
+		if err := filter.Validate(); err != nil {
+			apierror.WriteHTTP(w, r, err)
+			return
+		}
+		header = clickCSVHeader
+		export = func(writer *exportWriter) (int, error) {
+			return ac.statistics.ExportClicks(r.Context(), filter, func(clicks []domain.Click) error {
+				for _, click := range clicks {
+					payload := clickPayload{ID: click.ID, Key: click.Key, Campaign: click.Campaign, Rule: click.Rule,
+						Variant: click.Variant, OccurredAt: click.OccurredAt}
+					if err := writer.write(payload); err != nil {
+						return err
+					}
+				}
+				return writer.flush()
+			})
+		}
+	default:
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+			apierror.FieldViolation{Field: "data", Description: "must be one of aliases, clicks"}))
+		return
+	}
+
+	writer := &exportWriter{controller: http.NewResponseController(w), json: json.NewEncoder(w)}
+	contentType := "application/x-ndjson"
+	if format == formatCSV {
+		contentType = "text/csv; charset=utf-8"
+		writer.csv = csv.NewWriter(w)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", data+"."+format))
+	w.WriteHeader(http.StatusOK)
+	extendDeadlines(writer.controller)
+	if writer.csv != nil {
+		if err := writer.csv.Write(header); err != nil {
+			return
+		}
+	}
+
+	exported, err := export(writer)
+	if err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err), zap.Int("exported", exported))
+		if writer.csv != nil {
+			// CSV has no room for the error, the broken connection tells the client the export is incomplete
+			panic(http.ErrAbortHandler)
+		}
+		apiErr := *apierror.FromError(err)
+		apiErr.RequestID = logging.RequestID(r.Context())
+		if err := writer.json.Encode(errorLine{Error: &apiErr}); err != nil {
+			logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+		}
+		return
+	}
+	if err := writer.flush(); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+	}
+}
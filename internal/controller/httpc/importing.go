@@ -0,0 +1,167 @@
+package httpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/controller/importer"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// batchTimeout is the time given to read and answer every batch of the streamed imports and exports,
+// the deadlines of the server are extended by it as the stream goes on
+const batchTimeout = 30 * time.Second
+
+type importResultPayload struct {
+	Line    int           `json:"line"`
+	Key     string        `json:"key,omitempty"`
+	Status  string        `json:"status"`
+	Code    apierror.Code `json:"code,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+type importSummaryPayload struct {
+	Rows     int  `json:"rows"`
+	Imported int  `json:"imported"`
+	Failed   int  `json:"failed"`
+	DryRun   bool `json:"dryRun"`
+}
+
+type importSummaryLine struct {
+	Summary importSummaryPayload `json:"summary"`
+}
+
+// errorLine ends a stream aborted by the error
+type errorLine struct {
+	Error *apierror.Error `json:"error"`
+}
+
+// importSource creates the source of the imported rows by the content type of the body
+func importSource(r *http.Request) (domain.ImportSource, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = r.Header.Get("Content-Type")
+	}
+	switch mediaType {
+	case "text/csv":
+		return importer.NewCSVSource(r.Body), nil
+	case "application/x-ndjson", "application/jsonl":
+		return importer.NewNDJSONSource(r.Body), nil
+	default:
+		return nil, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia,
+			fmt.Sprintf("content type %q is not supported, use text/csv or application/x-ndjson", mediaType))
+	}
+}
+
+// Import endpoint creates the aliases of a CSV or NDJSON body. The results of the rows are streamed back as NDJSON
+// while the body is being read, followed by the summary line or, if the import is aborted, by the error line.
+func (ac *Controller) Import(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var options domain.ImportOptions
+	var failuresOnly bool
+	for name, value := range map[string]*bool{"dryRun": &options.DryRun, "failuresOnly": &failuresOnly} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "invalid query parameter",
+					apierror.FieldViolation{Field: name, Description: "must be a boolean"}))
+				return
+			}
+			*value = parsed
+		}
+	}
+
+	source, err := importSource(r)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	// the results are written while the body is still being read, HTTP/2 is always full duplex
+	controller := http.NewResponseController(w)
+	_ = controller.EnableFullDuplex()
+	extendDeadlines(controller)
+
+	encoder := json.NewEncoder(w)
+	started := false
+	report := func(results []domain.ImportResult) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		for _, result := range results {
+			if failuresOnly && result.Status != domain.ImportFailed {
+				continue
+			}
+			if err := encoder.Encode(newImportResultPayload(result)); err != nil {
+				return err
+			}
+		}
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		extendDeadlines(controller)
+		return nil
+	}
+
+	summary, err := ac.service.Import(r.Context(), source, options, report)
+	if err != nil {
+		if !started {
+			apierror.WriteHTTP(w, r, err)
+			return
+		}
+		apiErr := *apierror.FromError(err)
+		apiErr.RequestID = logging.RequestID(r.Context())
+		if err := encoder.Encode(errorLine{Error: &apiErr}); err != nil {
+			logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+		}
+		return
+	}
+
+	if !started {
+		// empty body, no batch has been reported
+		if err := report(nil); err != nil {
+			return
+		}
+	}
+	if err := encoder.Encode(importSummaryLine{Summary: importSummaryPayload{
+		Rows:     summary.Rows,
+		Imported: summary.Imported,
+		Failed:   summary.Failed,
+		DryRun:   summary.DryRun,
+	}}); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+	}
+}
+
+// extendDeadlines gives the server another batchTimeout to read and answer the next batch,
+// the deadlines are not supported by every response writer
+func extendDeadlines(controller *http.ResponseController) {
+	deadline := time.Now().Add(batchTimeout)
+	_ = controller.SetReadDeadline(deadline)
+	_ = controller.SetWriteDeadline(deadline)
+}
+
+func newImportResultPayload(result domain.ImportResult) importResultPayload {
+	payload := importResultPayload{Line: result.Line, Key: result.Key, Status: string(result.Status)}
+	if result.Err != nil {
+		apiErr := apierror.FromError(result.Err)
+		payload.Code = apiErr.Code
+		payload.Message = apiErr.Message
+	}
+	return payload
+}
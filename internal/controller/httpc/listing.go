@@ -0,0 +1,167 @@
+package httpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type aliasPayload struct {
+	Key          string     `json:"key"`
+	URL          string     `json:"url"`
+	Target       string     `json:"target"`
+	Status       string     `json:"status"`
+	IsPermanent  bool       `json:"isPermanent"`
+	TriesLeft    int        `json:"triesLeft"`
+	RedirectType int        `json:"redirectType"`
+	Campaign     string     `json:"campaign,omitempty"`
+	Protected    bool       `json:"protected"`
+	Interstitial bool       `json:"interstitial"`
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+	ActivatesAt  *time.Time `json:"activatesAt,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	ExpiredAt    *time.Time `json:"expiredAt,omitempty"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+}
+
+type aliasList struct {
+	Aliases    []aliasPayload `json:"aliases"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+type activationPayload struct {
+	Key         string     `json:"key,omitempty"`
+	ActivatesAt *time.Time `json:"activatesAt"`
+}
+
+func (ac *Controller) newAliasPayload(alias domain.Alias, now time.Time) aliasPayload {
+	alias = alias.Redacted()
+	payload := aliasPayload{
+		Key:          alias.Key,
+		URL:          fmt.Sprintf("%s/%s", ac.address, alias.Key),
+		Target:       alias.URL.String(),
+		Status:       string(alias.Status(now)),
+		IsPermanent:  alias.Params.IsPermanent,
+		TriesLeft:    alias.Params.TriesLeft,
+		RedirectType: int(alias.Redirect()),
+		Campaign:     alias.Campaign,
+		Protected:    alias.IsProtected(),
+		Interstitial: alias.Interstitial,
+	}
+	if !alias.CreatedAt.IsZero() {
+		payload.CreatedAt = &alias.CreatedAt
+	}
+	if !alias.ActivatesAt.IsZero() {
+		payload.ActivatesAt = &alias.ActivatesAt
+	}
+	if !alias.ExpiresAt.IsZero() {
+		payload.ExpiresAt = &alias.ExpiresAt
+	}
+	if !alias.ExpiredAt.IsZero() {
+		payload.ExpiredAt = &alias.ExpiredAt
+	}
+	if !alias.DeletedAt.IsZero() {
+		payload.DeletedAt = &alias.DeletedAt
+	}
+	return payload
+}
+
+// Aliases endpoint lists (GET) or creates (POST) the aliases
+func (ac *Controller) Aliases(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ac.ListAliases(w, r)
+	default:
+		ac.CreateAlias(w, r)
+	}
+}
+
+// ListAliases returns a page of the aliases ordered by key, filtered by status and campaign
+func (ac *Controller) ListAliases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	status, err := domain.ParseAliasStatus(query.Get("status"))
+	if err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid query parameter",
+			apierror.FieldViolation{Field: "status", Description: "must be one of active, scheduled, disabled, expired, deleted"}))
+		return
+	}
+	filter := domain.ListFilter{Status: status, Campaign: query.Get("campaign"), After: query.Get("cursor")}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > domain.MaxListLimit {
+			apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidFilter, "invalid query parameter",
+				apierror.FieldViolation{Field: "limit", Description: fmt.Sprintf("must be an integer between 1 and %d", domain.MaxListLimit)}))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	page, err := ac.service.List(r.Context(), filter)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	now := time.Now()
+	response := aliasList{Aliases: make([]aliasPayload, len(page.Aliases)), NextCursor: page.Next}
+	for index, alias := range page.Aliases {
+		response.Aliases[index] = ac.newAliasPayload(alias, now)
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// Info endpoint returns (GET) the alias unless it is in the trash
+func (ac *Controller) Info(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	alias, err := ac.service.FindOriginalURL(r.Context(), r.PathValue("key"))
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, ac.newAliasPayload(*alias, time.Now()))
+}
+
+// Activation endpoint sets (PUT) the time the alias starts redirecting at, null activates it immediately
+func (ac *Controller) Activation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	key := r.PathValue("key")
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	payload := &activationPayload{}
+	if err := json.Unmarshal(content, payload); err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidJSON, "request body is not a valid JSON"))
+		return
+	}
+	var activatesAt time.Time
+	if payload.ActivatesAt != nil {
+		activatesAt = payload.ActivatesAt.UTC()
+	}
+
+	if err := ac.service.SetActivation(r.Context(), key, activatesAt); err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	response := activationPayload{Key: key}
+	if !activatesAt.IsZero() {
+		response.ActivatesAt = &activatesAt
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
@@ -2,6 +2,14 @@ package mw
 
 import (
 	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"net/http"
 	"runtime/debug"
@@ -11,9 +19,23 @@ import (
 
 type Middleware func(next http.HandlerFunc) http.HandlerFunc
 
-const maxIncomingRequests = 100
+// DefaultMaxIncomingRequests is the limit of the requests processed at once until SetMaxIncomingRequests changes it
+const DefaultMaxIncomingRequests = 100
 
-var totalRequestsInProcessing atomic.Int32
+var (
+	maxIncomingRequests       atomic.Int32
+	totalRequestsInProcessing atomic.Int32
+)
+
+func init() {
+	maxIncomingRequests.Store(DefaultMaxIncomingRequests)
+}
+
+// SetMaxIncomingRequests changes the limit of the requests processed at once by RequestThrottler, the requests
+// in process are not affected
+func SetMaxIncomingRequests(limit int) {
+	maxIncomingRequests.Store(int32(limit))
+}
 
 func Use(handlerFunc http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
 	handlerFn := handlerFunc
@@ -26,8 +48,9 @@ func Use(handlerFunc http.HandlerFunc, middlewares ...Middleware) http.HandlerFu
 func Logging(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		logger := logging.FromContext(r.Context())
 
-		zap.S().Infow("HTTP",
+		logger.Infow("HTTP",
 			zap.String("request", r.Method),
 			zap.String("status", "received"),
 			zap.String("uri", r.RequestURI))
@@ -41,7 +64,7 @@ func Logging(next http.HandlerFunc) http.HandlerFunc {
 			durationString = fmt.Sprintf("%dμs", duration.Microseconds())
 		}
 
-		zap.S().Infow("HTTP",
+		logger.Infow("HTTP",
 			zap.String("request", r.Method),
 			zap.String("status", "processed"),
 			zap.String("duration", durationString))
@@ -52,8 +75,12 @@ func PanicRecovery(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				zap.S().Errorw("panic recovered! ", zap.ByteString("stack", debug.Stack()))
+				if err == http.ErrAbortHandler {
+					// the handler breaks the connection on purpose, e.g. to tell the client a stream is incomplete
+					panic(err)
+				}
+				apierror.WriteHTTP(w, req, apierror.ErrInternal)
+				logging.FromContext(req.Context()).Errorw("panic recovered! ", zap.ByteString("stack", debug.Stack()))
 			}
 		}()
 		next(w, req)
@@ -64,10 +91,62 @@ func RequestThrottler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		totalRequestsInProcessing.Add(1)
 		defer totalRequestsInProcessing.Add(-1)
-		if totalRequestsInProcessing.Load() >= maxIncomingRequests {
-			zap.S().Error(zap.String("error", "too many requests"))
-			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		if totalRequestsInProcessing.Load() > maxIncomingRequests.Load() {
+			logging.FromContext(r.Context()).Error(zap.String("error", "too many requests"))
+			apierror.WriteHTTP(w, r, apierror.ErrTooManyRequests)
+			return
 		}
 		next(w, r)
 	}
 }
+
+// RequestID assigns a request ID to every request, honoring the one sent by the caller,
+// and echoes it in the response
+func RequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := logging.EnsureRequestID(r.Header.Get(logging.HeaderRequestID))
+		// keep the header in the request, so it is forwarded to the gRPC-server by the gateway
+		r.Header.Set(logging.HeaderRequestID, requestID)
+		w.Header().Set(logging.HeaderRequestID, requestID)
+		next(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	}
+}
+
+// statusRecorder keeps the status code written by the wrapped handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the flushing and deadlines of the wrapped writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Tracing starts a server span for every request, continuing the trace of the caller if any
+func Tracing(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracing.Tracer().Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package mw
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewTracerProvider(exporter, "alias-test", 1)
+	defer provider.Shutdown(context.Background())
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var handlerSpan trace.SpanContext
+	handler := Use(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}, Tracing)
+
+	request := httptest.NewRequest(http.MethodGet, "/key", nil)
+	request.Header.Set("traceparent", traceparent)
+	handler(httptest.NewRecorder(), request)
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "HTTP GET", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+	assert.Equal(t, "Error", span.Status.Code.String())
+}
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "incoming request id is honored", incoming: "req-42", keep: true},
+		{name: "missing request id is generated", incoming: ""},
+		{name: "invalid request id is replaced", incoming: "bad id\nforged log line"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var got string
+			handler := Use(func(w http.ResponseWriter, r *http.Request) {
+				got = logging.RequestID(r.Context())
+			}, RequestID)
+
+			request := httptest.NewRequest(http.MethodGet, "/key", nil)
+			if testCase.incoming != "" {
+				request.Header.Set(logging.HeaderRequestID, testCase.incoming)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+
+			require.NotEmpty(t, got)
+			assert.Equal(t, got, recorder.Header().Get(logging.HeaderRequestID))
+			if testCase.keep {
+				assert.Equal(t, testCase.incoming, got)
+			} else {
+				assert.NotEqual(t, testCase.incoming, got)
+			}
+		})
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	t.Parallel()
+	t.Run("panic is answered with internal error", func(t *testing.T) {
+		t.Parallel()
+		handler := Use(func(w http.ResponseWriter, r *http.Request) { panic("boom") }, PanicRecovery)
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodGet, "/key", nil))
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+
+	t.Run("panic answer carries the request id", func(t *testing.T) {
+		t.Parallel()
+		handler := Use(func(w http.ResponseWriter, r *http.Request) { panic("boom") }, PanicRecovery, RequestID)
+		request := httptest.NewRequest(http.MethodGet, "/key", nil)
+		request.Header.Set(logging.HeaderRequestID, "req-42")
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, "req-42", recorder.Header().Get(logging.HeaderRequestID))
+		assert.Contains(t, recorder.Body.String(), `"request_id":"req-42"`)
+	})
+
+	t.Run("aborted handler is not recovered", func(t *testing.T) {
+		t.Parallel()
+		handler := Use(func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) }, PanicRecovery)
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/key", nil))
+		})
+	})
+}
+
+func TestRequestThrottler(t *testing.T) {
+	SetMaxIncomingRequests(1)
+	defer SetMaxIncomingRequests(DefaultMaxIncomingRequests)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := Use(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	}, RequestThrottler)
+
+	processed := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodGet, "/key", nil))
+		processed <- recorder.Code
+	}()
+	<-entered
+
+	rejected := httptest.NewRecorder()
+	handler(rejected, httptest.NewRequest(http.MethodGet, "/key", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rejected.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-processed)
+}
@@ -0,0 +1,135 @@
+// Package pages renders the HTML pages shown to the visitors of the aliases.
+// Every page has an embedded default, which can be replaced by a file of the same name in the configured directory.
+package pages
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name identifies a page, the template of the page is read from the "<name>.html" file
+type Name string
+
+const (
+	NotFound Name = "not_found"
+	Expired  Name = "expired"
+	Disabled Name = "disabled"
+	Blocked  Name = "blocked"
+	// ComingSoon is shown for the scheduled aliases, if the service is configured to reveal them
+	ComingSoon Name = "coming_soon"
+	Password   Name = "password"
+	Preview    Name = "preview"
+)
+
+// Names lists all the pages rendered by the service
+var Names = []Name{NotFound, Expired, Disabled, Blocked, ComingSoon, Password, Preview}
+
+//go:embed templates/*.html
+var defaults embed.FS
+
+// Error is the data of the pages reporting why the visitor cannot be redirected
+type Error struct {
+	Key       string
+	Status    int
+	Code      string
+	Message   string
+	RequestID string
+	// ActivatesAt is the time the scheduled alias starts working at, zero for the other errors
+	ActivatesAt time.Time
+}
+
+// Renderer renders the pages from the parsed templates
+type Renderer struct {
+	templates map[Name]*template.Template
+}
+
+// NewRenderer parses the embedded pages, overriding them with the templates found in dir.
+// Empty dir means the embedded pages only. A missing file keeps the embedded page, a broken one is an error.
+func NewRenderer(dir string) (*Renderer, error) {
+	renderer := &Renderer{templates: make(map[Name]*template.Template, len(Names))}
+	for _, name := range Names {
+		file := string(name) + ".html"
+		content, err := defaults.ReadFile("templates/" + file)
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", name, err)
+		}
+		if dir != "" {
+			override, err := os.ReadFile(filepath.Join(dir, file))
+			switch {
+			case err == nil:
+				content = override
+			case !errors.Is(err, fs.ErrNotExist):
+				return nil, fmt.Errorf("page %s: %w", name, err)
+			}
+		}
+		tmpl, err := template.New(file).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", name, err)
+		}
+		renderer.templates[name] = tmpl
+	}
+	return renderer, nil
+}
+
+// MustDefault returns the renderer of the embedded pages
+func MustDefault() *Renderer {
+	renderer, err := NewRenderer("")
+	if err != nil {
+		panic(err)
+	}
+	return renderer
+}
+
+// Render writes the page with the status. The pages are never cached and never framed by other sites.
+func (p *Renderer) Render(w http.ResponseWriter, r *http.Request, name Name, status int, data any) {
+	tmpl, ok := p.templates[name]
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache, no-store, must-revalidate")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.String("page", string(name)), zap.Error(err))
+	}
+}
+
+// WantsHTML reports whether the client prefers an HTML page to a JSON document.
+// Only an explicitly accepted text/html counts, so API clients sending "*/*" or no Accept header get JSON.
+func WantsHTML(r *http.Request) bool {
+	var htmlQ, jsonQ float64
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			htmlQ = max(htmlQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return htmlQ > 0 && htmlQ >= jsonQ
+}
@@ -0,0 +1,79 @@
+package pages
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWantsHTML(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		accept string
+		wants  bool
+	}{
+		{name: "no accept header", accept: "", wants: false},
+		{name: "any type", accept: "*/*", wants: false},
+		{name: "json", accept: "application/json", wants: false},
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", wants: true},
+		{name: "html preferred", accept: "application/json;q=0.5, text/html", wants: true},
+		{name: "json preferred", accept: "text/html;q=0.5, application/json", wants: false},
+		{name: "html refused", accept: "text/html;q=0", wants: false},
+		{name: "malformed quality", accept: "text/html;q=abc", wants: false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest(http.MethodGet, "/key", nil)
+			if testCase.accept != "" {
+				r.Header.Set("Accept", testCase.accept)
+			}
+			assert.Equal(t, testCase.wants, WantsHTML(r))
+		})
+	}
+}
+
+func TestNewRenderer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("embedded pages", func(t *testing.T) {
+		t.Parallel()
+		renderer, err := NewRenderer("")
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		renderer.Render(w, httptest.NewRequest(http.MethodGet, "/key", nil), Expired, http.StatusGone, Error{Key: "key"})
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<code>/key</code>")
+	})
+
+	t.Run("overridden page", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "not_found.html"), []byte(`custom {{.Key}}`), 0o600))
+		renderer, err := NewRenderer(dir)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		renderer.Render(w, httptest.NewRequest(http.MethodGet, "/key", nil), NotFound, http.StatusNotFound, Error{Key: "<key>"})
+		assert.Equal(t, "custom &lt;key&gt;", w.Body.String())
+
+		w = httptest.NewRecorder()
+		renderer.Render(w, httptest.NewRequest(http.MethodGet, "/key", nil), Disabled, http.StatusForbidden, Error{Key: "key"})
+		assert.Contains(t, w.Body.String(), "Link disabled")
+	})
+
+	t.Run("broken page", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "blocked.html"), []byte(`{{.Key`), 0o600))
+		_, err := NewRenderer(dir)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,102 @@
+package httpc
+
+import (
+	"errors"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/controller/httpc/pages"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPasswordMaxAttempts = 5
+	defaultPasswordWindow      = 15 * time.Minute
+	// maxPasswordFormSize limits the body of the password form
+	maxPasswordFormSize = 4 << 10
+)
+
+type passwordForm struct {
+	Action string
+	Error  string
+}
+
+// writePasswordForm renders the form asking for the password of a protected alias
+func (ac *Controller) writePasswordForm(w http.ResponseWriter, r *http.Request, status int, message string) {
+	// the form is posted back to the visited link, keeping the path and query passed through to the target
+	ac.Pages().Render(w, r, pages.Password, status, passwordForm{Action: r.URL.RequestURI(), Error: message})
+}
+
+// unlock verifies the password posted for a protected alias, reporting whether the visitor may be redirected.
+// Failed attempts are limited per client IP and alias.
+func (ac *Controller) unlock(w http.ResponseWriter, r *http.Request, alias *domain.Alias) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasswordFormSize)
+	if err := r.ParseForm(); err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidArgument, "request body is not a valid form"))
+		return false
+	}
+
+	// the attempt is counted before the password is checked, the concurrent guesses are limited as well
+	client := ac.clientIP(r) + "/" + alias.Key
+	if allowed, retryAfter := ac.attempts.Allow(client); !allowed {
+		logging.FromContext(r.Context()).Warnw("HTTP",
+			zap.String("key", alias.Key),
+			zap.String("client", client),
+			zap.Error(domain.ErrTooManyAttempts))
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second).Seconds())))
+		ac.writePasswordForm(w, r, http.StatusTooManyRequests, "Too many attempts, try again later.")
+		return false
+	}
+
+	if err := ac.service.CheckPassword(r.Context(), alias, r.PostForm.Get("password")); err != nil {
+		if errors.Is(err, domain.ErrWrongPassword) {
+			ac.writePasswordForm(w, r, http.StatusForbidden, "Wrong password.")
+			return false
+		}
+		ac.attempts.Refund(client)
+		apierror.WriteHTTP(w, r, err)
+		return false
+	}
+	ac.attempts.Reset(client)
+	return true
+}
+
+// clientIP returns the IP of the visitor, taken from the configured proxy header if present
+func (ac *Controller) clientIP(r *http.Request) string {
+	if header := ac.ClientIPHeader(); header != "" {
+		if value := r.Header.Get(header); value != "" {
+			// the proxy appends the address it received the request from to the list
+			first, _, _ := strings.Cut(value, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// SetPasswordAttemptLimit sets how many wrong passwords a client may enter for an alias within the window
+func (ac *Controller) SetPasswordAttemptLimit(maxAttempts int, window time.Duration) {
+	ac.attempts.SetLimit(maxAttempts, window)
+}
+
+// SetClientIPHeader sets the header the proxy in front of the service passes the IP of the visitor in,
+// empty header means the remote address of the connection
+func (ac *Controller) SetClientIPHeader(header string) {
+	if header != "" {
+		header = http.CanonicalHeaderKey(header)
+	}
+	ac.clientIPHeader.Store(header)
+}
+
+// ClientIPHeader returns the header the proxy in front of the service passes the IP of the visitor in
+func (ac *Controller) ClientIPHeader() string {
+	return ac.clientIPHeader.Load().(string)
+}
@@ -0,0 +1,111 @@
+package httpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestController_Redirect_ConcurrentPasswordGuesses(t *testing.T) {
+	t.Parallel()
+	const guesses = 20
+	target, err := url.Parse("https://example.com")
+	require.NoError(t, err)
+	server := newRedirectTestServer(t, domain.CreateRequest{
+		Key:      "locked",
+		Params:   domain.TTLParams{IsPermanent: true},
+		URL:      target,
+		Password: "secret",
+	})
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	statuses := make(chan int, guesses)
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			response, err := client.Post(server.URL+"/locked", "application/x-www-form-urlencoded",
+				strings.NewReader("password=wrong"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			_ = response.Body.Close()
+			statuses <- response.StatusCode
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+	// the guesses racing the slow password check are limited as well as the sequential ones
+	assert.Equal(t, map[int]int{
+		http.StatusForbidden:       defaultPasswordMaxAttempts,
+		http.StatusTooManyRequests: guesses - defaultPasswordMaxAttempts,
+	}, counts)
+
+	// the right password is refused too until the window is over
+	response, err := client.Post(server.URL+"/locked", "application/x-www-form-urlencoded",
+		strings.NewReader("password=secret"))
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+}
+
+func TestController_ProtectedDestinationsAreHidden(t *testing.T) {
+	t.Parallel()
+	secret, err := url.Parse("https://example.com/secret")
+	require.NoError(t, err)
+	ctrl := newTestController(t, domain.CreateRequest{
+		Key:      "locked",
+		Params:   domain.TTLParams{IsPermanent: true},
+		URL:      secret,
+		Password: "secret",
+	})
+	ctx := context.Background()
+	require.NoError(t, ctrl.service.SetRules(ctx, "locked", []domain.RedirectRule{
+		{Name: "ios", Platforms: []domain.Platform{domain.PlatformIOS}, URL: secret},
+	}))
+	require.NoError(t, ctrl.service.SetVariants(ctx, "locked", []domain.Variant{
+		{Name: "a", URL: secret, Weight: 1}, {Name: "b", URL: secret, Weight: 1},
+	}))
+
+	testCases := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+		expect  string
+	}{
+		{name: "info", handler: ctrl.Info, target: "/api/v1/alias/locked/info", expect: `"protected":true`},
+		{name: "listing", handler: ctrl.ListAliases, target: "/api/v1/alias", expect: `"key":"locked"`},
+		{name: "rules", handler: ctrl.Rules, target: "/api/v1/alias/locked/rules", expect: `"name":"ios"`},
+		{name: "variants", handler: ctrl.Variants, target: "/api/v1/alias/locked/variants", expect: `"name":"a"`},
+		{name: "export", handler: ctrl.Export, target: "/api/v1/alias/export", expect: `"key":"locked"`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			request := httptest.NewRequest(http.MethodGet, testCase.target, nil)
+			request.SetPathValue("key", "locked")
+			recorder := httptest.NewRecorder()
+			testCase.handler(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			assert.Contains(t, recorder.Body.String(), testCase.expect)
+			assert.NotContains(t, recorder.Body.String(), "example.com")
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package httpc
+
+import (
+	"github.com/xloki21/alias/internal/controller/httpc/pages"
+	"github.com/xloki21/alias/internal/domain"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// previewQueryParam is reserved by the service and never passed through to the destination
+const previewQueryParam = "preview"
+
+type previewDestination struct {
+	URL    string
+	Domain string
+}
+
+type previewData struct {
+	Type         string
+	IsPermanent  bool
+	TriesLeft    int
+	CreatedAt    string
+	Protected    bool
+	Destinations []previewDestination
+	Continue     string
+}
+
+// previewMode reads and removes the preview parameter from the query of the visited link.
+// Returns whether the preview is requested and whether the parameter is set at all.
+func previewMode(query url.Values) (bool, bool) {
+	value, ok := query[previewQueryParam]
+	if !ok {
+		return false, false
+	}
+	query.Del(previewQueryParam)
+	if len(value) == 0 {
+		return false, false
+	}
+	preview, err := strconv.ParseBool(value[0])
+	if err != nil {
+		return false, false
+	}
+	return preview, true
+}
+
+// preview renders the page describing where the alias leads without using it.
+// query is the query of the visited link without the preview parameter.
+func (ac *Controller) preview(w http.ResponseWriter, r *http.Request, alias *domain.Alias, visitor domain.Visitor, query url.Values) {
+	path := extraPath(r)
+	preview, err := ac.service.Preview(r.Context(), alias, visitor, path, query)
+	if err != nil {
+		ac.writeVisitError(w, r, alias.Key, alias, err)
+		return
+	}
+
+	data := previewData{
+		Type:        preview.Type,
+		IsPermanent: preview.IsPermanent,
+		TriesLeft:   preview.TriesLeft,
+		Protected:   preview.Protected,
+	}
+	if !preview.CreatedAt.IsZero() {
+		data.CreatedAt = preview.CreatedAt.UTC().Format(time.DateOnly)
+	}
+	for _, destination := range preview.Destinations {
+		data.Destinations = append(data.Destinations, previewDestination{URL: destination.String(), Domain: destination.Hostname()})
+	}
+
+	// the visited link confirming the redirect
+	continueURL := url.URL{Path: "/" + alias.Key}
+	if path != "" {
+		continueURL.RawPath = "/" + alias.Key + "/" + path
+		if unescaped, err := url.PathUnescape(continueURL.RawPath); err == nil {
+			continueURL.Path = unescaped
+		}
+	}
+	continueQuery := url.Values{}
+	for name, values := range query {
+		continueQuery[name] = values
+	}
+	continueQuery.Set(previewQueryParam, "0")
+	continueURL.RawQuery = continueQuery.Encode()
+	data.Continue = continueURL.String()
+
+	ac.Pages().Render(w, r, pages.Preview, http.StatusOK, data)
+}
@@ -0,0 +1,114 @@
+package httpc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/pkg/qrcode"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// qrCodeParams names the query parameters of the QR code options
+type qrCodeParams struct {
+	format, size, margin, level, foreground, background string
+}
+
+var (
+	// qrCodeQuery are the parameters of the QR code endpoint
+	qrCodeQuery = qrCodeParams{
+		format: "format", size: "size", margin: "margin", level: "level", foreground: "foreground", background: "background",
+	}
+	// createQRCodeQuery are the parameters of the alias creation, the QR codes are returned if "qr" is set
+	createQRCodeQuery = qrCodeParams{
+		format: "qr", size: "qrSize", margin: "qrMargin", level: "qrLevel", foreground: "qrForeground", background: "qrBackground",
+	}
+)
+
+// parse reads the QR code options from the query, missing parameters keep the defaults
+func (p qrCodeParams) parse(query url.Values) (qrcode.Options, []apierror.FieldViolation) {
+	options := qrcode.DefaultOptions()
+	var violations []apierror.FieldViolation
+	violation := func(field string, err error) {
+		violations = append(violations, apierror.FieldViolation{Field: field, Description: err.Error()})
+	}
+
+	var err error
+	if value := query.Get(p.format); value != "" {
+		if options.Format, err = qrcode.ParseFormat(value); err != nil {
+			violation(p.format, err)
+		}
+	}
+	if value := query.Get(p.size); value != "" {
+		if options.Size, err = strconv.Atoi(value); err != nil || options.Size < qrcode.MinSize || options.Size > qrcode.MaxSize {
+			violation(p.size, qrcode.ErrInvalidSize)
+		}
+	}
+	if value := query.Get(p.margin); value != "" {
+		if options.Margin, err = strconv.Atoi(value); err != nil || options.Margin < 0 || options.Margin > qrcode.MaxMargin {
+			violation(p.margin, qrcode.ErrInvalidMargin)
+		}
+	}
+	if value := query.Get(p.level); value != "" {
+		if options.Level, err = qrcode.ParseLevel(value); err != nil {
+			violation(p.level, err)
+		}
+	}
+	if value := query.Get(p.foreground); value != "" {
+		if options.Foreground, err = qrcode.ParseColor(value); err != nil {
+			violation(p.foreground, err)
+		}
+	}
+	if value := query.Get(p.background); value != "" {
+		if options.Background, err = qrcode.ParseColor(value); err != nil {
+			violation(p.background, err)
+		}
+	}
+	return options, violations
+}
+
+// QRCode endpoint returns the QR code image of the short link
+func (ac *Controller) QRCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	options, violations := qrCodeQuery.parse(r.URL.Query())
+	if len(violations) > 0 {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidQRCode, "invalid query parameter", violations...))
+		return
+	}
+
+	alias, err := ac.service.FindOriginalURL(r.Context(), r.PathValue("key"))
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	image, err := qrcode.Encode(fmt.Sprintf("%s/%s", ac.address, alias.Key), options)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", options.Format.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	// the short link of the key never changes
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(image); err != nil {
+		logging.FromContext(r.Context()).Errorw("HTTP", zap.Error(err))
+	}
+}
+
+// qrCodeDataURI renders the QR code of the short link as a data URI to embed into the JSON responses
+func qrCodeDataURI(shortURL string, options qrcode.Options) (string, error) {
+	image, err := qrcode.Encode(shortURL, options)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data:%s;base64,%s", options.Format.ContentType(), base64.StdEncoding.EncodeToString(image)), nil
+}
@@ -0,0 +1,108 @@
+package httpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/pkg/urlparser"
+	"io"
+	"net/http"
+)
+
+type rulePayload struct {
+	Name      string   `json:"name"`
+	Platforms []string `json:"platforms,omitempty"`
+	Languages []string `json:"languages,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+	URL       string   `json:"url"`
+}
+
+type ruleList struct {
+	Key   string        `json:"key,omitempty"`
+	Rules []rulePayload `json:"rules"`
+}
+
+func newRuleList(key string, rules []domain.RedirectRule) ruleList {
+	response := ruleList{Key: key, Rules: make([]rulePayload, len(rules))}
+	for index, rule := range rules {
+		platforms := make([]string, len(rule.Platforms))
+		for i, platform := range rule.Platforms {
+			platforms[i] = string(platform)
+		}
+		response.Rules[index] = rulePayload{
+			Name:      rule.Name,
+			Platforms: platforms,
+			Languages: rule.Languages,
+			Countries: rule.Countries,
+			URL:       rule.URL.String(),
+		}
+	}
+	return response
+}
+
+// Rules endpoint returns (GET) or replaces (PUT) the ordered redirect rules of the alias
+func (ac *Controller) Rules(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := ac.service.Rules(r.Context(), key)
+		if err != nil {
+			apierror.WriteHTTP(w, r, err)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, newRuleList(key, rules))
+	case http.MethodPut:
+		ac.setRules(w, r, key)
+	default:
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+	}
+}
+
+func (ac *Controller) setRules(w http.ResponseWriter, r *http.Request, key string) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	payload := &ruleList{}
+	if err := json.Unmarshal(content, payload); err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidJSON, "request body is not a valid JSON"))
+		return
+	}
+
+	rules := make([]domain.RedirectRule, len(payload.Rules))
+	var violations []apierror.FieldViolation
+	for index, rule := range payload.Rules {
+		validURL, err := urlparser.Validate(rule.URL)
+		if err != nil {
+			violations = append(violations, apierror.FieldViolation{
+				Field:       fmt.Sprintf("rules[%d].url", index),
+				Description: err.Error(),
+			})
+			continue
+		}
+		platforms := make([]domain.Platform, len(rule.Platforms))
+		for i, platform := range rule.Platforms {
+			platforms[i] = domain.Platform(platform)
+		}
+		rules[index] = domain.RedirectRule{
+			Name:      rule.Name,
+			Platforms: platforms,
+			Languages: rule.Languages,
+			Countries: rule.Countries,
+			URL:       validURL,
+		}
+	}
+	if len(violations) > 0 {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidURL, "request contains invalid urls", violations...))
+		return
+	}
+
+	if err := ac.service.SetRules(r.Context(), key, rules); err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, newRuleList(key, rules))
+}
@@ -0,0 +1,121 @@
+package httpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/pkg/urlparser"
+	"io"
+	"net/http"
+	"time"
+)
+
+type variantPayload struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+type variantList struct {
+	Key      string           `json:"key,omitempty"`
+	Variants []variantPayload `json:"variants"`
+}
+
+func newVariantList(key string, variants []domain.Variant) variantList {
+	response := variantList{Key: key, Variants: make([]variantPayload, len(variants))}
+	for index, variant := range variants {
+		response.Variants[index] = variantPayload{Name: variant.Name, URL: variant.URL.String(), Weight: variant.Weight}
+	}
+	return response
+}
+
+type clickStatsPayload struct {
+	Key         string         `json:"key"`
+	Clicks      int            `json:"clicks"`
+	Rules       map[string]int `json:"rules"`
+	Variants    map[string]int `json:"variants"`
+	LastClickAt *time.Time     `json:"lastClickAt,omitempty"`
+}
+
+// Variants endpoint returns (GET) or replaces (PUT) the A/B variants of the alias
+func (ac *Controller) Variants(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	switch r.Method {
+	case http.MethodGet:
+		variants, err := ac.service.Variants(r.Context(), key)
+		if err != nil {
+			apierror.WriteHTTP(w, r, err)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, newVariantList(key, variants))
+	case http.MethodPut:
+		ac.setVariants(w, r, key)
+	default:
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+	}
+}
+
+func (ac *Controller) setVariants(w http.ResponseWriter, r *http.Request, key string) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	payload := &variantList{}
+	if err := json.Unmarshal(content, payload); err != nil {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidJSON, "request body is not a valid JSON"))
+		return
+	}
+
+	variants := make([]domain.Variant, len(payload.Variants))
+	var violations []apierror.FieldViolation
+	for index, variant := range payload.Variants {
+		validURL, err := urlparser.Validate(variant.URL)
+		if err != nil {
+			violations = append(violations, apierror.FieldViolation{
+				Field:       fmt.Sprintf("variants[%d].url", index),
+				Description: err.Error(),
+			})
+			continue
+		}
+		variants[index] = domain.Variant{Name: variant.Name, URL: validURL, Weight: variant.Weight}
+	}
+	if len(violations) > 0 {
+		apierror.WriteHTTP(w, r, apierror.InvalidArgument(apierror.CodeInvalidURL, "request contains invalid urls", violations...))
+		return
+	}
+
+	if err := ac.service.SetVariants(r.Context(), key, variants); err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, newVariantList(key, variants))
+}
+
+// Stats endpoint returns the click counters of the alias, in total and per redirect rule and A/B variant
+func (ac *Controller) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.WriteHTTP(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+	key := r.PathValue("key")
+
+	// counters are kept after the alias is gone, but only the existing aliases are reported
+	if _, err := ac.service.FindOriginalURL(r.Context(), key); err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+	stats, err := ac.statistics.Clicks(r.Context(), key)
+	if err != nil {
+		apierror.WriteHTTP(w, r, err)
+		return
+	}
+
+	response := clickStatsPayload{Key: key, Clicks: stats.Total, Rules: stats.Rules, Variants: stats.Variants}
+	if !stats.LastClickAt.IsZero() {
+		response.LastClickAt = &stats.LastClickAt
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
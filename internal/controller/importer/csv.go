@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the columns known to the CSV source, the url one is required
+var csvColumns = map[string]struct{}{
+	"key": {}, "url": {}, "maxUsageCount": {}, "redirectType": {}, "campaign": {},
+}
+
+// CSVSource reads the rows of a CSV file starting with a header naming the columns
+type CSVSource struct {
+	reader *csv.Reader
+	// columns are the indexes of the columns by name
+	columns map[string]int
+}
+
+// NewCSVSource creates a new CSV source, the header is read on the first call of Next
+func NewCSVSource(r io.Reader) *CSVSource {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+	return &CSVSource{reader: reader}
+}
+
+// Next reads the next row, a malformed line is returned as a failed row
+func (s *CSVSource) Next() (domain.ImportRow, error) {
+	if s.columns == nil {
+		if err := s.readHeader(); err != nil {
+			return domain.ImportRow{}, err
+		}
+	}
+
+	fields, err := s.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return domain.ImportRow{
+				Line: parseErr.StartLine,
+				Err:  apierror.InvalidArgument(apierror.CodeInvalidImport, parseErr.Err.Error()),
+			}, nil
+		}
+		return domain.ImportRow{}, err
+	}
+	line, _ := s.reader.FieldPos(0)
+
+	record := Record{
+		Key:      s.field(fields, "key"),
+		URL:      s.field(fields, "url"),
+		Campaign: s.field(fields, "campaign"),
+	}
+	if value := s.field(fields, "maxUsageCount"); value != "" {
+		maxUsageCount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return domain.ImportRow{Line: line, Key: record.Key, Err: apierror.InvalidArgument(
+				apierror.CodeInvalidArgument, "maxUsageCount must be a non-negative integer")}, nil
+		}
+		record.MaxUsageCount = &maxUsageCount
+	}
+	if value := s.field(fields, "redirectType"); value != "" {
+		redirectType, err := domain.ParseRedirectType(value)
+		if err != nil {
+			return domain.ImportRow{Line: line, Key: record.Key, Err: err}, nil
+		}
+		record.RedirectType = int(redirectType)
+	}
+	return record.Row(line), nil
+}
+
+// readHeader reads the names of the columns
+func (s *CSVSource) readHeader() error {
+	header, err := s.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return apierror.InvalidArgument(apierror.CodeInvalidImport, "header is missing")
+	}
+	if err != nil {
+		return apierror.InvalidArgument(apierror.CodeInvalidImport, fmt.Sprintf("invalid header: %s", err.Error()))
+	}
+	columns := make(map[string]int, len(header))
+	for index, name := range header {
+		// spreadsheets may start the file with a byte order mark
+		name = strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")
+		if _, known := csvColumns[name]; !known {
+			return apierror.InvalidArgument(apierror.CodeInvalidImport, fmt.Sprintf("unknown column %q", name))
+		}
+		if _, duplicate := columns[name]; duplicate {
+			return apierror.InvalidArgument(apierror.CodeInvalidImport, fmt.Sprintf("duplicate column %q", name))
+		}
+		columns[name] = index
+	}
+	if _, ok := columns["url"]; !ok {
+		return apierror.InvalidArgument(apierror.CodeInvalidImport, "url column is missing")
+	}
+	s.columns = columns
+	return nil
+}
+
+// field returns the value of the named column, empty if there is no such column
+func (s *CSVSource) field(fields []string, name string) string {
+	index, ok := s.columns[name]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(fields[index])
+}
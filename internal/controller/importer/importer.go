@@ -0,0 +1,54 @@
+// Package importer reads the aliases imported through the HTTP and gRPC controllers
+package importer
+
+import (
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/pkg/urlparser"
+)
+
+// Record is an imported alias as supplied by the client
+type Record struct {
+	// Key is preserved as the key of the alias, empty to generate one
+	Key string `json:"key"`
+	URL string `json:"url"`
+	// MaxUsageCount limits the uses of the alias, the alias is permanent if not set
+	MaxUsageCount *int64 `json:"maxUsageCount"`
+	// RedirectType is one of 301, 302, 307, 308, zero for the default one
+	RedirectType int    `json:"redirectType"`
+	Campaign     string `json:"campaign"`
+}
+
+// Row checks the record and converts it to the import row of the given line,
+// the problems are kept in the row to be reported with its result
+func (r Record) Row(line int) domain.ImportRow {
+	row := domain.ImportRow{Line: line, Key: r.Key}
+
+	target, err := urlparser.Validate(r.URL)
+	if err != nil {
+		row.Err = apierror.InvalidArgument(apierror.CodeInvalidURL, err.Error())
+		return row
+	}
+	params := domain.TTLParams{IsPermanent: true}
+	if r.MaxUsageCount != nil {
+		if *r.MaxUsageCount < 0 {
+			row.Err = apierror.InvalidArgument(apierror.CodeInvalidArgument, "maxUsageCount must be a non-negative integer")
+			return row
+		}
+		params = domain.TTLParams{TriesLeft: int(*r.MaxUsageCount)}
+	}
+	redirectType := domain.RedirectType(r.RedirectType)
+	if redirectType != domain.RedirectDefault && !redirectType.IsValid() {
+		row.Err = fmt.Errorf("%w: %d", domain.ErrInvalidRedirectType, r.RedirectType)
+		return row
+	}
+
+	row.Request = domain.CreateRequest{
+		Params:       params,
+		URL:          target,
+		RedirectType: redirectType,
+		Campaign:     r.Campaign,
+	}
+	return row
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll reads the rows of the source until its end or failure
+func readAll(source domain.ImportSource) ([]domain.ImportRow, error) {
+	rows := make([]domain.ImportRow, 0)
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// wantRow is the expected outcome of reading a row
+type wantRow struct {
+	line         int
+	key          string
+	url          string
+	permanent    bool
+	triesLeft    int
+	redirectType domain.RedirectType
+	campaign     string
+	code         apierror.Code
+}
+
+func checkRows(t *testing.T, want []wantRow, got []domain.ImportRow) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for index, expected := range want {
+		row := got[index]
+		assert.Equal(t, expected.line, row.Line)
+		assert.Equal(t, expected.key, row.Key)
+		if expected.code != "" {
+			require.Error(t, row.Err)
+			assert.Equal(t, expected.code, apierror.FromError(row.Err).Code)
+			continue
+		}
+		require.NoError(t, row.Err)
+		assert.Equal(t, expected.url, row.Request.URL.String())
+		assert.Equal(t, domain.TTLParams{IsPermanent: expected.permanent, TriesLeft: expected.triesLeft}, row.Request.Params)
+		assert.Equal(t, expected.redirectType, row.Request.RedirectType)
+		assert.Equal(t, expected.campaign, row.Request.Campaign)
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		data    string
+		want    []wantRow
+		wantErr apierror.Code
+	}{
+		{
+			name: "columns are read by name",
+			data: "\ufeffurl,key, maxUsageCount,redirectType,campaign\n" +
+				"https://example.com,promo,,,\n" +
+				"https://example.org/a,,3,302,spring-sale\n",
+			want: []wantRow{
+				{line: 2, key: "promo", url: "https://example.com", permanent: true},
+				{line: 3, url: "https://example.org/a", triesLeft: 3, redirectType: domain.RedirectFound, campaign: "spring-sale"},
+			},
+		},
+		{
+			name: "malformed rows are reported",
+			data: "key,url,maxUsageCount,redirectType\n" +
+				"a,ftp://example.com,,\n" +
+				"b,https://example.com,-1,\n" +
+				"c,https://example.com,,303\n" +
+				"d,https://example.com\n" +
+				"e,https://example.com,,301\n",
+			want: []wantRow{
+				{line: 2, key: "a", code: apierror.CodeInvalidURL},
+				{line: 3, key: "b", code: apierror.CodeInvalidArgument},
+				{line: 4, key: "c", code: apierror.CodeInvalidRedirect},
+				{line: 5, code: apierror.CodeInvalidImport},
+				{line: 6, key: "e", url: "https://example.com", permanent: true, redirectType: domain.RedirectMovedPermanently},
+			},
+		},
+		{name: "header is required", data: "", wantErr: apierror.CodeInvalidImport},
+		{name: "url column is required", data: "key\npromo\n", wantErr: apierror.CodeInvalidImport},
+		{name: "unknown column is rejected", data: "url,password\nhttps://example.com,secret\n", wantErr: apierror.CodeInvalidImport},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			rows, err := readAll(NewCSVSource(strings.NewReader(testCase.data)))
+			if testCase.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, testCase.wantErr, apierror.FromError(err).Code)
+				return
+			}
+			require.NoError(t, err)
+			checkRows(t, testCase.want, rows)
+		})
+	}
+}
+
+func TestNDJSONSource(t *testing.T) {
+	t.Parallel()
+	t.Run("lines are read as records", func(t *testing.T) {
+		t.Parallel()
+		data := `{"key":"promo","url":"https://example.com"}` + "\n" +
+			"\n" +
+			`{"url":"https://example.org","maxUsageCount":0,"redirectType":307,"campaign":"spring-sale"}` + "\n" +
+			`{"url":` + "\n" +
+			`{"key":"bad","url":"example.com"}`
+		rows, err := readAll(NewNDJSONSource(strings.NewReader(data)))
+		require.NoError(t, err)
+		checkRows(t, []wantRow{
+			{line: 1, key: "promo", url: "https://example.com", permanent: true},
+			{line: 3, url: "https://example.org", redirectType: domain.RedirectTemporary, campaign: "spring-sale"},
+			{line: 4, code: apierror.CodeInvalidJSON},
+			{line: 5, key: "bad", code: apierror.CodeInvalidURL},
+		}, rows)
+	})
+
+	t.Run("too long line aborts the reading", func(t *testing.T) {
+		t.Parallel()
+		data := `{"url":"https://example.com/` + strings.Repeat("a", maxLineSize) + `"}`
+		_, err := readAll(NewNDJSONSource(strings.NewReader(data)))
+		require.Error(t, err)
+		assert.Equal(t, apierror.CodeInvalidImport, apierror.FromError(err).Code)
+	})
+}
@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/controller/apierror"
+	"github.com/xloki21/alias/internal/domain"
+	"io"
+)
+
+// maxLineSize limits a line of the NDJSON source
+const maxLineSize = 64 * 1024
+
+// NDJSONSource reads the rows of a JSON Lines file, every non-empty line is a Record
+type NDJSONSource struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONSource creates a new NDJSON source
+func NewNDJSONSource(r io.Reader) *NDJSONSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	return &NDJSONSource{scanner: scanner}
+}
+
+// Next reads the next row, a line which is not a valid JSON object is returned as a failed row
+func (s *NDJSONSource) Next() (domain.ImportRow, error) {
+	for s.scanner.Scan() {
+		s.line++
+		content := bytes.TrimSpace(s.scanner.Bytes())
+		if len(content) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(content, &record); err != nil {
+			return domain.ImportRow{
+				Line: s.line,
+				Err:  apierror.InvalidArgument(apierror.CodeInvalidJSON, "line is not a valid JSON object"),
+			}, nil
+		}
+		return record.Row(s.line), nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return domain.ImportRow{}, apierror.InvalidArgument(apierror.CodeInvalidImport,
+				fmt.Sprintf("line %d is longer than %d bytes", s.line+1, maxLineSize))
+		}
+		return domain.ImportRow{}, err
+	}
+	return domain.ImportRow{}, io.EOF
+}
@@ -12,17 +12,49 @@ type TTLParams struct {
 
 // Alias is a struct that represents an alias for an origin url.
 type Alias struct {
-	ID       string
-	Key      string
-	URL      *url.URL
-	IsActive bool
-	Params   TTLParams
+	ID           string
+	Key          string
+	URL          *url.URL
+	IsActive     bool // false for the aliases in the trash only, the expired ones keep it and are told by IsExpired
+	Params       TTLParams
+	RedirectType RedirectType
+	Passthrough  PassthroughPolicy
+	Campaign     string
+	// Rules are evaluated in order, URL is the fallback destination if none of them matches
+	Rules []RedirectRule
+	// Variants split the visitors not matched by the rules between weighted destinations, replacing URL
+	Variants []Variant
+	// PasswordHash is the salted hash of the password protecting the alias, empty if it is not protected
+	PasswordHash []byte `json:"-"`
+	// Interstitial makes every visit show the preview page before the visitor is redirected
+	Interstitial bool
+	// Disabled aliases are kept, but cannot be followed until enabled again
+	Disabled  bool
+	CreatedAt time.Time
+	// ActivatesAt is the time the alias starts redirecting at, zero means immediately
+	ActivatesAt time.Time
+	// DeletedAt is the time the alias was moved to the trash, IsActive is false for such aliases
+	DeletedAt time.Time
+	// ExpiresAt is the time the alias stops redirecting at, zero means never
+	ExpiresAt time.Time
+	// ExpiredAt is the time the reaper deactivated the expired alias, zero until then
+	ExpiredAt time.Time
 }
 
 // CreateRequest is a struct that represents an alias creation request.
 type CreateRequest struct {
-	Params TTLParams
-	URL    *url.URL
+	// Key is the key of the alias, generated if empty
+	Key          string
+	Params       TTLParams
+	URL          *url.URL
+	RedirectType RedirectType
+	Passthrough  PassthroughPolicy
+	Campaign     string
+	// Password protects the alias if not empty
+	Password     string
+	Interstitial bool
+	ActivatesAt  time.Time
+	ExpiresAt    time.Time
 }
 
 func (a Alias) Type() string {
@@ -33,6 +65,42 @@ func (a Alias) Type() string {
 	return "ttl-restricted"
 }
 
+// Redirect returns the redirect type the alias must be served with.
+// Usage-limited, expiring, A/B split, rule-based, protected and interstitial aliases are never redirected
+// with a permanently cacheable redirect, as every visit must reach the service.
+func (a Alias) Redirect() RedirectType {
+	mustReachService := !a.Params.IsPermanent || !a.ExpiresAt.IsZero() || len(a.Variants) > 0 || len(a.Rules) > 0 ||
+		a.IsProtected() || a.Interstitial
+	if mustReachService && a.RedirectType.IsCacheable() {
+		return RedirectTemporary
+	}
+	if !a.RedirectType.IsValid() {
+		return RedirectTemporary
+	}
+	return a.RedirectType
+}
+
+// Target returns the url the visitor is redirected to. The campaign parameters, if any, are applied first,
+// then the visited link is merged according to the passthrough policy of the alias.
+// extraPath is the escaped path following the key in the visited link.
+func (a Alias) Target(campaign *Campaign, extraPath string, query url.Values) *url.URL {
+	target := a.URL
+	if campaign != nil {
+		target = campaign.Apply(target)
+	}
+	return a.Passthrough.Apply(target, extraPath, query)
+}
+
+// Match returns the first rule matching the visitor, nil if the visitor goes to the fallback URL
+func (a Alias) Match(visitor Visitor) *RedirectRule {
+	for index := range a.Rules {
+		if a.Rules[index].Matches(visitor) {
+			return &a.Rules[index]
+		}
+	}
+	return nil
+}
+
 // Redirected is a function that creates an AliasLinkRedirected event.
 func (a Alias) Redirected() AliasUsed {
 	return AliasUsed{
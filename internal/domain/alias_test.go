@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAlias_Redirect(t *testing.T) {
+	t.Parallel()
+	target := &url.URL{Scheme: "https", Host: "example.com"}
+	permanent := TTLParams{IsPermanent: true}
+	testCases := []struct {
+		name     string
+		alias    Alias
+		expected RedirectType
+	}{
+		{
+			name:     "permanent alias keeps the cacheable redirect",
+			alias:    Alias{Params: permanent, RedirectType: RedirectPermanent},
+			expected: RedirectPermanent,
+		},
+		{
+			name:     "usage-limited alias",
+			alias:    Alias{Params: TTLParams{TriesLeft: 3}, RedirectType: RedirectMovedPermanently},
+			expected: RedirectTemporary,
+		},
+		{
+			name:     "expiring alias",
+			alias:    Alias{Params: permanent, RedirectType: RedirectPermanent, ExpiresAt: time.Now().Add(time.Hour)},
+			expected: RedirectTemporary,
+		},
+		{
+			name: "alias with rules",
+			alias: Alias{Params: permanent, RedirectType: RedirectMovedPermanently, Rules: []RedirectRule{
+				{Name: "ios", Platforms: []Platform{PlatformIOS}, URL: target},
+			}},
+			expected: RedirectTemporary,
+		},
+		{
+			name:     "not cacheable redirect is kept",
+			alias:    Alias{Params: TTLParams{TriesLeft: 3}, RedirectType: RedirectFound},
+			expected: RedirectFound,
+		},
+		{
+			name:     "unset redirect",
+			alias:    Alias{Params: permanent},
+			expected: RedirectTemporary,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, testCase.alias.Redirect())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Blocklist holds the domains the aliases must not lead to, subdomains of a listed domain are blocked as well
+type Blocklist struct {
+	domains map[string]struct{}
+}
+
+// NewBlocklist creates a new Blocklist
+func NewBlocklist(domains []string) *Blocklist {
+	blocklist := &Blocklist{domains: make(map[string]struct{}, len(domains))}
+	for _, domain := range domains {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			blocklist.domains[domain] = struct{}{}
+		}
+	}
+	return blocklist
+}
+
+// Len returns the number of blocked domains
+func (b *Blocklist) Len() int {
+	return len(b.domains)
+}
+
+// Blocks reports whether the host of the url or any of its parent domains is blocked
+func (b *Blocklist) Blocks(target *url.URL) bool {
+	if b == nil || len(b.domains) == 0 || target == nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	for host != "" {
+		if _, blocked := b.domains[host]; blocked {
+			return true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return false
+}
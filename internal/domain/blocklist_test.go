@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestBlocklist_Blocks(t *testing.T) {
+	t.Parallel()
+	blocklist := NewBlocklist([]string{"Evil.test", " .phishing.test. ", ""})
+	testCases := []struct {
+		name     string
+		target   string
+		expected bool
+	}{
+		{name: "listed domain", target: "https://evil.test/login", expected: true},
+		{name: "subdomain of listed domain", target: "http://www.EVIL.test:8080/", expected: true},
+		{name: "normalized domain", target: "https://phishing.test./", expected: true},
+		{name: "domain with the same suffix", target: "https://notevil.test/", expected: false},
+		{name: "parent of listed domain", target: "https://test/", expected: false},
+		{name: "other domain", target: "https://example.com/", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			target, err := url.Parse(testCase.target)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, blocklist.Blocks(target))
+		})
+	}
+
+	require.Equal(t, 2, blocklist.Len())
+	require.False(t, (*Blocklist)(nil).Blocks(&url.URL{Host: "evil.test"}))
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var campaignNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Campaign is a named set of query parameters added to the target urls of the attached aliases
+type Campaign struct {
+	ID        string
+	Name      string
+	Params    map[string]string
+	CreatedAt time.Time
+}
+
+// Validate checks the campaign name and parameters
+func (c Campaign) Validate() error {
+	if !campaignNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidCampaign, campaignNamePattern)
+	}
+	if len(c.Params) == 0 {
+		return fmt.Errorf("%w: at least one parameter is required", ErrInvalidCampaign)
+	}
+	for name := range c.Params {
+		if name == "" {
+			return fmt.Errorf("%w: parameter name is empty", ErrInvalidCampaign)
+		}
+	}
+	return nil
+}
+
+// Apply returns the target url with the campaign parameters set. Parameters of the campaign replace
+// the ones of the target url with the same name. The target url is never modified.
+func (c Campaign) Apply(target *url.URL) *url.URL {
+	result := *target
+	if len(c.Params) == 0 {
+		return &result
+	}
+
+	query := target.Query()
+	for name, value := range c.Params {
+		query.Set(name, value)
+	}
+	result.RawQuery = query.Encode()
+	return &result
+}
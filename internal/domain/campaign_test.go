@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestCampaign_Validate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		campaign  Campaign
+		expectErr error
+	}{
+		{
+			name:     "valid campaign",
+			campaign: Campaign{Name: "spring-sale_2024", Params: map[string]string{"utm_source": "newsletter"}},
+		},
+		{
+			name:      "empty name",
+			campaign:  Campaign{Params: map[string]string{"utm_source": "newsletter"}},
+			expectErr: ErrInvalidCampaign,
+		},
+		{
+			name:      "name with spaces",
+			campaign:  Campaign{Name: "spring sale", Params: map[string]string{"utm_source": "newsletter"}},
+			expectErr: ErrInvalidCampaign,
+		},
+		{
+			name:      "no parameters",
+			campaign:  Campaign{Name: "spring-sale"},
+			expectErr: ErrInvalidCampaign,
+		},
+		{
+			name:      "empty parameter name",
+			campaign:  Campaign{Name: "spring-sale", Params: map[string]string{"": "newsletter"}},
+			expectErr: ErrInvalidCampaign,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, testCase.campaign.Validate(), testCase.expectErr)
+		})
+	}
+}
+
+func TestCampaign_Apply(t *testing.T) {
+	t.Parallel()
+	campaign := Campaign{
+		Name:   "spring-sale",
+		Params: map[string]string{"utm_source": "newsletter", "utm_medium": "email", "utm_campaign": "spring"},
+	}
+	testCases := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{
+			name:     "parameters are added",
+			target:   "https://example.com/page",
+			expected: "https://example.com/page?utm_campaign=spring&utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name:     "parameters of the target are kept",
+			target:   "https://example.com/page?id=42#top",
+			expected: "https://example.com/page?id=42&utm_campaign=spring&utm_medium=email&utm_source=newsletter#top",
+		},
+		{
+			name:     "campaign parameters replace the ones of the target",
+			target:   "https://example.com/page?utm_source=old&utm_source=older",
+			expected: "https://example.com/page?utm_campaign=spring&utm_medium=email&utm_source=newsletter",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			target, err := url.Parse(testCase.target)
+			require.NoError(t, err)
+
+			require.Equal(t, testCase.expected, campaign.Apply(target).String())
+			require.Equal(t, testCase.target, target.String(), "target url must not be modified")
+		})
+	}
+}
@@ -6,3 +6,21 @@ var ErrAliasNotFound = errors.New("alias not found")
 var ErrAliasExpired = errors.New("alias expired")
 var ErrStatsCollectingFailed = errors.New("statistics collecting failed")
 var ErrUnknownStorageType = errors.New("unknown storage type")
+var ErrInvalidRedirectType = errors.New("invalid redirect type")
+var ErrInvalidPassthroughPolicy = errors.New("invalid passthrough policy")
+var ErrCampaignNotFound = errors.New("campaign not found")
+var ErrCampaignAlreadyExists = errors.New("campaign already exists")
+var ErrInvalidCampaign = errors.New("invalid campaign")
+var ErrInvalidRule = errors.New("invalid redirect rule")
+var ErrInvalidVariant = errors.New("invalid variant")
+var ErrAliasNotActive = errors.New("alias not active yet")
+var ErrInvalidExpiration = errors.New("invalid expiration time")
+var ErrInvalidKey = errors.New("invalid alias key")
+var ErrKeyAlreadyExists = errors.New("alias key already exists")
+var ErrInvalidListFilter = errors.New("invalid list filter")
+var ErrAliasDisabled = errors.New("alias disabled")
+var ErrDestinationBlocked = errors.New("destination blocked")
+var ErrInvalidPassword = errors.New("invalid password")
+var ErrWrongPassword = errors.New("wrong password")
+var ErrTooManyAttempts = errors.New("too many password attempts")
+var ErrCacheableRedirectForLimitedAlias = errors.New("permanent redirect is not allowed for usage-limited or expiring alias")
@@ -8,6 +8,14 @@ import (
 type AliasUsed struct {
 	Alias
 	OccurredAt time.Time
+	// Rule is the name of the redirect rule the visitor matched, empty for the fallback URL
+	Rule string
+	// Variant is the name of the A/B variant the visitor was sent to
+	Variant string
+	// TraceContext carries the trace context of the request the event originates from
+	TraceContext map[string]string
+	// RequestID is the ID of the request the event originates from
+	RequestID string
 }
 
 func (a AliasUsed) String() string {
@@ -18,6 +26,10 @@ func (a AliasUsed) String() string {
 type AliasExpired struct {
 	Alias
 	OccurredAt time.Time
+	// TraceContext carries the trace context of the request the event originates from
+	TraceContext map[string]string
+	// RequestID is the ID of the request the event originates from
+	RequestID string
 }
 
 func (u AliasExpired) String() string {
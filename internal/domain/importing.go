@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MaxKeyLength = 64
+
+// reservedKeys are the first path segments taken by the endpoints of the service, an alias with such a key is unreachable
+var reservedKeys = map[string]struct{}{"api": {}, "livez": {}, "readyz": {}, "import": {}, "export": {}}
+
+// ValidateKey checks a key supplied instead of a generated one.
+// The key must be a path segment made of the letters, digits and "-", "_", ".", "~", "=" characters.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return fmt.Errorf("%w: length must be between 1 and %d", ErrInvalidKey, MaxKeyLength)
+	}
+	for _, char := range key {
+		if !isKeyChar(char) {
+			return fmt.Errorf("%w: character %q is not allowed", ErrInvalidKey, char)
+		}
+	}
+	if _, reserved := reservedKeys[strings.ToLower(key)]; reserved || strings.Trim(key, ".") == "" {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+func isKeyChar(char rune) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' ||
+		strings.ContainsRune("-_.~=", char)
+}
+
+// ImportRow is a single alias of an import
+type ImportRow struct {
+	// Line is the position of the row in the imported data, the results are reported by it
+	Line int
+	// Key is the key to be preserved, empty to generate one
+	Key     string
+	Request CreateRequest
+	// Err is the problem found while reading the row, such a row is reported failed as is
+	Err error
+}
+
+// ImportSource reads the rows of an import one by one, Next returns io.EOF after the last row.
+// Any other error aborts the import.
+type ImportSource interface {
+	Next() (ImportRow, error)
+}
+
+// ImportStatus is the outcome of an imported row
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	// ImportValid is the outcome of a valid row in dry-run mode, nothing is saved
+	ImportValid  ImportStatus = "valid"
+	ImportFailed ImportStatus = "failed"
+)
+
+// ImportResult is the outcome of an imported row
+type ImportResult struct {
+	Line   int
+	Key    string
+	Status ImportStatus
+	// Err is the reason of the failure
+	Err error
+}
+
+// ImportOptions control an import
+type ImportOptions struct {
+	// DryRun validates the rows without saving the aliases
+	DryRun bool
+}
+
+// ImportSummary counts the outcomes of an import
+type ImportSummary struct {
+	Rows     int
+	Imported int
+	Failed   int
+	DryRun   bool
+}
+
+// Add counts the result
+func (s *ImportSummary) Add(result ImportResult) {
+	s.Rows++
+	if result.Status == ImportFailed {
+		s.Failed++
+	} else {
+		s.Imported++
+	}
+}
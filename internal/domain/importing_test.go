@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		key       string
+		expectErr error
+	}{
+		{name: "generated key", key: "pfemZ9bl5w=="},
+		{name: "legacy key", key: "spring-sale_2024.v2~a"},
+		{name: "empty key", key: "", expectErr: ErrInvalidKey},
+		{name: "too long key", key: strings.Repeat("a", MaxKeyLength+1), expectErr: ErrInvalidKey},
+		{name: "slash", key: "a/b", expectErr: ErrInvalidKey},
+		{name: "non-ascii letter", key: "ключ", expectErr: ErrInvalidKey},
+		{name: "reserved key", key: "Api", expectErr: ErrInvalidKey},
+		{name: "dot segment", key: "..", expectErr: ErrInvalidKey},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.ErrorIs(t, ValidateKey(testCase.key), testCase.expectErr)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// AliasStatus is the state of the alias as seen by its visitors
+type AliasStatus string
+
+const (
+	StatusActive    AliasStatus = "active"
+	StatusScheduled AliasStatus = "scheduled"
+	StatusDisabled  AliasStatus = "disabled"
+	StatusExpired   AliasStatus = "expired"
+	// StatusDeleted is the status of the aliases in the trash, they are listed only if asked for explicitly
+	StatusDeleted AliasStatus = "deleted"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// ParseAliasStatus parses the status of the list filter, empty value means any status
+func ParseAliasStatus(value string) (AliasStatus, error) {
+	switch status := AliasStatus(value); status {
+	case "", StatusActive, StatusScheduled, StatusDisabled, StatusExpired, StatusDeleted:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: unknown status %q", ErrInvalidListFilter, value)
+	}
+}
+
+// IsScheduled reports whether the alias is not active yet at the given time
+func (a Alias) IsScheduled(now time.Time) bool {
+	return !a.ActivatesAt.IsZero() && now.Before(a.ActivatesAt)
+}
+
+// IsExpired reports whether the alias cannot be followed anymore at the given time: it has no uses left,
+// its expiration time has passed or the reaper has deactivated it
+func (a Alias) IsExpired(now time.Time) bool {
+	return !a.ExpiredAt.IsZero() || a.IsExhausted() || !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt)
+}
+
+// ValidateExpiration checks the expiration time of a new alias, zero time means the alias never expires.
+// The alias must expire in the future and after it is activated.
+func ValidateExpiration(expiresAt, activatesAt, now time.Time) error {
+	switch {
+	case expiresAt.IsZero():
+		return nil
+	case !expiresAt.After(now):
+		return fmt.Errorf("%w: %s is in the past", ErrInvalidExpiration, expiresAt.UTC().Format(time.RFC3339))
+	case !activatesAt.IsZero() && !expiresAt.After(activatesAt):
+		return fmt.Errorf("%w: %s is not after the activation time", ErrInvalidExpiration, expiresAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Status returns the state of the alias at the given time.
+// A deleted alias is reported as deleted, then a disabled one as disabled, then an expired one as expired,
+// whatever its schedule is.
+func (a Alias) Status(now time.Time) AliasStatus {
+	switch {
+	case !a.IsActive:
+		return StatusDeleted
+	case a.Disabled:
+		return StatusDisabled
+	case a.IsExpired(now):
+		return StatusExpired
+	case a.IsScheduled(now):
+		return StatusScheduled
+	default:
+		return StatusActive
+	}
+}
+
+// ListFilter selects the aliases of a listing, the aliases are ordered by key
+type ListFilter struct {
+	Status   AliasStatus
+	Campaign string
+	// After is the key the listing continues after, empty for the first page
+	After string
+	Limit int
+}
+
+// Validate checks the filter, zero limit means the default one
+func (f ListFilter) Validate() error {
+	if _, err := ParseAliasStatus(string(f.Status)); err != nil {
+		return err
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListFilter, MaxListLimit)
+	}
+	return nil
+}
+
+// Matches reports whether the alias passes the filter at the given time, the page boundaries are not checked.
+// The deleted aliases match only the filter of the deleted status.
+func (f ListFilter) Matches(alias Alias, now time.Time) bool {
+	status := alias.Status(now)
+	if f.Status == "" && status == StatusDeleted || f.Status != "" && status != f.Status {
+		return false
+	}
+	return f.Campaign == "" || alias.Campaign == f.Campaign
+}
+
+// AliasPage is a page of a listing
+type AliasPage struct {
+	Aliases []Alias
+	// Next is the cursor of the next page, empty on the last one
+	Next string
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAlias_Status(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		alias    Alias
+		expected AliasStatus
+	}{
+		{name: "permanent alias", alias: Alias{IsActive: true, Params: TTLParams{IsPermanent: true}}, expected: StatusActive},
+		{name: "alias with uses left", alias: Alias{IsActive: true, Params: TTLParams{TriesLeft: 1}}, expected: StatusActive},
+		{name: "activation time passed", alias: Alias{IsActive: true, Params: TTLParams{IsPermanent: true}, ActivatesAt: now}, expected: StatusActive},
+		{name: "activation in future", alias: Alias{IsActive: true, Params: TTLParams{IsPermanent: true}, ActivatesAt: now.Add(time.Second)}, expected: StatusScheduled},
+		{name: "exhausted alias", alias: Alias{IsActive: true, Params: TTLParams{TriesLeft: 0}, ActivatesAt: now.Add(time.Hour)}, expected: StatusExpired},
+		{name: "expiration in future", alias: Alias{IsActive: true, Params: TTLParams{IsPermanent: true}, ExpiresAt: now.Add(time.Second)}, expected: StatusActive},
+		{name: "expiration time passed", alias: Alias{IsActive: true, Params: TTLParams{IsPermanent: true}, ExpiresAt: now}, expected: StatusExpired},
+		{name: "deactivated by the reaper", alias: Alias{IsActive: true, Params: TTLParams{IsPermanent: true}, ExpiredAt: now}, expected: StatusExpired},
+		{
+			name:     "disabled alias",
+			alias:    Alias{IsActive: true, Params: TTLParams{TriesLeft: 0}, Disabled: true, ActivatesAt: now.Add(time.Hour)},
+			expected: StatusDisabled,
+		},
+		{
+			name:     "deleted alias",
+			alias:    Alias{Params: TTLParams{IsPermanent: true}, Disabled: true, DeletedAt: now},
+			expected: StatusDeleted,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, testCase.alias.Status(now))
+		})
+	}
+}
+
+func TestValidateExpiration(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		expiresAt   time.Time
+		activatesAt time.Time
+		expectErr   error
+	}{
+		{name: "never expires", activatesAt: now.Add(time.Hour)},
+		{name: "expires in future", expiresAt: now.Add(time.Second)},
+		{name: "expires after activation", expiresAt: now.Add(2 * time.Hour), activatesAt: now.Add(time.Hour)},
+		{name: "expires now", expiresAt: now, expectErr: ErrInvalidExpiration},
+		{name: "expires in past", expiresAt: now.Add(-time.Hour), expectErr: ErrInvalidExpiration},
+		{name: "expires at activation", expiresAt: now.Add(time.Hour), activatesAt: now.Add(time.Hour), expectErr: ErrInvalidExpiration},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.ErrorIs(t, ValidateExpiration(testCase.expiresAt, testCase.activatesAt, now), testCase.expectErr)
+		})
+	}
+}
+
+func TestListFilter_Validate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		filter    ListFilter
+		expectErr error
+	}{
+		{name: "empty filter", filter: ListFilter{}},
+		{name: "status and limit", filter: ListFilter{Status: StatusScheduled, Limit: MaxListLimit}},
+		{name: "trash", filter: ListFilter{Status: StatusDeleted}},
+		{name: "unknown status", filter: ListFilter{Status: "removed"}, expectErr: ErrInvalidListFilter},
+		{name: "negative limit", filter: ListFilter{Limit: -1}, expectErr: ErrInvalidListFilter},
+		{name: "limit too large", filter: ListFilter{Limit: MaxListLimit + 1}, expectErr: ErrInvalidListFilter},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.ErrorIs(t, testCase.filter.Validate(), testCase.expectErr)
+		})
+	}
+}
+
+func TestListFilter_Matches(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	active := Alias{IsActive: true, Params: TTLParams{IsPermanent: true}, Campaign: "spring"}
+	deleted := Alias{Params: TTLParams{IsPermanent: true}, Campaign: "spring", DeletedAt: now}
+
+	testCases := []struct {
+		name    string
+		filter  ListFilter
+		alias   Alias
+		matches bool
+	}{
+		{name: "any status", filter: ListFilter{}, alias: active, matches: true},
+		{name: "same status and campaign", filter: ListFilter{Status: StatusActive, Campaign: "spring"}, alias: active, matches: true},
+		{name: "other campaign", filter: ListFilter{Campaign: "autumn"}, alias: active, matches: false},
+		{name: "other status", filter: ListFilter{Status: StatusExpired}, alias: active, matches: false},
+		{name: "deleted alias is hidden", filter: ListFilter{Campaign: "spring"}, alias: deleted, matches: false},
+		{name: "deleted alias in trash", filter: ListFilter{Status: StatusDeleted}, alias: deleted, matches: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.matches, testCase.filter.Matches(testCase.alias, now))
+		})
+	}
+}
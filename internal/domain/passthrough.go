@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PassthroughMode tells which parts of the visited short link are forwarded to the target url
+type PassthroughMode string
+
+const (
+	PassthroughNone  PassthroughMode = "none"
+	PassthroughQuery PassthroughMode = "query"
+	PassthroughPath  PassthroughMode = "path"
+	PassthroughAll   PassthroughMode = "all"
+)
+
+// QueryConflict tells how a query parameter present both in the target url and in the visited link is resolved
+type QueryConflict string
+
+const (
+	// QueryConflictKeepTarget keeps the values of the target url
+	QueryConflictKeepTarget QueryConflict = "keep-target"
+	// QueryConflictOverride replaces the values of the target url with the visited ones
+	QueryConflictOverride QueryConflict = "override"
+	// QueryConflictAppend keeps the values of both
+	QueryConflictAppend QueryConflict = "append"
+)
+
+// PassthroughPolicy describes how the visited short link is merged into the target url
+type PassthroughPolicy struct {
+	Mode       PassthroughMode
+	OnConflict QueryConflict
+}
+
+// ParsePassthroughMode parses a passthrough mode, empty value means PassthroughNone
+func ParsePassthroughMode(value string) (PassthroughMode, error) {
+	mode := PassthroughMode(value)
+	if mode == "" {
+		return PassthroughNone, nil
+	}
+	if !mode.IsValid() {
+		return PassthroughNone, fmt.Errorf("%w: %s", ErrInvalidPassthroughPolicy, value)
+	}
+	return mode, nil
+}
+
+// ParseQueryConflict parses a query conflict rule, empty value means QueryConflictKeepTarget
+func ParseQueryConflict(value string) (QueryConflict, error) {
+	conflict := QueryConflict(value)
+	if conflict == "" {
+		return QueryConflictKeepTarget, nil
+	}
+	if !conflict.IsValid() {
+		return QueryConflictKeepTarget, fmt.Errorf("%w: %s", ErrInvalidPassthroughPolicy, value)
+	}
+	return conflict, nil
+}
+
+// IsValid reports whether the passthrough mode is one of the supported ones
+func (m PassthroughMode) IsValid() bool {
+	switch m {
+	case PassthroughNone, PassthroughQuery, PassthroughPath, PassthroughAll:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the query conflict rule is one of the supported ones
+func (c QueryConflict) IsValid() bool {
+	switch c {
+	case QueryConflictKeepTarget, QueryConflictOverride, QueryConflictAppend:
+		return true
+	}
+	return false
+}
+
+// Validate checks the policy, empty fields are treated as defaults
+func (p PassthroughPolicy) Validate() error {
+	if p.Mode != "" && !p.Mode.IsValid() {
+		return fmt.Errorf("%w: mode %s", ErrInvalidPassthroughPolicy, p.Mode)
+	}
+	if p.OnConflict != "" && !p.OnConflict.IsValid() {
+		return fmt.Errorf("%w: conflict rule %s", ErrInvalidPassthroughPolicy, p.OnConflict)
+	}
+	return nil
+}
+
+func (p PassthroughPolicy) passQuery() bool {
+	return p.Mode == PassthroughQuery || p.Mode == PassthroughAll
+}
+
+func (p PassthroughPolicy) passPath() bool {
+	return p.Mode == PassthroughPath || p.Mode == PassthroughAll
+}
+
+// Apply builds the redirect url from the target url, the path following the key and the query of the visited link.
+// The target url is never modified.
+func (p PassthroughPolicy) Apply(target *url.URL, extraPath string, query url.Values) *url.URL {
+	result := *target
+	if target.User != nil {
+		user := *target.User
+		result.User = &user
+	}
+
+	if p.passPath() {
+		if extraPath = strings.TrimLeft(extraPath, "/"); extraPath != "" {
+			result = *result.JoinPath(extraPath)
+		}
+	}
+
+	if p.passQuery() && len(query) > 0 {
+		result.RawQuery = p.mergeQuery(target.Query(), query).Encode()
+	}
+	return &result
+}
+
+func (p PassthroughPolicy) mergeQuery(target, visited url.Values) url.Values {
+	merged := make(url.Values, len(target)+len(visited))
+	for name, values := range target {
+		merged[name] = append([]string(nil), values...)
+	}
+	for name, values := range visited {
+		if _, exists := merged[name]; !exists {
+			merged[name] = append([]string(nil), values...)
+			continue
+		}
+		switch p.OnConflict {
+		case QueryConflictOverride:
+			merged[name] = append([]string(nil), values...)
+		case QueryConflictAppend:
+			merged[name] = append(merged[name], values...)
+		default:
+			// QueryConflictKeepTarget: values of the target url win
+		}
+	}
+	return merged
+}
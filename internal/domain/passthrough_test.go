@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestPassthroughPolicy_Apply(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		policy    PassthroughPolicy
+		target    string
+		extraPath string
+		query     string
+		expected  string
+	}{
+		{
+			name:      "none discards path and query",
+			policy:    PassthroughPolicy{Mode: PassthroughNone},
+			target:    "https://example.com/page?a=1",
+			extraPath: "extra/path",
+			query:     "utm_source=x",
+			expected:  "https://example.com/page?a=1",
+		},
+		{
+			name:     "query is added to the target without query",
+			policy:   PassthroughPolicy{Mode: PassthroughQuery},
+			target:   "https://example.com/page",
+			query:    "utm_source=x",
+			expected: "https://example.com/page?utm_source=x",
+		},
+		{
+			name:      "query mode discards path",
+			policy:    PassthroughPolicy{Mode: PassthroughQuery},
+			target:    "https://example.com/page?a=1",
+			extraPath: "extra",
+			query:     "b=2",
+			expected:  "https://example.com/page?a=1&b=2",
+		},
+		{
+			name:     "conflicting query keeps target values by default",
+			policy:   PassthroughPolicy{Mode: PassthroughQuery},
+			target:   "https://example.com/?a=1",
+			query:    "a=2&b=3",
+			expected: "https://example.com/?a=1&b=3",
+		},
+		{
+			name:     "conflicting query keeps target values",
+			policy:   PassthroughPolicy{Mode: PassthroughQuery, OnConflict: QueryConflictKeepTarget},
+			target:   "https://example.com/?a=1",
+			query:    "a=2",
+			expected: "https://example.com/?a=1",
+		},
+		{
+			name:     "conflicting query overrides target values",
+			policy:   PassthroughPolicy{Mode: PassthroughQuery, OnConflict: QueryConflictOverride},
+			target:   "https://example.com/?a=1&a=4",
+			query:    "a=2",
+			expected: "https://example.com/?a=2",
+		},
+		{
+			name:     "conflicting query appends to target values",
+			policy:   PassthroughPolicy{Mode: PassthroughQuery, OnConflict: QueryConflictAppend},
+			target:   "https://example.com/?a=1",
+			query:    "a=2",
+			expected: "https://example.com/?a=1&a=2",
+		},
+		{
+			name:     "empty query leaves the target query untouched",
+			policy:   PassthroughPolicy{Mode: PassthroughQuery},
+			target:   "https://example.com/?z=1&a=2",
+			expected: "https://example.com/?z=1&a=2",
+		},
+		{
+			name:      "path is appended to the target path",
+			policy:    PassthroughPolicy{Mode: PassthroughPath},
+			target:    "https://example.com/docs",
+			extraPath: "guide/intro",
+			expected:  "https://example.com/docs/guide/intro",
+		},
+		{
+			name:      "path is appended to the target with trailing slash",
+			policy:    PassthroughPolicy{Mode: PassthroughPath},
+			target:    "https://example.com/docs/",
+			extraPath: "/guide",
+			expected:  "https://example.com/docs/guide",
+		},
+		{
+			name:      "path is appended to the target without path",
+			policy:    PassthroughPolicy{Mode: PassthroughPath},
+			target:    "https://example.com",
+			extraPath: "guide/",
+			expected:  "https://example.com/guide/",
+		},
+		{
+			name:      "path mode discards query and keeps target query",
+			policy:    PassthroughPolicy{Mode: PassthroughPath},
+			target:    "https://example.com/docs?a=1",
+			extraPath: "guide",
+			query:     "b=2",
+			expected:  "https://example.com/docs/guide?a=1",
+		},
+		{
+			name:      "path cannot escape the target root",
+			policy:    PassthroughPolicy{Mode: PassthroughPath},
+			target:    "https://example.com/docs",
+			extraPath: "../../../admin",
+			expected:  "https://example.com/admin",
+		},
+		{
+			name:      "escaped path is preserved",
+			policy:    PassthroughPolicy{Mode: PassthroughPath},
+			target:    "https://example.com/files",
+			extraPath: "a%2Fb/c%20d",
+			expected:  "https://example.com/files/a%2Fb/c%20d",
+		},
+		{
+			name:      "all passes both path and query",
+			policy:    PassthroughPolicy{Mode: PassthroughAll, OnConflict: QueryConflictOverride},
+			target:    "https://example.com/docs?a=1#top",
+			extraPath: "guide",
+			query:     "a=2&b=3",
+			expected:  "https://example.com/docs/guide?a=2&b=3#top",
+		},
+		{
+			name:      "empty policy behaves as none",
+			policy:    PassthroughPolicy{},
+			target:    "https://example.com/docs",
+			extraPath: "guide",
+			query:     "a=1",
+			expected:  "https://example.com/docs",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			target, err := url.Parse(testCase.target)
+			require.NoError(t, err)
+			query, err := url.ParseQuery(testCase.query)
+			require.NoError(t, err)
+
+			got := testCase.policy.Apply(target, testCase.extraPath, query)
+			require.Equal(t, testCase.expected, got.String())
+			require.Equal(t, testCase.target, target.String(), "target url must not be modified")
+		})
+	}
+}
+
+func TestPassthroughPolicy_Validate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		policy    PassthroughPolicy
+		expectErr error
+	}{
+		{name: "empty policy", policy: PassthroughPolicy{}},
+		{name: "valid policy", policy: PassthroughPolicy{Mode: PassthroughAll, OnConflict: QueryConflictAppend}},
+		{name: "unknown mode", policy: PassthroughPolicy{Mode: "fragment"}, expectErr: ErrInvalidPassthroughPolicy},
+		{name: "unknown conflict rule", policy: PassthroughPolicy{OnConflict: "merge"}, expectErr: ErrInvalidPassthroughPolicy},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, testCase.policy.Validate(), testCase.expectErr)
+		})
+	}
+}
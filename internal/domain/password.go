@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"net/url"
+)
+
+const (
+	MinPasswordLength = 4
+	// MaxPasswordLength is the longest password bcrypt takes into account
+	MaxPasswordLength = 72
+)
+
+// HashPassword validates the password of a protected alias and returns its salted hash
+func HashPassword(password string) ([]byte, error) {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return nil, fmt.Errorf("%w: length must be in range %d..%d", ErrInvalidPassword, MinPasswordLength, MaxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPassword, err)
+	}
+	return hash, nil
+}
+
+// IsProtected reports whether the alias requires a password to be followed
+func (a Alias) IsProtected() bool {
+	return len(a.PasswordHash) > 0
+}
+
+// CheckPassword verifies the password of a protected alias, unprotected aliases accept any password
+func (a Alias) CheckPassword(password string) error {
+	if !a.IsProtected() {
+		return nil
+	}
+	err := bcrypt.CompareHashAndPassword(a.PasswordHash, []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrWrongPassword
+	}
+	return err
+}
+
+// Redacted returns the alias as the management API shows it: a protected alias never reveals its destinations,
+// the target, rule and variant URLs are blank as on the preview page
+func (a Alias) Redacted() Alias {
+	if !a.IsProtected() {
+		return a
+	}
+	a.URL = &url.URL{}
+	if a.Rules != nil {
+		rules := make([]RedirectRule, len(a.Rules))
+		for index, rule := range a.Rules {
+			rule.URL = &url.URL{}
+			rules[index] = rule
+		}
+		a.Rules = rules
+	}
+	if a.Variants != nil {
+		variants := make([]Variant, len(a.Variants))
+		for index, variant := range a.Variants {
+			variant.URL = &url.URL{}
+			variants[index] = variant
+		}
+		a.Variants = variants
+	}
+	return a
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		password  string
+		expectErr error
+	}{
+		{name: "valid password", password: "secret"},
+		{name: "too short password", password: "abc", expectErr: ErrInvalidPassword},
+		{name: "too long password", password: strings.Repeat("a", MaxPasswordLength+1), expectErr: ErrInvalidPassword},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			hash, err := HashPassword(testCase.password)
+			require.ErrorIs(t, err, testCase.expectErr)
+			if testCase.expectErr == nil {
+				require.NotContains(t, string(hash), testCase.password)
+			}
+		})
+	}
+}
+
+func TestAlias_CheckPassword(t *testing.T) {
+	t.Parallel()
+	first, err := HashPassword("secret")
+	require.NoError(t, err)
+	second, err := HashPassword("secret")
+	require.NoError(t, err)
+	require.NotEqual(t, first, second, "hashes must be salted")
+
+	alias := Alias{PasswordHash: first}
+	require.True(t, alias.IsProtected())
+	require.NoError(t, alias.CheckPassword("secret"))
+	require.ErrorIs(t, alias.CheckPassword("Secret"), ErrWrongPassword)
+	require.ErrorIs(t, alias.CheckPassword(""), ErrWrongPassword)
+
+	require.NoError(t, Alias{}.CheckPassword("anything"), "unprotected alias accepts any password")
+}
+
+func TestAlias_Redacted(t *testing.T) {
+	t.Parallel()
+	target, err := url.Parse("https://example.com/secret")
+	require.NoError(t, err)
+	alias := Alias{
+		Key:      "key",
+		URL:      target,
+		Rules:    []RedirectRule{{Name: "ios", Platforms: []Platform{PlatformIOS}, URL: target}},
+		Variants: []Variant{{Name: "a", URL: target, Weight: 1}},
+	}
+	require.Equal(t, alias, alias.Redacted(), "unprotected alias reveals its destinations")
+
+	alias.PasswordHash = []byte("hash")
+	redacted := alias.Redacted()
+	require.True(t, redacted.IsProtected())
+	require.Empty(t, redacted.URL.String())
+	require.Empty(t, redacted.Rules[0].URL.String())
+	require.Equal(t, "ios", redacted.Rules[0].Name)
+	require.Empty(t, redacted.Variants[0].URL.String())
+	require.Equal(t, 1, redacted.Variants[0].Weight)
+	require.Equal(t, "https://example.com/secret", alias.Rules[0].URL.String(), "original alias is not modified")
+	require.Equal(t, "https://example.com/secret", alias.Variants[0].URL.String(), "original alias is not modified")
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"net/url"
+	"time"
+)
+
+// Preview describes where the alias leads without following it
+type Preview struct {
+	Key         string
+	Type        string
+	IsPermanent bool
+	TriesLeft   int
+	CreatedAt   time.Time
+	// Protected aliases never reveal their destinations
+	Protected bool
+	// Destinations contains the destination of the visitor, or all A/B variants if the visitor is not assigned yet
+	Destinations []*url.URL
+}
+
+// IsExhausted reports whether the usage-limited alias has no uses left
+func (a Alias) IsExhausted() bool {
+	return !a.Params.IsPermanent && a.Params.TriesLeft == 0
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// RedirectType is an HTTP status code the alias redirects with
+type RedirectType int
+
+const (
+	RedirectDefault          RedirectType = 0
+	RedirectMovedPermanently RedirectType = http.StatusMovedPermanently
+	RedirectFound            RedirectType = http.StatusFound
+	RedirectTemporary        RedirectType = http.StatusTemporaryRedirect
+	RedirectPermanent        RedirectType = http.StatusPermanentRedirect
+)
+
+// ParseRedirectType parses a redirect type given as an HTTP status code
+func ParseRedirectType(value string) (RedirectType, error) {
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return RedirectDefault, fmt.Errorf("%w: %s", ErrInvalidRedirectType, value)
+	}
+	redirectType := RedirectType(code)
+	if !redirectType.IsValid() {
+		return RedirectDefault, fmt.Errorf("%w: %s", ErrInvalidRedirectType, value)
+	}
+	return redirectType, nil
+}
+
+// IsValid reports whether the redirect type is one of the supported ones
+func (t RedirectType) IsValid() bool {
+	switch t {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent:
+		return true
+	}
+	return false
+}
+
+// IsCacheable reports whether browsers are allowed to cache the redirect permanently
+func (t RedirectType) IsCacheable() bool {
+	return t == RedirectMovedPermanently || t == RedirectPermanent
+}
+
+// StatusCode returns the HTTP status code of the redirect, temporary redirect if unset
+func (t RedirectType) StatusCode() int {
+	if !t.IsValid() {
+		return int(RedirectTemporary)
+	}
+	return int(t)
+}
+
+// NotActiveResponse is how the visitors of the scheduled aliases are answered
+type NotActiveResponse string
+
+const (
+	// NotActiveNotFound answers as if the alias did not exist, so the scheduled links are not revealed
+	NotActiveNotFound NotActiveResponse = "not-found"
+	// NotActiveComingSoon answers with ALIAS_NOT_ACTIVE and the "coming soon" page telling the activation time
+	NotActiveComingSoon NotActiveResponse = "coming-soon"
+)
+
+// ParseNotActiveResponse parses the configured response to the visitors of the scheduled aliases
+func ParseNotActiveResponse(value string) (NotActiveResponse, error) {
+	switch response := NotActiveResponse(value); response {
+	case NotActiveNotFound, NotActiveComingSoon:
+		return response, nil
+	default:
+		return "", fmt.Errorf("unknown not active response %q, must be %s or %s", value, NotActiveNotFound, NotActiveComingSoon)
+	}
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxRedirectRules limits the number of targeting rules of a single alias
+const MaxRedirectRules = 32
+
+// ruleNamePattern also keeps rule names usable as keys of the statistics documents
+var ruleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Platform is an operating system of the visitor detected from the User-Agent header
+type Platform string
+
+const (
+	PlatformIOS     Platform = "ios"
+	PlatformAndroid Platform = "android"
+	PlatformWindows Platform = "windows"
+	PlatformMacOS   Platform = "macos"
+	PlatformLinux   Platform = "linux"
+	PlatformOther   Platform = "other"
+)
+
+// IsValid reports whether the platform is one of the supported ones
+func (p Platform) IsValid() bool {
+	switch p {
+	case PlatformIOS, PlatformAndroid, PlatformWindows, PlatformMacOS, PlatformLinux, PlatformOther:
+		return true
+	}
+	return false
+}
+
+// Visitor describes the client following the short link
+type Visitor struct {
+	UserAgent      string
+	AcceptLanguage string
+	// Country is an ISO 3166-1 alpha-2 code set by the proxy in front of the service
+	Country string
+	// Variant is the A/B variant the visitor has been assigned to on the previous visits
+	Variant string
+}
+
+// Visit is a registered visit of an alias
+type Visit struct {
+	// Alias is the visited alias with URL set to the destination of the visitor
+	Alias *Alias
+	// Rule is the name of the matched redirect rule, empty if none matched
+	Rule string
+	// Variant is the name of the A/B variant the visitor is assigned to, empty if the alias has no variants
+	Variant string
+}
+
+// Platform detects the operating system of the visitor
+func (v Visitor) Platform() Platform {
+	ua := v.UserAgent
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		return PlatformIOS
+	case strings.Contains(ua, "Android"):
+		return PlatformAndroid
+	case strings.Contains(ua, "Windows"):
+		return PlatformWindows
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		return PlatformMacOS
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"), strings.Contains(ua, "CrOS"):
+		return PlatformLinux
+	}
+	return PlatformOther
+}
+
+// Language returns the most preferred language of the visitor in lower case, empty if unknown
+func (v Visitor) Language() string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var languages []weighted
+	for _, part := range strings.Split(v.AcceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			languages = append(languages, weighted{tag: tag, q: q})
+		}
+	}
+	if len(languages) == 0 {
+		return ""
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+	return languages[0].tag
+}
+
+// RedirectRule sends the visitors matching all of its non-empty conditions to its URL.
+// A condition matches if any of its values matches.
+type RedirectRule struct {
+	Name      string
+	Platforms []Platform
+	// Languages are language tags matched against the most preferred language of the visitor,
+	// "de" matches both "de" and "de-at", "de-at" matches only "de-at"
+	Languages []string
+	Countries []string
+	URL       *url.URL
+}
+
+// Validate checks the rule
+func (r RedirectRule) Validate() error {
+	if !ruleNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidRule, ruleNamePattern)
+	}
+	if r.URL == nil {
+		return fmt.Errorf("%w: rule %s has no url", ErrInvalidRule, r.Name)
+	}
+	if len(r.Platforms) == 0 && len(r.Languages) == 0 && len(r.Countries) == 0 {
+		return fmt.Errorf("%w: rule %s has no conditions", ErrInvalidRule, r.Name)
+	}
+	for _, platform := range r.Platforms {
+		if !platform.IsValid() {
+			return fmt.Errorf("%w: rule %s has unknown platform %s", ErrInvalidRule, r.Name, platform)
+		}
+	}
+	for _, language := range r.Languages {
+		if language == "" {
+			return fmt.Errorf("%w: rule %s has empty language", ErrInvalidRule, r.Name)
+		}
+	}
+	for _, country := range r.Countries {
+		if len(country) != 2 {
+			return fmt.Errorf("%w: rule %s has invalid country %s", ErrInvalidRule, r.Name, country)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the visitor satisfies all conditions of the rule
+func (r RedirectRule) Matches(visitor Visitor) bool {
+	if len(r.Platforms) > 0 {
+		if !slices.Contains(r.Platforms, visitor.Platform()) {
+			return false
+		}
+	}
+	if len(r.Languages) > 0 {
+		preferred := visitor.Language()
+		if preferred == "" || !slices.ContainsFunc(r.Languages, func(language string) bool {
+			language = strings.ToLower(language)
+			return preferred == language || strings.HasPrefix(preferred, language+"-")
+		}) {
+			return false
+		}
+	}
+	if len(r.Countries) > 0 {
+		if visitor.Country == "" || !slices.ContainsFunc(r.Countries, func(country string) bool {
+			return strings.EqualFold(country, visitor.Country)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRules checks the ordered rule list of an alias
+func ValidateRules(rules []RedirectRule) error {
+	if len(rules) > MaxRedirectRules {
+		return fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidRule, MaxRedirectRules)
+	}
+	names := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if _, exists := names[rule.Name]; exists {
+			return fmt.Errorf("%w: duplicate rule name %s", ErrInvalidRule, rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestVisitor_Platform(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		userAgent string
+		expected  Platform
+	}{
+		{userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15", expected: PlatformIOS},
+		{userAgent: "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15", expected: PlatformIOS},
+		{userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36", expected: PlatformAndroid},
+		{userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36", expected: PlatformWindows},
+		{userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15", expected: PlatformMacOS},
+		{userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", expected: PlatformLinux},
+		{userAgent: "curl/8.5.0", expected: PlatformOther},
+		{userAgent: "", expected: PlatformOther},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.expected)+" "+testCase.userAgent, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, testCase.expected, Visitor{UserAgent: testCase.userAgent}.Platform())
+		})
+	}
+}
+
+func TestVisitor_Language(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		acceptLanguage string
+		expected       string
+	}{
+		{acceptLanguage: "de", expected: "de"},
+		{acceptLanguage: "de-DE,de;q=0.9,en;q=0.8", expected: "de-de"},
+		{acceptLanguage: "en;q=0.5, fr-CH", expected: "fr-ch"},
+		{acceptLanguage: "*, en;q=0.1", expected: "en"},
+		{acceptLanguage: "de;q=0, en;q=invalid", expected: ""},
+		{acceptLanguage: "", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.acceptLanguage, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, testCase.expected, Visitor{AcceptLanguage: testCase.acceptLanguage}.Language())
+		})
+	}
+}
+
+func TestRedirectRule_Matches(t *testing.T) {
+	t.Parallel()
+	iPhone := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"
+	testCases := []struct {
+		name     string
+		rule     RedirectRule
+		visitor  Visitor
+		expected bool
+	}{
+		{
+			name:     "platform matches",
+			rule:     RedirectRule{Platforms: []Platform{PlatformAndroid, PlatformIOS}},
+			visitor:  Visitor{UserAgent: iPhone},
+			expected: true,
+		},
+		{
+			name:    "platform does not match",
+			rule:    RedirectRule{Platforms: []Platform{PlatformAndroid}},
+			visitor: Visitor{UserAgent: iPhone},
+		},
+		{
+			name:     "language range matches regional tag",
+			rule:     RedirectRule{Languages: []string{"de"}},
+			visitor:  Visitor{AcceptLanguage: "de-AT"},
+			expected: true,
+		},
+		{
+			name:    "regional tag does not match other region",
+			rule:    RedirectRule{Languages: []string{"de-AT"}},
+			visitor: Visitor{AcceptLanguage: "de-DE"},
+		},
+		{
+			name:    "only the most preferred language is matched",
+			rule:    RedirectRule{Languages: []string{"de"}},
+			visitor: Visitor{AcceptLanguage: "en, de;q=0.9"},
+		},
+		{
+			name:     "country matches case-insensitively",
+			rule:     RedirectRule{Countries: []string{"DE", "AT"}},
+			visitor:  Visitor{Country: "at"},
+			expected: true,
+		},
+		{
+			name:    "unknown country does not match",
+			rule:    RedirectRule{Countries: []string{"DE"}},
+			visitor: Visitor{},
+		},
+		{
+			name:     "all conditions must match",
+			rule:     RedirectRule{Platforms: []Platform{PlatformIOS}, Countries: []string{"DE"}},
+			visitor:  Visitor{UserAgent: iPhone, Country: "DE"},
+			expected: true,
+		},
+		{
+			name:    "one of conditions does not match",
+			rule:    RedirectRule{Platforms: []Platform{PlatformIOS}, Countries: []string{"DE"}},
+			visitor: Visitor{UserAgent: iPhone, Country: "FR"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, testCase.expected, testCase.rule.Matches(testCase.visitor))
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	t.Parallel()
+	target := &url.URL{Scheme: "https", Host: "example.com"}
+	testCases := []struct {
+		name      string
+		rules     []RedirectRule
+		expectErr error
+	}{
+		{name: "no rules"},
+		{
+			name:  "valid rules",
+			rules: []RedirectRule{{Name: "ios", Platforms: []Platform{PlatformIOS}, URL: target}, {Name: "de", Languages: []string{"de"}, URL: target}},
+		},
+		{
+			name:      "duplicate names",
+			rules:     []RedirectRule{{Name: "ios", Platforms: []Platform{PlatformIOS}, URL: target}, {Name: "ios", Countries: []string{"US"}, URL: target}},
+			expectErr: ErrInvalidRule,
+		},
+		{
+			name:      "invalid name",
+			rules:     []RedirectRule{{Name: "i.os", Platforms: []Platform{PlatformIOS}, URL: target}},
+			expectErr: ErrInvalidRule,
+		},
+		{
+			name:      "unknown platform",
+			rules:     []RedirectRule{{Name: "tv", Platforms: []Platform{"tizen"}, URL: target}},
+			expectErr: ErrInvalidRule,
+		},
+		{
+			name:      "invalid country",
+			rules:     []RedirectRule{{Name: "de", Countries: []string{"DEU"}, URL: target}},
+			expectErr: ErrInvalidRule,
+		},
+		{
+			name:      "no url",
+			rules:     []RedirectRule{{Name: "de", Countries: []string{"DE"}}},
+			expectErr: ErrInvalidRule,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, ValidateRules(testCase.rules), testCase.expectErr)
+		})
+	}
+}
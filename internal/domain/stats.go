@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ClickStats are the click counters of an alias
+type ClickStats struct {
+	Key         string
+	Total       int
+	Rules       map[string]int
+	Variants    map[string]int
+	LastClickAt time.Time
+}
+
+// Click is a single visit of an alias kept in the click log
+type Click struct {
+	// ID orders the clicks in the log, the IDs of the later clicks are greater
+	ID         string
+	Key        string
+	Campaign   string
+	Rule       string
+	Variant    string
+	OccurredAt time.Time
+}
+
+// Expiration is the record of an alias that ran out of uses, kept in the statistics
+type Expiration struct {
+	// ID orders the records in the storage
+	ID         string
+	Key        string
+	URL        *url.URL
+	Campaign   string
+	OccurredAt time.Time
+}
+
+// ClickFilter selects the clicks of the log, the clicks are ordered by ID
+type ClickFilter struct {
+	Key      string
+	Campaign string
+	// From and To limit the time of the clicks to [From, To), zero time means no limit
+	From time.Time
+	To   time.Time
+	// After is the ID the listing continues after, empty for the first page
+	After string
+	Limit int
+}
+
+// Validate checks the filter, zero limit means the default one
+func (f ClickFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidListFilter)
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListFilter, MaxListLimit)
+	}
+	return nil
+}
+
+// Matches reports whether the click passes the filter, the page boundaries are not checked
+func (f ClickFilter) Matches(click Click) bool {
+	return (f.Key == "" || click.Key == f.Key) &&
+		(f.Campaign == "" || click.Campaign == f.Campaign) &&
+		(f.From.IsZero() || !click.OccurredAt.Before(f.From)) &&
+		(f.To.IsZero() || click.OccurredAt.Before(f.To))
+}
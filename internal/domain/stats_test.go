@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClickFilter(t *testing.T) {
+	t.Parallel()
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	click := Click{ID: "1", Key: "promo", Campaign: "spring", OccurredAt: noon}
+
+	testCases := []struct {
+		name      string
+		filter    ClickFilter
+		matches   bool
+		expectErr error
+	}{
+		{name: "any click", filter: ClickFilter{}, matches: true},
+		{name: "same key and campaign", filter: ClickFilter{Key: "promo", Campaign: "spring"}, matches: true},
+		{name: "other key", filter: ClickFilter{Key: "other"}},
+		{name: "other campaign", filter: ClickFilter{Campaign: "autumn"}},
+		{name: "from is inclusive", filter: ClickFilter{From: noon, To: noon.Add(time.Hour)}, matches: true},
+		{name: "to is exclusive", filter: ClickFilter{From: noon.Add(-time.Hour), To: noon}},
+		{name: "empty range", filter: ClickFilter{From: noon, To: noon}, expectErr: ErrInvalidListFilter},
+		{name: "too large limit", filter: ClickFilter{Limit: MaxListLimit + 1}, matches: true, expectErr: ErrInvalidListFilter},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.ErrorIs(t, testCase.filter.Validate(), testCase.expectErr)
+			assert.Equal(t, testCase.matches, testCase.filter.Matches(click))
+		})
+	}
+}
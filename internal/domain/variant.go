@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// MaxVariants limits the number of A/B destinations of a single alias
+	MaxVariants = 16
+	// MaxVariantWeight limits the weight of a single variant
+	MaxVariantWeight = 10000
+)
+
+// Variant is one of the weighted destinations of an A/B split alias
+type Variant struct {
+	Name   string
+	URL    *url.URL
+	Weight int
+}
+
+// Validate checks the variant
+func (v Variant) Validate() error {
+	if !ruleNamePattern.MatchString(v.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidVariant, ruleNamePattern)
+	}
+	if v.URL == nil {
+		return fmt.Errorf("%w: variant %s has no url", ErrInvalidVariant, v.Name)
+	}
+	if v.Weight <= 0 || v.Weight > MaxVariantWeight {
+		return fmt.Errorf("%w: variant %s weight must be in range 1..%d", ErrInvalidVariant, v.Name, MaxVariantWeight)
+	}
+	return nil
+}
+
+// ValidateVariants checks the destinations of an A/B split alias
+func ValidateVariants(variants []Variant) error {
+	if len(variants) == 1 {
+		return fmt.Errorf("%w: at least two variants are required", ErrInvalidVariant)
+	}
+	if len(variants) > MaxVariants {
+		return fmt.Errorf("%w: at most %d variants are allowed", ErrInvalidVariant, MaxVariants)
+	}
+	names := make(map[string]struct{}, len(variants))
+	for _, variant := range variants {
+		if err := variant.Validate(); err != nil {
+			return err
+		}
+		if _, exists := names[variant.Name]; exists {
+			return fmt.Errorf("%w: duplicate variant name %s", ErrInvalidVariant, variant.Name)
+		}
+		names[variant.Name] = struct{}{}
+	}
+	return nil
+}
+
+// AssignedVariant returns the variant with the given name, nil if the alias has no such variant
+func (a Alias) AssignedVariant(name string) *Variant {
+	for index := range a.Variants {
+		if a.Variants[index].Name == name {
+			return &a.Variants[index]
+		}
+	}
+	return nil
+}
+
+// PickVariant returns the variant the visitor has already been assigned to, or picks one at random
+// proportionally to the weights. random must return a number in range [0, n). Returns nil if the alias has no variants.
+func (a Alias) PickVariant(assigned string, random func(n int) int) *Variant {
+	if len(a.Variants) == 0 {
+		return nil
+	}
+	if variant := a.AssignedVariant(assigned); variant != nil {
+		return variant
+	}
+
+	total := 0
+	for index := range a.Variants {
+		total += a.Variants[index].Weight
+	}
+	if total <= 0 {
+		return &a.Variants[0]
+	}
+
+	point := random(total)
+	for index := range a.Variants {
+		if point < a.Variants[index].Weight {
+			return &a.Variants[index]
+		}
+		point -= a.Variants[index].Weight
+	}
+	return &a.Variants[len(a.Variants)-1]
+}
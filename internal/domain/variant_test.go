@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestValidateVariants(t *testing.T) {
+	t.Parallel()
+	target := &url.URL{Scheme: "https", Host: "example.com"}
+	testCases := []struct {
+		name      string
+		variants  []Variant
+		expectErr error
+	}{
+		{name: "no variants"},
+		{
+			name:     "valid variants",
+			variants: []Variant{{Name: "a", URL: target, Weight: 1}, {Name: "b", URL: target, Weight: 9}},
+		},
+		{
+			name:      "single variant",
+			variants:  []Variant{{Name: "a", URL: target, Weight: 1}},
+			expectErr: ErrInvalidVariant,
+		},
+		{
+			name:      "duplicate names",
+			variants:  []Variant{{Name: "a", URL: target, Weight: 1}, {Name: "a", URL: target, Weight: 1}},
+			expectErr: ErrInvalidVariant,
+		},
+		{
+			name:      "invalid name",
+			variants:  []Variant{{Name: "a.b", URL: target, Weight: 1}, {Name: "b", URL: target, Weight: 1}},
+			expectErr: ErrInvalidVariant,
+		},
+		{
+			name:      "zero weight",
+			variants:  []Variant{{Name: "a", URL: target}, {Name: "b", URL: target, Weight: 1}},
+			expectErr: ErrInvalidVariant,
+		},
+		{
+			name:      "no url",
+			variants:  []Variant{{Name: "a", Weight: 1}, {Name: "b", URL: target, Weight: 1}},
+			expectErr: ErrInvalidVariant,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorIs(t, ValidateVariants(testCase.variants), testCase.expectErr)
+		})
+	}
+}
+
+func TestAlias_PickVariant(t *testing.T) {
+	t.Parallel()
+	alias := Alias{Variants: []Variant{
+		{Name: "a", URL: &url.URL{Scheme: "https", Host: "a.test"}, Weight: 2},
+		{Name: "b", URL: &url.URL{Scheme: "https", Host: "b.test"}, Weight: 1},
+	}}
+	testCases := []struct {
+		name     string
+		alias    Alias
+		assigned string
+		point    int
+		expected string
+	}{
+		{name: "alias without variants", alias: Alias{}},
+		{name: "lower bound of the first variant", alias: alias, point: 0, expected: "a"},
+		{name: "upper bound of the first variant", alias: alias, point: 1, expected: "a"},
+		{name: "second variant", alias: alias, point: 2, expected: "b"},
+		{name: "assigned variant is kept", alias: alias, assigned: "b", point: 0, expected: "b"},
+		{name: "removed variant is reassigned", alias: alias, assigned: "c", point: 0, expected: "a"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			variant := testCase.alias.PickVariant(testCase.assigned, func(n int) int {
+				require.Equal(t, 3, n)
+				return testCase.point
+			})
+			if testCase.expected == "" {
+				require.Nil(t, variant)
+				return
+			}
+			require.Equal(t, testCase.expected, variant.Name)
+		})
+	}
+}
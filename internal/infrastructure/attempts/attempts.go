@@ -0,0 +1,90 @@
+// Package attempts limits the number of failed attempts of a client, e.g. password guesses
+package attempts
+
+import (
+	"sync"
+	"time"
+)
+
+type record struct {
+	failures int
+	resetAt  time.Time
+}
+
+// Limiter blocks a client after maxFailures failed attempts until the window started by its first attempt is over
+type Limiter struct {
+	mu          sync.Mutex
+	maxFailures int
+	window      time.Duration
+	clients     map[string]*record
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+// NewLimiter creates a new Limiter
+func NewLimiter(maxFailures int, window time.Duration) *Limiter {
+	return &Limiter{
+		maxFailures: maxFailures,
+		window:      window,
+		clients:     make(map[string]*record),
+		now:         time.Now,
+	}
+}
+
+// SetLimit changes the limit, the failures counted so far are kept
+func (l *Limiter) SetLimit(maxFailures int, window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxFailures = maxFailures
+	l.window = window
+}
+
+// Allow reports whether the client may make an attempt, otherwise returns how long it has to wait.
+// The allowed attempt is counted as failed up front, so the concurrent attempts of the client never exceed the limit:
+// Reset forgets the failures after a successful attempt and Refund takes back an attempt which was not made.
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	entry, ok := l.clients[client]
+	if !ok || !now.Before(entry.resetAt) {
+		entry = &record{resetAt: now.Add(l.window)}
+		l.clients[client] = entry
+	}
+	if entry.failures >= l.maxFailures {
+		return false, entry.resetAt.Sub(now)
+	}
+	entry.failures++
+	return true, 0
+}
+
+// Refund takes back an attempt allowed to the client which was not made, e.g. the request was malformed
+func (l *Limiter) Refund(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry, ok := l.clients[client]; ok && entry.failures > 0 {
+		entry.failures--
+	}
+}
+
+// Reset forgets the failed attempts of the client after a successful one
+func (l *Limiter) Reset(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, client)
+}
+
+// sweep drops the records of the finished windows, at most once per window
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for client, entry := range l.clients {
+		if !now.Before(entry.resetAt) {
+			delete(l.clients, client)
+		}
+	}
+}
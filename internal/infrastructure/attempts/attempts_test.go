@@ -0,0 +1,71 @@
+package attempts
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("client")
+	require.True(t, allowed)
+
+	now = now.Add(10 * time.Second)
+	allowed, _ = limiter.Allow("client")
+	require.True(t, allowed, "client is blocked only after reaching the limit")
+
+	allowed, retryAfter := limiter.Allow("client")
+	require.False(t, allowed)
+	require.Equal(t, 50*time.Second, retryAfter, "window starts at the first attempt")
+
+	allowed, _ = limiter.Allow("other")
+	require.True(t, allowed, "clients are limited separately")
+
+	now = now.Add(50 * time.Second)
+	allowed, _ = limiter.Allow("client")
+	require.True(t, allowed, "client is allowed after the window is over")
+
+	limiter.Refund("client")
+	allowed, _ = limiter.Allow("client")
+	require.True(t, allowed, "refunded attempt is not counted")
+	allowed, _ = limiter.Allow("client")
+	require.True(t, allowed)
+	allowed, _ = limiter.Allow("client")
+	require.False(t, allowed)
+
+	limiter.Reset("client")
+	allowed, _ = limiter.Allow("client")
+	require.True(t, allowed, "successful attempt resets the failures")
+}
+
+func TestLimiter_ConcurrentAttempts(t *testing.T) {
+	t.Parallel()
+	const maxFailures, clients = 5, 50
+	limiter := NewLimiter(maxFailures, time.Minute)
+
+	var allowed atomic.Int32
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if ok, _ := limiter.Allow("client"); ok {
+				allowed.Add(1)
+				// the password check takes a while, the failure is known only after it
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	require.Equal(t, int32(maxFailures), allowed.Load())
+}
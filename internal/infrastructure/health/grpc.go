@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+)
+
+// ServeGRPC keeps the serving status of the standard grpc.health.v1 server in sync with the readiness
+// of the application until ctx is done. The overall status and the status of every given service are updated.
+func (h *Health) ServeGRPC(ctx context.Context, server *health.Server, interval time.Duration, services ...string) {
+	update := func() {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !h.Readiness(ctx).IsReady() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		server.SetServingStatus("", status)
+		for _, service := range services {
+			server.SetServingStatus(service, status)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		update()
+		for {
+			select {
+			case <-ctx.Done():
+				server.Shutdown()
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusUnavailable Status = "unavailable"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+// Checker checks a single dependency of the application
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// ComponentStatus is a result of a single dependency check
+type ComponentStatus struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is an aggregated result of all dependency checks
+type Report struct {
+	Status     Status                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// IsReady reports whether every dependency is available
+func (r Report) IsReady() bool {
+	return r.Status == StatusOK
+}
+
+// Health aggregates pluggable dependency checkers
+type Health struct {
+	mu       sync.RWMutex
+	checkers []Checker
+	timeout  time.Duration
+}
+
+// New creates a new Health with the given checkers
+func New(checkers ...Checker) *Health {
+	return &Health{checkers: checkers, timeout: defaultCheckTimeout}
+}
+
+// Register adds a checker
+func (h *Health) Register(checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, checker)
+}
+
+// Readiness runs all checkers concurrently and aggregates their results
+func (h *Health) Readiness(ctx context.Context) Report {
+	h.mu.RLock()
+	checkers := make([]Checker, len(h.checkers))
+	copy(checkers, h.checkers)
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Components: make(map[string]ComponentStatus, len(checkers))}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, checker := range checkers {
+		wg.Add(1)
+		go func(checker Checker) {
+			defer wg.Done()
+			component := ComponentStatus{Status: StatusOK}
+			if err := checker.Check(ctx); err != nil {
+				component = ComponentStatus{Status: StatusUnavailable, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[checker.Name()] = component
+			if component.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}(checker)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewCheckerFunc creates a named checker from a function
+func NewCheckerFunc(name string, fn func(ctx context.Context) error) *CheckerFunc {
+	return &CheckerFunc{name: name, fn: fn}
+}
+
+func (c *CheckerFunc) Name() string {
+	return c.name
+}
+
+func (c *CheckerFunc) Check(ctx context.Context) error {
+	return c.fn(ctx)
+}
+
+var ErrHeartbeatStale = errors.New("heartbeat is stale")
+
+type heartbeatSource interface {
+	Heartbeat() time.Time
+}
+
+// HeartbeatChecker reports a component unavailable when its last heartbeat is older than maxAge
+type HeartbeatChecker struct {
+	name   string
+	source heartbeatSource
+	maxAge time.Duration
+}
+
+// NewHeartbeatChecker creates a new HeartbeatChecker
+func NewHeartbeatChecker(name string, source heartbeatSource, maxAge time.Duration) *HeartbeatChecker {
+	return &HeartbeatChecker{name: name, source: source, maxAge: maxAge}
+}
+
+func (c *HeartbeatChecker) Name() string {
+	return c.name
+}
+
+func (c *HeartbeatChecker) Check(ctx context.Context) error {
+	last := c.source.Heartbeat()
+	if last.IsZero() {
+		return fmt.Errorf("%w: no heartbeat received", ErrHeartbeatStale)
+	}
+	if age := time.Since(last); age > c.maxAge {
+		return fmt.Errorf("%w: last heartbeat %s ago", ErrHeartbeatStale, age.Truncate(time.Millisecond))
+	}
+	return nil
+}
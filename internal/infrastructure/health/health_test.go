@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testHeartbeat time.Time
+
+func (h testHeartbeat) Heartbeat() time.Time {
+	return time.Time(h)
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	t.Parallel()
+
+	alive := NewCheckerFunc("alive", func(ctx context.Context) error { return nil })
+	dead := NewCheckerFunc("dead", func(ctx context.Context) error { return assert.AnError })
+
+	testCases := []struct {
+		name       string
+		checkers   []Checker
+		wantStatus Status
+		wantFailed []string
+	}{
+		{
+			name:       "no checkers means ready",
+			wantStatus: StatusOK,
+		},
+		{
+			name:       "all checkers pass",
+			checkers:   []Checker{alive, NewHeartbeatChecker("consumer", testHeartbeat(time.Now()), time.Minute)},
+			wantStatus: StatusOK,
+		},
+		{
+			name:       "failed checker makes application unavailable",
+			checkers:   []Checker{alive, dead},
+			wantStatus: StatusUnavailable,
+			wantFailed: []string{"dead"},
+		},
+		{
+			name: "stale and missing heartbeats make application unavailable",
+			checkers: []Checker{
+				NewHeartbeatChecker("stale", testHeartbeat(time.Now().Add(-time.Hour)), time.Minute),
+				NewHeartbeatChecker("missing", testHeartbeat(time.Time{}), time.Minute),
+			},
+			wantStatus: StatusUnavailable,
+			wantFailed: []string{"stale", "missing"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			report := New(testCase.checkers...).Readiness(context.Background())
+			assert.Equal(t, testCase.wantStatus, report.Status)
+			assert.Len(t, report.Components, len(testCase.checkers))
+			for _, name := range testCase.wantFailed {
+				assert.Equal(t, StatusUnavailable, report.Components[name].Status)
+				assert.NotEmpty(t, report.Components[name].Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package health
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// MongoDBChecker pings the primary of the MongoDB deployment
+type MongoDBChecker struct {
+	client *mongo.Client
+}
+
+// NewMongoDBChecker creates a new MongoDBChecker
+func NewMongoDBChecker(client *mongo.Client) *MongoDBChecker {
+	return &MongoDBChecker{client: client}
+}
+
+func (c *MongoDBChecker) Name() string {
+	return "mongodb"
+}
+
+func (c *MongoDBChecker) Check(ctx context.Context) error {
+	return c.client.Ping(ctx, readpref.Primary())
+}
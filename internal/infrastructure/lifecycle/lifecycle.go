@@ -0,0 +1,129 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrShuttingDown = errors.New("application is shutting down")
+
+// Hook is a single shutdown step
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Manager runs registered shutdown steps in the order of registration
+type Manager struct {
+	mu           sync.Mutex
+	hooks        []namedHook
+	shuttingDown atomic.Bool
+}
+
+// New creates a new lifecycle Manager
+func New() *Manager {
+	return &Manager{}
+}
+
+// OnShutdown registers a shutdown step
+func (m *Manager) OnShutdown(name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, namedHook{name: name, hook: hook})
+}
+
+// Shutdown runs all shutdown steps. A failed step does not prevent the following ones from running.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	if m.shuttingDown.Swap(true) {
+		return ErrShuttingDown
+	}
+
+	m.mu.Lock()
+	hooks := make([]namedHook, len(m.hooks))
+	copy(hooks, m.hooks)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		zap.S().Infow("core", zap.String("state", fmt.Sprintf("stopping %s", h.name)))
+		if err := h.hook(ctx); err != nil {
+			zap.S().Errorw("core", zap.String("state", fmt.Sprintf("failed to stop %s", h.name)), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		zap.S().Infow("core", zap.String("state", fmt.Sprintf("%s stopped", h.name)))
+	}
+	return errors.Join(errs...)
+}
+
+// Name implements the health checker interface
+func (m *Manager) Name() string {
+	return "lifecycle"
+}
+
+// Check reports the application unavailable once the shutdown has begun
+func (m *Manager) Check(ctx context.Context) error {
+	if m.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// DrainReport tells how many pending events were processed and how many were lost on shutdown
+type DrainReport struct {
+	Queue   string
+	Flushed int
+	Dropped int
+}
+
+type drainableQueue interface {
+	Close()
+	Len() int
+	Dropped() int
+}
+
+type eventConsumer interface {
+	Wait(ctx context.Context) error
+}
+
+// NamedQueue is one of the queues read by a consumer
+type NamedQueue struct {
+	Name  string
+	Queue drainableQueue
+}
+
+// Drain closes the queue and waits until the consumer processes the pending events or ctx is done
+func Drain(ctx context.Context, name string, queue drainableQueue, consumer eventConsumer) DrainReport {
+	return DrainAll(ctx, consumer, NamedQueue{Name: name, Queue: queue})[0]
+}
+
+// DrainAll closes all queues read by the consumer and waits until it processes the pending events or ctx is done
+func DrainAll(ctx context.Context, consumer eventConsumer, queues ...NamedQueue) []DrainReport {
+	pending := make([]int, len(queues))
+	for index, queue := range queues {
+		pending[index] = queue.Queue.Len()
+		queue.Queue.Close()
+	}
+
+	err := consumer.Wait(ctx)
+
+	reports := make([]DrainReport, len(queues))
+	for index, queue := range queues {
+		left := 0
+		if err != nil {
+			left = queue.Queue.Len()
+		}
+		reports[index] = DrainReport{
+			Queue:   queue.Name,
+			Flushed: pending[index] - left,
+			Dropped: left + queue.Queue.Dropped(),
+		}
+	}
+	return reports
+}
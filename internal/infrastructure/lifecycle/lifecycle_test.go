@@ -0,0 +1,161 @@
+package lifecycle
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/infrastructure/squeue"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConsumer processes events from the queue, blocking on every event until released
+type testConsumer struct {
+	done    chan struct{}
+	release chan struct{}
+	// start, if set, holds the consumer back until it is waited for
+	start     chan struct{}
+	startOnce sync.Once
+}
+
+func newTestConsumer(queue *squeue.EventQueue, blocked bool) *testConsumer {
+	c := &testConsumer{done: make(chan struct{}), release: make(chan struct{})}
+	if !blocked {
+		close(c.release)
+	}
+	go func() {
+		defer close(c.done)
+		for range queue.Consume() {
+			<-c.release
+		}
+	}()
+	return c
+}
+
+// newIdleTestConsumer creates a consumer which reads nothing until it is waited for, so the events
+// produced before are still pending when the queue is drained
+func newIdleTestConsumer(queue *squeue.EventQueue) *testConsumer {
+	c := &testConsumer{done: make(chan struct{}), release: make(chan struct{}), start: make(chan struct{})}
+	close(c.release)
+	go func() {
+		defer close(c.done)
+		<-c.start
+		for range queue.Consume() {
+			<-c.release
+		}
+	}()
+	return c
+}
+
+func (c *testConsumer) Wait(ctx context.Context) error {
+	if c.start != nil {
+		c.startOnce.Do(func() { close(c.start) })
+	}
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pending events are flushed", func(t *testing.T) {
+		t.Parallel()
+		queue := squeue.NewWithCapacity(10)
+		consumer := newIdleTestConsumer(queue)
+		for i := 0; i < 5; i++ {
+			queue.Produce(i)
+		}
+		require.Equal(t, 5, queue.Len())
+
+		report := Drain(context.Background(), "test", queue, consumer)
+		assert.Equal(t, DrainReport{Queue: "test", Flushed: 5, Dropped: 0}, report)
+		assert.Equal(t, 0, queue.Len())
+
+		queue.Produce("late event")
+		assert.Equal(t, 1, queue.Dropped())
+	})
+
+	t.Run("pending events are dropped after deadline", func(t *testing.T) {
+		t.Parallel()
+		queue := squeue.NewWithCapacity(10)
+		consumer := newTestConsumer(queue, true)
+		for i := 0; i < 5; i++ {
+			queue.Produce(i)
+		}
+		require.Eventually(t, func() bool { return queue.Len() == 4 }, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		report := Drain(ctx, "test", queue, consumer)
+		assert.Equal(t, DrainReport{Queue: "test", Flushed: 0, Dropped: 4}, report)
+		close(consumer.release)
+	})
+}
+
+func TestDrainAll(t *testing.T) {
+	t.Parallel()
+	first := squeue.NewWithCapacity(10)
+	second := squeue.NewWithCapacity(10)
+	for i := 0; i < 3; i++ {
+		first.Produce(i)
+		second.Produce(i)
+	}
+
+	// the consumer stops only when both queues are closed and drained
+	consumer := &testConsumer{done: make(chan struct{})}
+	go func() {
+		defer close(consumer.done)
+		firstEvents, secondEvents := first.Consume(), second.Consume()
+		for firstEvents != nil || secondEvents != nil {
+			select {
+			case _, ok := <-firstEvents:
+				if !ok {
+					firstEvents = nil
+				}
+			case _, ok := <-secondEvents:
+				if !ok {
+					secondEvents = nil
+				}
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reports := DrainAll(ctx, consumer, NamedQueue{Name: "first", Queue: first}, NamedQueue{Name: "second", Queue: second})
+	require.Len(t, reports, 2)
+	for index, name := range []string{"first", "second"} {
+		assert.Equal(t, name, reports[index].Queue)
+		assert.Equal(t, 0, reports[index].Dropped)
+	}
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	manager := New()
+	manager.OnShutdown("first", func(ctx context.Context) error {
+		order = append(order, "first")
+		return assert.AnError
+	})
+	manager.OnShutdown("second", func(ctx context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+
+	require.NoError(t, manager.Check(context.Background()))
+
+	err := manager.Shutdown(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.ErrorIs(t, manager.Check(context.Background()), ErrShuttingDown)
+	assert.ErrorIs(t, manager.Shutdown(context.Background()), ErrShuttingDown)
+}
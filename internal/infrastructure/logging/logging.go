@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"regexp"
+)
+
+const (
+	// HeaderRequestID is the HTTP header carrying the request ID
+	HeaderRequestID = "X-Request-ID"
+	// MetadataRequestID is the gRPC metadata key carrying the request ID
+	MetadataRequestID = "x-request-id"
+)
+
+const requestIDLength = 16
+
+// incoming request IDs are accepted only if they are safe to be logged and echoed back
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	loggerKey
+)
+
+// NewRequestID generates a new random request ID
+func NewRequestID() string {
+	b := make([]byte, requestIDLength)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// EnsureRequestID returns the incoming request ID if it is valid, or a newly generated one
+func EnsureRequestID(incoming string) string {
+	if validRequestID.MatchString(incoming) {
+		return incoming
+	}
+	return NewRequestID()
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID and a logger annotated with it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return WithLogger(ctx, zap.S().With(zap.String("request_id", requestID)))
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithLogger returns a copy of ctx carrying the logger
+func WithLogger(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger carried by ctx, falling back to the global one.
+// The returned logger is annotated with the trace ID of the current span, if any.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	logger, ok := ctx.Value(loggerKey).(*zap.SugaredLogger)
+	if !ok {
+		logger = zap.S()
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With(zap.String("trace_id", spanContext.TraceID().String()))
+	}
+	return logger
+}
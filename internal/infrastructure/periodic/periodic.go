@@ -0,0 +1,86 @@
+// Package periodic runs background jobs at a fixed interval
+package periodic
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Task is a single run of the job, a failed run is logged and retried at the next tick
+type Task func(ctx context.Context) error
+
+// Locker grants named leases shared by the replicas of the service
+type Locker interface {
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+}
+
+// Exclusive runs the task only while holding the lease, so that a single replica runs it at a time.
+// The holder extends the lease on every run, if it stops another replica takes the lease over once it expires.
+func Exclusive(locker Locker, name string, lease time.Duration, task Task) Task {
+	return func(ctx context.Context) error {
+		acquired, err := locker.Acquire(ctx, name, lease)
+		if err != nil {
+			return fmt.Errorf("lock %s: %w", name, err)
+		}
+		if !acquired {
+			return nil
+		}
+		return task(ctx)
+	}
+}
+
+// Job runs the task at every tick of the interval until stopped
+type Job struct {
+	name     string
+	interval time.Duration
+	task     Task
+	done     chan struct{}
+}
+
+// New creates a new job, it does nothing until started
+func New(name string, interval time.Duration, task Task) *Job {
+	return &Job{name: name, interval: interval, task: task, done: make(chan struct{})}
+}
+
+// Name returns the name of the job
+func (j *Job) Name() string {
+	return j.name
+}
+
+// Start runs the task once at once and then at every tick, until ctx is done
+func (j *Job) Start(ctx context.Context) {
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.run(ctx)
+			}
+		}
+	}()
+}
+
+// Wait waits until the started job stops or ctx is done
+func (j *Job) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *Job) run(ctx context.Context) {
+	if err := j.task(ctx); err != nil && ctx.Err() == nil {
+		zap.S().Errorw("job", zap.String("name", j.name), zap.Error(err))
+	}
+}
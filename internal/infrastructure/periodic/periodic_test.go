@@ -0,0 +1,74 @@
+package periodic
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJob(t *testing.T) {
+	t.Parallel()
+
+	t.Run("task runs at start and at every tick", func(t *testing.T) {
+		t.Parallel()
+		var runs atomic.Int32
+		job := New("test", 10*time.Millisecond, func(ctx context.Context) error {
+			runs.Add(1)
+			return assert.AnError
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Start(ctx)
+		assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+
+		cancel()
+		require.NoError(t, job.Wait(context.Background()))
+	})
+
+	t.Run("wait gives up when ctx is done", func(t *testing.T) {
+		t.Parallel()
+		job := New("test", time.Hour, func(ctx context.Context) error { return nil })
+		job.Start(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, job.Wait(ctx), context.DeadlineExceeded)
+	})
+}
+
+type testLocker struct {
+	acquired bool
+	err      error
+}
+
+func (l testLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return l.acquired, l.err
+}
+
+func TestExclusive(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		locker    testLocker
+		runs      int32
+		expectErr error
+	}{
+		{name: "lease holder runs the task", locker: testLocker{acquired: true}, runs: 1},
+		{name: "lease held by another replica", locker: testLocker{acquired: false}, runs: 0},
+		{name: "lock failure", locker: testLocker{err: assert.AnError}, runs: 0, expectErr: assert.AnError},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			var runs atomic.Int32
+			task := Exclusive(testCase.locker, "test", time.Minute, func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			})
+			assert.ErrorIs(t, task(context.Background()), testCase.expectErr)
+			assert.Equal(t, testCase.runs, runs.Load())
+		})
+	}
+}
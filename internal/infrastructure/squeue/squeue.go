@@ -1,17 +1,86 @@
 package squeue
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 type EventQueue struct {
-	queue chan any
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	done      chan struct{}
+	queue     chan any
+	dropped   atomic.Int64
 }
 
 func New() *EventQueue {
-	return &EventQueue{queue: make(chan any)}
+	return NewWithCapacity(0)
+}
+
+// NewWithCapacity creates a queue buffering up to capacity events
+func NewWithCapacity(capacity int) *EventQueue {
+	return &EventQueue{queue: make(chan any, capacity), done: make(chan struct{})}
 }
 
+// Produce publishes an event. Events produced after the queue has been closed are dropped.
 func (q *EventQueue) Produce(data any) {
-	q.queue <- data
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		q.dropped.Add(1)
+		return
+	}
+
+	select {
+	case q.queue <- data:
+	case <-q.done:
+		q.dropped.Add(1)
+	}
 }
 
 func (q *EventQueue) Consume() chan any {
 	return q.queue
 }
+
+// Close stops accepting new events. Events already in the queue are still delivered to the consumer.
+func (q *EventQueue) Close() {
+	q.closeOnce.Do(func() {
+		// release producers waiting for the consumer before taking the write lock
+		close(q.done)
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.closed = true
+		close(q.queue)
+	})
+}
+
+// Len returns the number of events waiting in the queue
+func (q *EventQueue) Len() int {
+	return len(q.queue)
+}
+
+// Dropped returns the number of events dropped because the queue was closed
+func (q *EventQueue) Dropped() int {
+	return int(q.dropped.Load())
+}
+
+type producer interface {
+	Produce(data any)
+}
+
+// Fanout publishes every event to all of its queues
+type Fanout []producer
+
+// NewFanout creates a producer publishing to all the given queues
+func NewFanout(queues ...producer) Fanout {
+	return queues
+}
+
+// Produce publishes the event to every queue in order
+func (f Fanout) Produce(data any) {
+	for _, queue := range f {
+		queue.Produce(data)
+	}
+}
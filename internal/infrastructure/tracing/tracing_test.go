@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestEventTraceContextPropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewTracerProvider(exporter, "alias-test", 1)
+	defer provider.Shutdown(context.Background())
+
+	ctx, parent := Start(context.Background(), "request")
+	carried := Inject(ctx)
+	parent.End()
+
+	assert.Contains(t, carried, "traceparent")
+
+	_, child := Start(Extract(context.Background(), carried), "processEvent")
+	child.End()
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent.SpanID())
+}
+
+func TestNewTracerProvider_NoExporter(t *testing.T) {
+	provider := NewTracerProvider(nil, "alias-test", 1)
+	assert.Nil(t, provider)
+
+	_, span := Start(context.Background(), "noop")
+	assert.False(t, span.SpanContext().IsValid())
+	assert.Equal(t, trace.SpanContext{}, span.SpanContext())
+}
+
+func TestNewExporter_UnknownType(t *testing.T) {
+	_, err := NewExporter(context.Background(), "zipkin", "")
+	assert.ErrorIs(t, err, ErrUnknownExporterType)
+}
@@ -3,8 +3,13 @@ package inmemory
 import (
 	"context"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
 	"go.uber.org/zap"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 )
 
 type AliasRepository struct {
@@ -19,9 +24,11 @@ func (a *AliasRepository) Name() string {
 // Save saves many aliases in one run
 func (a *AliasRepository) Save(ctx context.Context, aliases []domain.Alias) error {
 	const fn = "Save"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	zap.S().Infow("repo",
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.Int("alias count", len(aliases)))
@@ -34,50 +41,221 @@ func (a *AliasRepository) Save(ctx context.Context, aliases []domain.Alias) erro
 // Find gets the target link from the shortened one
 func (a *AliasRepository) Find(ctx context.Context, key string) (*domain.Alias, error) {
 	const fn = "Find"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
 
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	if presented, ok := a.db[key]; ok {
+	return a.find(key)
+}
+
+// find returns the alias unless it is in the trash, the caller must hold the lock
+func (a *AliasRepository) find(key string) (*domain.Alias, error) {
+	if presented, ok := a.db[key]; ok && presented.IsActive {
 		return presented, nil
-	} else {
-		return nil, domain.ErrAliasNotFound
 	}
+	return nil, domain.ErrAliasNotFound
+}
+
+// ExistingKeys returns the given keys taken by the stored aliases, including the ones in the trash
+func (a *AliasRepository) ExistingKeys(ctx context.Context, keys []string) ([]string, error) {
+	const fn = "ExistingKeys"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.Int("keys count", len(keys)))
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	existing := make([]string, 0)
+	for _, key := range keys {
+		if _, ok := a.db[key]; ok {
+			existing = append(existing, key)
+		}
+	}
+	return existing, nil
+}
+
+// List returns the aliases matching the filter ordered by key, at most filter.Limit of them
+func (a *AliasRepository) List(ctx context.Context, filter domain.ListFilter, now time.Time) ([]domain.Alias, error) {
+	const fn = "List"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("status", string(filter.Status)),
+		zap.String("campaign", filter.Campaign),
+		zap.String("after", filter.After),
+		zap.Int("limit", filter.Limit))
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	aliases := make([]domain.Alias, 0)
+	for key, alias := range a.db {
+		if key > filter.After && filter.Matches(*alias, now) {
+			aliases = append(aliases, *alias)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Key < aliases[j].Key
+	})
+	if len(aliases) > filter.Limit {
+		aliases = aliases[:filter.Limit]
+	}
+	return aliases, nil
+}
+
+// Scan returns the aliases with keys greater than after ordered by key, at most limit of them.
+// Unlike List it returns the aliases in the trash along with the others.
+func (a *AliasRepository) Scan(ctx context.Context, after string, limit int) ([]domain.Alias, error) {
+	const fn = "Scan"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("after", after),
+		zap.Int("limit", limit))
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	aliases := make([]domain.Alias, 0)
+	for key, alias := range a.db {
+		if key > after {
+			aliases = append(aliases, *alias)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Key < aliases[j].Key
+	})
+	if len(aliases) > limit {
+		aliases = aliases[:limit]
+	}
+	return aliases, nil
 }
 
-// Remove removes a shortened link
-func (a *AliasRepository) Remove(ctx context.Context, key string) error {
+// Remove moves a shortened link to the trash
+func (a *AliasRepository) Remove(ctx context.Context, key string, deletedAt time.Time) error {
 	const fn = "Remove"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	if _, ok := a.db[key]; ok {
-		delete(a.db, key)
-	} else {
+	presented, err := a.find(key)
+	if err != nil {
+		return err
+	}
+	updated := *presented
+	updated.IsActive = false
+	updated.DeletedAt = deletedAt
+	a.db[key] = &updated
+	return nil
+}
+
+// Restore takes a shortened link back from the trash
+func (a *AliasRepository) Restore(ctx context.Context, key string) error {
+	const fn = "Restore"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	presented, ok := a.db[key]
+	if !ok || presented.IsActive {
 		return domain.ErrAliasNotFound
 	}
+	updated := *presented
+	updated.IsActive = true
+	updated.DeletedAt = time.Time{}
+	a.db[key] = &updated
 	return nil
 }
 
+// Purge deletes the aliases moved to the trash before the given time, returns the number of deleted aliases
+func (a *AliasRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const fn = "Purge"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.Time("deleted before", deletedBefore))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var purged int64
+	for key, alias := range a.db {
+		if !alias.IsActive && alias.DeletedAt.Before(deletedBefore) {
+			delete(a.db, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Expire deactivates at most limit of the expired aliases not deactivated yet, ordered by key, and returns them.
+// The deactivated aliases stay out of the trash and keep their expired status.
+func (a *AliasRepository) Expire(ctx context.Context, now time.Time, limit int) ([]domain.Alias, error) {
+	const fn = "Expire"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.Time("now", now),
+		zap.Int("limit", limit))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	keys := make([]string, 0)
+	for key, alias := range a.db {
+		if alias.IsActive && !alias.Disabled && alias.ExpiredAt.IsZero() && alias.IsExpired(now) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	expired := make([]domain.Alias, 0, len(keys))
+	for _, key := range keys {
+		updated := *a.db[key]
+		updated.ExpiredAt = now
+		a.db[key] = &updated
+		expired = append(expired, updated)
+	}
+	return expired, nil
+}
+
 func (a *AliasRepository) DecreaseTTLCounter(ctx context.Context, key string) error {
 	const fn = "DecreaseTTLCounter"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.String("id", key))
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if _, ok := a.db[key]; !ok {
-		return domain.ErrAliasNotFound
+	alias, err := a.find(key)
+	if err != nil {
+		return err
 	}
-	if a.db[key].Params.TriesLeft == 0 {
+	// the alias deactivated by the reaper is not followed even if it has uses left
+	if alias.Params.TriesLeft == 0 || !alias.ExpiredAt.IsZero() {
 		return domain.ErrAliasExpired
 	}
 	// decrease TTL counter
@@ -91,3 +269,94 @@ func NewAliasRepository() *AliasRepository {
 		mu: sync.RWMutex{},
 	}
 }
+
+// UpdateRules replaces the redirect rules of the alias
+func (a *AliasRepository) UpdateRules(ctx context.Context, key string, rules []domain.RedirectRule) error {
+	const fn = "UpdateRules"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	presented, err := a.find(key)
+	if err != nil {
+		return err
+	}
+	// replace the stored alias instead of modifying it, it may be in use by concurrent readers
+	updated := *presented
+	updated.Rules = slices.Clone(rules)
+	a.db[key] = &updated
+	return nil
+}
+
+// UpdateVariants replaces the A/B variants of the alias
+func (a *AliasRepository) UpdateVariants(ctx context.Context, key string, variants []domain.Variant) error {
+	const fn = "UpdateVariants"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	presented, err := a.find(key)
+	if err != nil {
+		return err
+	}
+	updated := *presented
+	updated.Variants = slices.Clone(variants)
+	a.db[key] = &updated
+	return nil
+}
+
+// UpdateDisabled disables the alias or enables it again
+func (a *AliasRepository) UpdateDisabled(ctx context.Context, key string, disabled bool) error {
+	const fn = "UpdateDisabled"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Bool("disabled", disabled))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	presented, err := a.find(key)
+	if err != nil {
+		return err
+	}
+	updated := *presented
+	updated.Disabled = disabled
+	a.db[key] = &updated
+	return nil
+}
+
+// UpdateActivation sets the time the alias starts redirecting at, zero time activates it immediately
+func (a *AliasRepository) UpdateActivation(ctx context.Context, key string, activatesAt time.Time) error {
+	const fn = "UpdateActivation"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Time("activates_at", activatesAt))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	presented, err := a.find(key)
+	if err != nil {
+		return err
+	}
+	updated := *presented
+	updated.ActivatesAt = activatesAt
+	a.db[key] = &updated
+	return nil
+}
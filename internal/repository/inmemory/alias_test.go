@@ -0,0 +1,56 @@
+package inmemory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/domain"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAliasRepository_Expire(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	target := &url.URL{Scheme: "https", Host: "example.com"}
+	repo := NewAliasRepository()
+	require.NoError(t, repo.Save(ctx, []domain.Alias{
+		{Key: "exhausted", URL: target, IsActive: true, Params: domain.TTLParams{TriesLeft: 0}},
+		{Key: "live", URL: target, IsActive: true, Params: domain.TTLParams{TriesLeft: 3}},
+		{Key: "outdated", URL: target, IsActive: true, Params: domain.TTLParams{TriesLeft: 3}, ExpiresAt: now},
+		{Key: "removed", URL: target, Params: domain.TTLParams{TriesLeft: 0}, DeletedAt: now},
+	}))
+
+	expired, err := repo.Expire(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 2)
+	assert.Equal(t, "exhausted", expired[0].Key)
+	assert.Equal(t, "outdated", expired[1].Key)
+	expired, err = repo.Expire(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// the deactivated alias stays out of the trash, is listed as expired and cannot be followed anymore
+	alias, err := repo.Find(ctx, "outdated")
+	require.NoError(t, err)
+	assert.Equal(t, now, alias.ExpiredAt)
+	assert.Equal(t, domain.StatusExpired, alias.Status(now.Add(-time.Hour)))
+	assert.ErrorIs(t, repo.DecreaseTTLCounter(ctx, "outdated"), domain.ErrAliasExpired)
+	assert.NoError(t, repo.DecreaseTTLCounter(ctx, "live"))
+
+	for status, keys := range map[domain.AliasStatus][]string{
+		domain.StatusActive:  {"live"},
+		domain.StatusExpired: {"exhausted", "outdated"},
+		domain.StatusDeleted: {"removed"},
+	} {
+		listed, err := repo.List(ctx, domain.ListFilter{Status: status, Limit: 10}, now.Add(-time.Hour))
+		require.NoError(t, err)
+		listedKeys := make([]string, 0, len(listed))
+		for _, alias := range listed {
+			listedKeys = append(listedKeys, alias.Key)
+		}
+		assert.Equal(t, keys, listedKeys, status)
+	}
+}
@@ -0,0 +1,108 @@
+package inmemory
+
+import (
+	"context"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.uber.org/zap"
+	"maps"
+	"sort"
+	"sync"
+)
+
+type CampaignRepository struct {
+	mu sync.RWMutex
+	db map[string]domain.Campaign
+}
+
+// NewCampaignRepository creates a new CampaignRepository
+func NewCampaignRepository() *CampaignRepository {
+	return &CampaignRepository{db: make(map[string]domain.Campaign)}
+}
+
+func (r *CampaignRepository) Name() string {
+	return "in-memory::CampaignRepository"
+}
+
+// Save saves a new campaign
+func (r *CampaignRepository) Save(ctx context.Context, campaign domain.Campaign) error {
+	const fn = "Save"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", campaign.Name))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.db[campaign.Name]; ok {
+		return domain.ErrCampaignAlreadyExists
+	}
+	campaign.ID = campaign.Name
+	campaign.Params = maps.Clone(campaign.Params)
+	r.db[campaign.Name] = campaign
+	return nil
+}
+
+// Find gets the campaign by name
+func (r *CampaignRepository) Find(ctx context.Context, name string) (*domain.Campaign, error) {
+	const fn = "Find"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", name))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	campaign, ok := r.db[name]
+	if !ok {
+		return nil, domain.ErrCampaignNotFound
+	}
+	campaign.Params = maps.Clone(campaign.Params)
+	return &campaign, nil
+}
+
+// List returns all campaigns ordered by name
+func (r *CampaignRepository) List(ctx context.Context) ([]domain.Campaign, error) {
+	const fn = "List"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	campaigns := make([]domain.Campaign, 0, len(r.db))
+	for _, campaign := range r.db {
+		campaign.Params = maps.Clone(campaign.Params)
+		campaigns = append(campaigns, campaign)
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].Name < campaigns[j].Name
+	})
+	return campaigns, nil
+}
+
+// Remove removes the campaign
+func (r *CampaignRepository) Remove(ctx context.Context, name string) error {
+	const fn = "Remove"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", name))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.db[name]; !ok {
+		return domain.ErrCampaignNotFound
+	}
+	delete(r.db, name)
+	return nil
+}
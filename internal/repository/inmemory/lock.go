@@ -0,0 +1,28 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+)
+
+// LockRepository grants every lease, the in-memory storage is never shared by several replicas
+type LockRepository struct{}
+
+// NewLockRepository creates a new LockRepository
+func NewLockRepository() *LockRepository {
+	return &LockRepository{}
+}
+
+func (r *LockRepository) Name() string {
+	return "in-memory::LockRepository"
+}
+
+// Acquire always takes the lease
+func (r *LockRepository) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Release does nothing
+func (r *LockRepository) Release(ctx context.Context, name string) error {
+	return nil
+}
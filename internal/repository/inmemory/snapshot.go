@@ -0,0 +1,111 @@
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"github.com/xloki21/alias/internal/domain"
+	"maps"
+	"math"
+	"os"
+	"sort"
+)
+
+// Snapshot is the in-memory storage saved to a file, so that its data outlives the server process
+// and can be migrated to another storage
+type Snapshot struct {
+	Aliases   *AliasRepository
+	Campaigns *CampaignRepository
+	Stats     *StatisticsRepository
+}
+
+// snapshotFile is the content of the snapshot file, the URLs are kept by gob in their binary form
+type snapshotFile struct {
+	Aliases     []domain.Alias
+	Campaigns   []domain.Campaign
+	Counters    []domain.ClickStats
+	Clicks      []domain.Click
+	Expirations []domain.Expiration
+}
+
+// Save writes the data of the repositories to the file at path, replacing the file at once
+func (s Snapshot) Save(ctx context.Context, path string) error {
+	var file snapshotFile
+	var err error
+	if file.Aliases, err = s.Aliases.Scan(ctx, "", math.MaxInt); err != nil {
+		return err
+	}
+	if file.Campaigns, err = s.Campaigns.List(ctx); err != nil {
+		return err
+	}
+	file.Counters = s.Stats.counters()
+	if file.Clicks, err = s.Stats.ListClicks(ctx, domain.ClickFilter{Limit: math.MaxInt}); err != nil {
+		return err
+	}
+	if file.Expirations, err = s.Stats.ListExpirations(ctx, "", math.MaxInt); err != nil {
+		return err
+	}
+
+	content := bytes.Buffer{}
+	if err := gob.NewEncoder(&content).Encode(file); err != nil {
+		return fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, content.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// Load adds the data of the snapshot file at path to the repositories, the error wraps os.ErrNotExist
+// if there is no such file. The clicks get the IDs of the log of the repository.
+func (s Snapshot) Load(ctx context.Context, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file snapshotFile
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&file); err != nil {
+		return fmt.Errorf("snapshot %s: %w", path, err)
+	}
+
+	if err := s.Aliases.Save(ctx, file.Aliases); err != nil {
+		return err
+	}
+	for _, campaign := range file.Campaigns {
+		if err := s.Campaigns.Save(ctx, campaign); err != nil {
+			return err
+		}
+	}
+	for _, counters := range file.Counters {
+		if err := s.Stats.SetClicks(ctx, counters); err != nil {
+			return err
+		}
+	}
+	if err := s.Stats.AppendClicks(ctx, file.Clicks); err != nil {
+		return err
+	}
+	return s.Stats.AppendExpirations(ctx, file.Expirations)
+}
+
+// counters returns the click counters of all the aliases ordered by key
+func (r *StatisticsRepository) counters() []domain.ClickStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counters := make([]domain.ClickStats, 0, len(r.clicks))
+	for key, stat := range r.clicks {
+		counters = append(counters, domain.ClickStats{
+			Key:         key,
+			Total:       stat.Total,
+			Rules:       maps.Clone(stat.Rules),
+			Variants:    maps.Clone(stat.Variants),
+			LastClickAt: stat.LastClickAt,
+		})
+	}
+	sort.Slice(counters, func(i, j int) bool {
+		return counters[i].Key < counters[j].Key
+	})
+	return counters
+}
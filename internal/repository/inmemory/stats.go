@@ -2,28 +2,50 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
 	"go.uber.org/zap"
+	"maps"
 	"net/url"
+	"sort"
 	"sync"
 	"time"
 )
 
+// maxClickLog is the number of the clicks kept in the log, the oldest ones are dropped beyond it
+const maxClickLog = 100_000
+
 type eventStat struct {
 	OccurredAt time.Time
 	Key        string
 	URL        *url.URL
+	Campaign   string
+}
+
+type clickStat struct {
+	Total       int
+	Rules       map[string]int
+	Variants    map[string]int
+	LastClickAt time.Time
 }
 
 type StatisticsRepository struct {
-	db map[string]eventStat
-	mu sync.RWMutex
+	db     map[string]eventStat
+	clicks map[string]*clickStat
+	// log keeps the last maxClickLog clicks in the order of recording
+	log []domain.Click
+	// recorded is the number of the clicks ever recorded, the ID of the last one
+	recorded uint64
+	mu       sync.RWMutex
 }
 
 // NewStatisticsRepository creates a new StatisticsRepository
 func NewStatisticsRepository() *StatisticsRepository {
 	return &StatisticsRepository{
-		db: make(map[string]eventStat),
+		db:     make(map[string]eventStat),
+		clicks: make(map[string]*clickStat),
 	}
 }
 
@@ -34,7 +56,9 @@ func (r *StatisticsRepository) Name() string {
 // PushStats pushes data with statistics into collection
 func (r *StatisticsRepository) PushStats(ctx context.Context, event domain.AliasExpired) error {
 	const fn = "PushStats"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", r.Name()),
 		zap.String("fn", fn),
 		zap.String("event", event.String()),
@@ -46,6 +70,247 @@ func (r *StatisticsRepository) PushStats(ctx context.Context, event domain.Alias
 		OccurredAt: event.OccurredAt,
 		Key:        event.Key,
 		URL:        event.URL,
+		Campaign:   event.Campaign,
+	}
+	return nil
+}
+
+// RecordClick increments the click counters of the alias, per matched rule and A/B variant as well
+func (r *StatisticsRepository) RecordClick(ctx context.Context, event domain.AliasUsed) error {
+	const fn = "RecordClick"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("event", event.String()),
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stat, ok := r.clicks[event.Key]
+	if !ok {
+		stat = &clickStat{Rules: make(map[string]int), Variants: make(map[string]int)}
+		r.clicks[event.Key] = stat
+	}
+	stat.Total++
+	if event.Rule != "" {
+		stat.Rules[event.Rule]++
+	}
+	if event.Variant != "" {
+		stat.Variants[event.Variant]++
+	}
+	if event.OccurredAt.After(stat.LastClickAt) {
+		stat.LastClickAt = event.OccurredAt
+	}
+	r.appendClick(domain.Click{
+		Key:        event.Key,
+		Campaign:   event.Campaign,
+		Rule:       event.Rule,
+		Variant:    event.Variant,
+		OccurredAt: event.OccurredAt,
+	})
+	return nil
+}
+
+// appendClick adds the click to the end of the log with the next ID, dropping the oldest click if the log is full.
+// The caller must hold the lock.
+func (r *StatisticsRepository) appendClick(click domain.Click) {
+	r.recorded++
+	// zero-padded, so that the IDs are ordered as strings
+	click.ID = fmt.Sprintf("%016x", r.recorded)
+	if len(r.log) == maxClickLog {
+		// the dropped click is cleared, so that the backing array does not keep it
+		r.log[0] = domain.Click{}
+		r.log = r.log[1:]
+	}
+	r.log = append(r.log, click)
+}
+
+// ListClicks returns the clicks matching the filter ordered by ID, at most filter.Limit of them
+func (r *StatisticsRepository) ListClicks(ctx context.Context, filter domain.ClickFilter) ([]domain.Click, error) {
+	const fn = "ListClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("key", filter.Key),
+		zap.String("campaign", filter.Campaign),
+		zap.String("after", filter.After),
+		zap.Int("limit", filter.Limit),
+	)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// the log is ordered by ID, so the page starts right after the cursor
+	start := sort.Search(len(r.log), func(i int) bool {
+		return r.log[i].ID > filter.After
+	})
+	clicks := make([]domain.Click, 0)
+	for _, click := range r.log[start:] {
+		if len(clicks) == filter.Limit {
+			break
+		}
+		if filter.Matches(click) {
+			clicks = append(clicks, click)
+		}
+	}
+	return clicks, nil
+}
+
+// PurgeClicks deletes the clicks of the log occurred before the given time, the click counters are kept.
+// It returns the number of the deleted clicks.
+func (r *StatisticsRepository) PurgeClicks(ctx context.Context, occurredBefore time.Time) (int64, error) {
+	const fn = "PurgeClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.Time("occurred before", occurredBefore),
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := make([]domain.Click, 0, len(r.log))
+	for _, click := range r.log {
+		if !click.OccurredAt.Before(occurredBefore) {
+			kept = append(kept, click)
+		}
+	}
+	purged := int64(len(r.log) - len(kept))
+	r.log = kept
+	return purged, nil
+}
+
+// Clicks returns the click counters of the alias, zero counters if it has never been clicked
+func (r *StatisticsRepository) Clicks(ctx context.Context, key string) (*domain.ClickStats, error) {
+	const fn = "Clicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+	)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &domain.ClickStats{Key: key, Rules: make(map[string]int), Variants: make(map[string]int)}
+	if stat, ok := r.clicks[key]; ok {
+		stats.Total = stat.Total
+		stats.Rules = maps.Clone(stat.Rules)
+		stats.Variants = maps.Clone(stat.Variants)
+		stats.LastClickAt = stat.LastClickAt
+	}
+	return stats, nil
+}
+
+// SetClicks replaces the click counters of the alias
+func (r *StatisticsRepository) SetClicks(ctx context.Context, stats domain.ClickStats) error {
+	const fn = "SetClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("key", stats.Key),
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stat := &clickStat{
+		Total:       stats.Total,
+		Rules:       maps.Clone(stats.Rules),
+		Variants:    maps.Clone(stats.Variants),
+		LastClickAt: stats.LastClickAt,
+	}
+	if stat.Rules == nil {
+		stat.Rules = make(map[string]int)
+	}
+	if stat.Variants == nil {
+		stat.Variants = make(map[string]int)
+	}
+	r.clicks[stats.Key] = stat
+	return nil
+}
+
+// AppendClicks adds the clicks to the end of the log, the clicks get the IDs of the log
+func (r *StatisticsRepository) AppendClicks(ctx context.Context, clicks []domain.Click) error {
+	const fn = "AppendClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.Int("clicks count", len(clicks)),
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, click := range clicks {
+		r.appendClick(click)
+	}
+	return nil
+}
+
+// ListExpirations returns the expiration records with IDs greater than after ordered by ID, at most limit of them.
+// Only the last expiration of an alias is kept, the key of the alias is the ID of the record.
+func (r *StatisticsRepository) ListExpirations(ctx context.Context, after string, limit int) ([]domain.Expiration, error) {
+	const fn = "ListExpirations"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("after", after),
+		zap.Int("limit", limit),
+	)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expirations := make([]domain.Expiration, 0)
+	for key, event := range r.db {
+		if key > after {
+			expirations = append(expirations, domain.Expiration{
+				ID:         key,
+				Key:        event.Key,
+				URL:        event.URL,
+				Campaign:   event.Campaign,
+				OccurredAt: event.OccurredAt,
+			})
+		}
+	}
+	sort.Slice(expirations, func(i, j int) bool {
+		return expirations[i].ID < expirations[j].ID
+	})
+	if len(expirations) > limit {
+		expirations = expirations[:limit]
+	}
+	return expirations, nil
+}
+
+// AppendExpirations adds the expiration records, replacing the kept expirations of the same aliases
+func (r *StatisticsRepository) AppendExpirations(ctx context.Context, expirations []domain.Expiration) error {
+	const fn = "AppendExpirations"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.Int("expirations count", len(expirations)),
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, expiration := range expirations {
+		r.db[expiration.Key] = eventStat{
+			OccurredAt: expiration.OccurredAt,
+			Key:        expiration.Key,
+			URL:        expiration.URL,
+			Campaign:   expiration.Campaign,
+		}
 	}
 	return nil
 }
@@ -0,0 +1,61 @@
+package inmemory
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestStatisticsRepository_ClickLog(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewStatisticsRepository()
+	for index, key := range []string{"a", "b", "a"} {
+		event := domain.AliasUsed{Alias: domain.Alias{Key: key}, OccurredAt: noon.Add(time.Duration(index) * time.Hour)}
+		require.NoError(t, repo.RecordClick(ctx, event))
+	}
+
+	first, err := repo.ListClicks(ctx, domain.ClickFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	next, err := repo.ListClicks(ctx, domain.ClickFilter{After: first[1].ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+	assert.Equal(t, noon.Add(2*time.Hour), next[0].OccurredAt)
+
+	purged, err := repo.PurgeClicks(ctx, noon.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, purged)
+	rest, err := repo.ListClicks(ctx, domain.ClickFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, next, rest)
+	stats, err := repo.Clicks(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+}
+
+func TestStatisticsRepository_ClickLogIsBounded(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := NewStatisticsRepository()
+	clicks := make([]domain.Click, maxClickLog+10)
+	for index := range clicks {
+		clicks[index] = domain.Click{Key: "a"}
+	}
+	require.NoError(t, repo.AppendClicks(ctx, clicks))
+	assert.Len(t, repo.log, maxClickLog)
+
+	// the oldest clicks are dropped, the IDs keep growing
+	first, err := repo.ListClicks(ctx, domain.ClickFilter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "000000000000000b", first[0].ID)
+	last, err := repo.ListClicks(ctx, domain.ClickFilter{After: repo.log[maxClickLog-2].ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, last, 1)
+	assert.Equal(t, "00000000000186aa", last[0].ID)
+}
@@ -5,27 +5,158 @@ import (
 	"errors"
 	"fmt"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	"net/url"
+	"time"
 )
 
 const (
-	AliasCollectionName = "aliases"
-	StatsCollectionName = "stats"
+	AliasCollectionName    = "aliases"
+	StatsCollectionName    = "stats"
+	CampaignCollectionName = "campaigns"
+	ClicksCollectionName   = "clicks"
+	// ClickEventsCollectionName keeps the log of the clicks, the counters are kept in ClicksCollectionName
+	ClickEventsCollectionName = "click_events"
+	LockCollectionName        = "locks"
 )
 
 // AliasDTO is DTO for AliasCollectionName collection
 type AliasDTO struct {
-	ID          string   `bson:"_id"`
-	URL         *url.URL `bson:"url"`
-	Key         string   `bson:"key"`
-	IsActive    bool     `bson:"is_active"`
-	IsPermanent bool     `bson:"is_permanent"`
-	TriesLeft   int      `bson:"tries_left,omitempty"`
+	ID           string       `bson:"_id"`
+	URL          *url.URL     `bson:"url"`
+	Key          string       `bson:"key"`
+	IsActive     bool         `bson:"is_active"`
+	IsPermanent  bool         `bson:"is_permanent"`
+	TriesLeft    int          `bson:"tries_left,omitempty"`
+	RedirectType int          `bson:"redirect_type,omitempty"`
+	Passthrough  string       `bson:"passthrough,omitempty"`
+	OnConflict   string       `bson:"query_conflict,omitempty"`
+	Campaign     string       `bson:"campaign,omitempty"`
+	Rules        []RuleDTO    `bson:"rules,omitempty"`
+	Variants     []VariantDTO `bson:"variants,omitempty"`
+	PasswordHash []byte       `bson:"password_hash,omitempty"`
+	Interstitial bool         `bson:"interstitial,omitempty"`
+	Disabled     bool         `bson:"disabled,omitempty"`
+	CreatedAt    time.Time    `bson:"created_at,omitempty"`
+	ActivatesAt  time.Time    `bson:"activates_at,omitempty"`
+	ExpiresAt    time.Time    `bson:"expires_at,omitempty"`
+	ExpiredAt    time.Time    `bson:"expired_at,omitempty"`
+	DeletedAt    time.Time    `bson:"deleted_at,omitempty"`
+}
+
+func (d AliasDTO) toDomain() *domain.Alias {
+	alias := &domain.Alias{
+		ID:           d.ID,
+		Key:          d.Key,
+		URL:          d.URL,
+		IsActive:     d.IsActive,
+		Params:       domain.TTLParams{TriesLeft: d.TriesLeft, IsPermanent: d.IsPermanent},
+		RedirectType: domain.RedirectType(d.RedirectType),
+		Passthrough: domain.PassthroughPolicy{
+			Mode:       domain.PassthroughMode(d.Passthrough),
+			OnConflict: domain.QueryConflict(d.OnConflict),
+		},
+		Campaign:     d.Campaign,
+		PasswordHash: d.PasswordHash,
+		Interstitial: d.Interstitial,
+		Disabled:     d.Disabled,
+		CreatedAt:    d.CreatedAt,
+		ActivatesAt:  d.ActivatesAt,
+		ExpiresAt:    d.ExpiresAt,
+		ExpiredAt:    d.ExpiredAt,
+		DeletedAt:    d.DeletedAt,
+	}
+	if id, err := primitive.ObjectIDFromHex(d.ID); err == nil && alias.CreatedAt.IsZero() {
+		// aliases saved before the creation time was stored
+		alias.CreatedAt = id.Timestamp()
+	}
+	for _, rule := range d.Rules {
+		alias.Rules = append(alias.Rules, rule.toDomain())
+	}
+	for _, variant := range d.Variants {
+		alias.Variants = append(alias.Variants, variant.toDomain())
+	}
+	return alias
+}
+
+// optionalTime stores the zero time as null
+func optionalTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// RuleDTO is DTO for the redirect rules embedded into the alias document
+type RuleDTO struct {
+	Name      string   `bson:"name"`
+	Platforms []string `bson:"platforms,omitempty"`
+	Languages []string `bson:"languages,omitempty"`
+	Countries []string `bson:"countries,omitempty"`
+	URL       *url.URL `bson:"url"`
+}
+
+func newRuleDTOs(rules []domain.RedirectRule) []RuleDTO {
+	if len(rules) == 0 {
+		return nil
+	}
+	documents := make([]RuleDTO, len(rules))
+	for index, rule := range rules {
+		platforms := make([]string, len(rule.Platforms))
+		for i, platform := range rule.Platforms {
+			platforms[i] = string(platform)
+		}
+		documents[index] = RuleDTO{
+			Name:      rule.Name,
+			Platforms: platforms,
+			Languages: rule.Languages,
+			Countries: rule.Countries,
+			URL:       rule.URL,
+		}
+	}
+	return documents
+}
+
+func (d RuleDTO) toDomain() domain.RedirectRule {
+	platforms := make([]domain.Platform, len(d.Platforms))
+	for index, platform := range d.Platforms {
+		platforms[index] = domain.Platform(platform)
+	}
+	return domain.RedirectRule{
+		Name:      d.Name,
+		Platforms: platforms,
+		Languages: d.Languages,
+		Countries: d.Countries,
+		URL:       d.URL,
+	}
+}
+
+// VariantDTO is DTO for the A/B variants embedded into the alias document
+type VariantDTO struct {
+	Name   string   `bson:"name"`
+	URL    *url.URL `bson:"url"`
+	Weight int      `bson:"weight"`
+}
+
+func newVariantDTOs(variants []domain.Variant) []VariantDTO {
+	if len(variants) == 0 {
+		return nil
+	}
+	documents := make([]VariantDTO, len(variants))
+	for index, variant := range variants {
+		documents[index] = VariantDTO{Name: variant.Name, URL: variant.URL, Weight: variant.Weight}
+	}
+	return documents
+}
+
+func (d VariantDTO) toDomain() domain.Variant {
+	return domain.Variant{Name: d.Name, URL: d.URL, Weight: d.Weight}
 }
 
 type AliasRepository struct {
@@ -46,7 +177,9 @@ func (r *AliasRepository) Name() string {
 // Save saves aliases in storage
 func (a *AliasRepository) Save(ctx context.Context, aliases []domain.Alias) error {
 	const fn = "Save"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.Int("aliases count", len(aliases)))
@@ -54,11 +187,25 @@ func (a *AliasRepository) Save(ctx context.Context, aliases []domain.Alias) erro
 	documents := make([]interface{}, len(aliases))
 	for index, alias := range aliases {
 		documents[index] = bson.D{
-			{"key", alias.Key},
-			{"url", alias.URL},
-			{"is_active", alias.IsActive},
-			{"is_permanent", alias.Params.IsPermanent},
-			{"tries_left", alias.Params.TriesLeft},
+			{Key: "key", Value: alias.Key},
+			{Key: "url", Value: alias.URL},
+			{Key: "is_active", Value: alias.IsActive},
+			{Key: "is_permanent", Value: alias.Params.IsPermanent},
+			{Key: "tries_left", Value: alias.Params.TriesLeft},
+			{Key: "redirect_type", Value: int(alias.RedirectType)},
+			{Key: "passthrough", Value: string(alias.Passthrough.Mode)},
+			{Key: "query_conflict", Value: string(alias.Passthrough.OnConflict)},
+			{Key: "campaign", Value: alias.Campaign},
+			{Key: "rules", Value: newRuleDTOs(alias.Rules)},
+			{Key: "variants", Value: newVariantDTOs(alias.Variants)},
+			{Key: "password_hash", Value: alias.PasswordHash},
+			{Key: "interstitial", Value: alias.Interstitial},
+			{Key: "disabled", Value: alias.Disabled},
+			{Key: "created_at", Value: alias.CreatedAt},
+			{Key: "activates_at", Value: optionalTime(alias.ActivatesAt)},
+			{Key: "expires_at", Value: optionalTime(alias.ExpiresAt)},
+			{Key: "expired_at", Value: optionalTime(alias.ExpiredAt)},
+			{Key: "deleted_at", Value: optionalTime(alias.DeletedAt)},
 		}
 	}
 	opStatus, err := a.collection.InsertMany(ctx, documents)
@@ -74,7 +221,9 @@ func (a *AliasRepository) Save(ctx context.Context, aliases []domain.Alias) erro
 // Find gets the alias by key
 func (a *AliasRepository) Find(ctx context.Context, key string) (*domain.Alias, error) {
 	const fn = "Find"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
@@ -97,26 +246,156 @@ func (a *AliasRepository) Find(ctx context.Context, key string) (*domain.Alias,
 	if err := result.Decode(doc); err != nil {
 		return nil, err
 	}
+	return doc.toDomain(), nil
+}
 
-	alias := &domain.Alias{
-		ID:       doc.ID,
-		Key:      doc.Key,
-		URL:      doc.URL,
-		IsActive: doc.IsActive,
-		Params:   domain.TTLParams{TriesLeft: doc.TriesLeft, IsPermanent: doc.IsPermanent},
+// ExistingKeys returns the given keys taken by the stored aliases, including the ones in the trash
+func (a *AliasRepository) ExistingKeys(ctx context.Context, keys []string) ([]string, error) {
+	const fn = "ExistingKeys"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.Int("keys count", len(keys)))
+
+	existing := make([]string, 0)
+	if len(keys) == 0 {
+		return existing, nil
+	}
+	opts := options.Find().SetProjection(bson.M{"key": 1, "_id": 0})
+	cursor, err := a.collection.Find(ctx, bson.M{"key": bson.M{"$in": keys}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var docs []struct {
+		Key string `bson:"key"`
 	}
-	return alias, nil
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	for _, doc := range docs {
+		existing = append(existing, doc.Key)
+	}
+	return existing, nil
+}
+
+// List returns the aliases matching the filter ordered by key, at most filter.Limit of them
+func (a *AliasRepository) List(ctx context.Context, filter domain.ListFilter, now time.Time) ([]domain.Alias, error) {
+	const fn = "List"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("status", string(filter.Status)),
+		zap.String("campaign", filter.Campaign),
+		zap.String("after", filter.After),
+		zap.Int("limit", filter.Limit))
+
+	// the aliases in the trash are listed only if asked for explicitly
+	conditions := []bson.M{{"is_active": filter.Status != domain.StatusDeleted}}
+	if filter.After != "" {
+		conditions = append(conditions, bson.M{"key": bson.M{"$gt": filter.After}})
+	}
+	if filter.Campaign != "" {
+		conditions = append(conditions, bson.M{"campaign": filter.Campaign})
+	}
+	if filter.Status != "" && filter.Status != domain.StatusDeleted {
+		conditions = append(conditions, statusFilter(filter.Status, now))
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "key", Value: 1}}).SetLimit(int64(filter.Limit))
+	cursor, err := a.collection.Find(ctx, bson.M{"$and": conditions}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var docs []AliasDTO
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	aliases := make([]domain.Alias, len(docs))
+	for index, doc := range docs {
+		aliases[index] = *doc.toDomain()
+	}
+	return aliases, nil
+}
+
+// Scan returns the aliases with keys greater than after ordered by key, at most limit of them.
+// Unlike List it returns the aliases in the trash along with the others.
+func (a *AliasRepository) Scan(ctx context.Context, after string, limit int) ([]domain.Alias, error) {
+	const fn = "Scan"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("after", after),
+		zap.Int("limit", limit))
+
+	opts := options.Find().SetSort(bson.D{{Key: "key", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := a.collection.Find(ctx, bson.M{"key": bson.M{"$gt": after}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var docs []AliasDTO
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	aliases := make([]domain.Alias, len(docs))
+	for index, doc := range docs {
+		aliases[index] = *doc.toDomain()
+	}
+	return aliases, nil
+}
+
+// statusFilter selects the aliases in the status at the given time, mirroring domain.Alias.Status
+func statusFilter(status domain.AliasStatus, now time.Time) bson.M {
+	notDisabled := bson.M{"disabled": bson.M{"$ne": true}}
+	notExpired := bson.M{
+		"expired_at": nil,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"is_permanent": true}, bson.M{"tries_left": bson.M{"$gt": 0}}}},
+			bson.M{"$or": bson.A{bson.M{"expires_at": nil}, bson.M{"expires_at": bson.M{"$gt": now}}}},
+		},
+	}
+
+	switch status {
+	case domain.StatusDisabled:
+		return bson.M{"disabled": true}
+	case domain.StatusExpired:
+		return bson.M{"$and": bson.A{notDisabled, expiredFilter(now)}}
+	case domain.StatusScheduled:
+		return bson.M{"$and": bson.A{notDisabled, notExpired, bson.M{"activates_at": bson.M{"$gt": now}}}}
+	default:
+		activated := bson.M{"$or": bson.A{bson.M{"activates_at": nil}, bson.M{"activates_at": bson.M{"$lte": now}}}}
+		return bson.M{"$and": bson.A{notDisabled, notExpired, activated}}
+	}
+}
+
+// expiredFilter selects the aliases expired at the given time, mirroring domain.Alias.IsExpired
+func expiredFilter(now time.Time) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"expired_at": bson.M{"$ne": nil}},
+		bson.M{"is_permanent": false, "tries_left": bson.M{"$not": bson.M{"$gt": 0}}},
+		bson.M{"expires_at": bson.M{"$lte": now}},
+	}}
 }
 
 // DecreaseTTLCounter decreases the alias redirect counter
 func (a *AliasRepository) DecreaseTTLCounter(ctx context.Context, key string) error {
 	const fn = "DecreaseTTLCounter"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
 
-	filter := bson.M{"key": key, "is_active": true}
+	// the alias deactivated by the reaper is not followed even if it has uses left
+	filter := bson.M{"key": key, "is_active": true, "expired_at": nil}
 
 	pipeline := bson.A{
 		bson.M{
@@ -133,6 +412,14 @@ func (a *AliasRepository) DecreaseTTLCounter(ctx context.Context, key string) er
 
 	if result.Err() != nil {
 		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			reaped, err := a.collection.CountDocuments(ctx,
+				bson.M{"key": key, "is_active": true, "expired_at": bson.M{"$ne": nil}})
+			if err != nil {
+				return fmt.Errorf("%s: %w", fn, err)
+			}
+			if reaped > 0 {
+				return domain.ErrAliasExpired
+			}
 			return domain.ErrAliasNotFound
 		}
 		return fmt.Errorf("%s: %w", fn, result.Err())
@@ -151,16 +438,18 @@ func (a *AliasRepository) DecreaseTTLCounter(ctx context.Context, key string) er
 	return nil
 }
 
-// Remove deletes a shortened link
-func (a *AliasRepository) Remove(ctx context.Context, key string) error {
+// Remove moves a shortened link to the trash
+func (a *AliasRepository) Remove(ctx context.Context, key string, deletedAt time.Time) error {
 	const fn = "Remove"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", a.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
 
 	filter := bson.M{"key": key, "is_active": true}
-	update := bson.M{"$set": bson.M{"is_active": false}}
+	update := bson.M{"$set": bson.M{"is_active": false, "deleted_at": deletedAt}}
 
 	result := a.collection.FindOneAndUpdate(ctx, filter, update)
 	if result.Err() != nil {
@@ -171,3 +460,185 @@ func (a *AliasRepository) Remove(ctx context.Context, key string) error {
 	}
 	return nil
 }
+
+// Restore takes a shortened link back from the trash
+func (a *AliasRepository) Restore(ctx context.Context, key string) error {
+	const fn = "Restore"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key))
+
+	filter := bson.M{"key": key, "is_active": false}
+	update := bson.M{"$set": bson.M{"is_active": true}, "$unset": bson.M{"deleted_at": ""}}
+
+	result, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
+
+// Purge deletes the aliases moved to the trash before the given time, returns the number of deleted aliases.
+// The aliases removed before the deletion time was stored have no deleted_at and are kept.
+func (a *AliasRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const fn = "Purge"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.Time("deleted before", deletedBefore))
+
+	filter := bson.M{"is_active": false, "deleted_at": bson.M{"$lt": deletedBefore}}
+	result, err := a.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Expire deactivates at most limit of the expired aliases not deactivated yet, ordered by key, and returns them.
+// The deactivated aliases stay out of the trash and keep their expired status.
+func (a *AliasRepository) Expire(ctx context.Context, now time.Time, limit int) ([]domain.Alias, error) {
+	const fn = "Expire"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.Time("now", now),
+		zap.Int("limit", limit))
+
+	filter := bson.M{"$and": bson.A{
+		bson.M{"is_active": true, "disabled": bson.M{"$ne": true}, "expired_at": nil},
+		expiredFilter(now),
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "key", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := a.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var docs []AliasDTO
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	expired := make([]domain.Alias, 0, len(docs))
+	for _, doc := range docs {
+		// the alias might have been removed, disabled or expired by another reaper meanwhile
+		result, err := a.collection.UpdateOne(ctx,
+			bson.M{"key": doc.Key, "is_active": true, "disabled": bson.M{"$ne": true}, "expired_at": nil},
+			bson.M{"$set": bson.M{"expired_at": now}})
+		if err != nil {
+			return expired, fmt.Errorf("%s: %w", fn, err)
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		doc.ExpiredAt = now
+		expired = append(expired, *doc.toDomain())
+	}
+	return expired, nil
+}
+
+// UpdateRules replaces the redirect rules of the alias
+func (a *AliasRepository) UpdateRules(ctx context.Context, key string, rules []domain.RedirectRule) error {
+	const fn = "UpdateRules"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Int("rules count", len(rules)))
+
+	filter := bson.M{"key": key, "is_active": true}
+	update := bson.M{"$set": bson.M{"rules": newRuleDTOs(rules)}}
+
+	result, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
+
+// UpdateVariants replaces the A/B variants of the alias
+func (a *AliasRepository) UpdateVariants(ctx context.Context, key string, variants []domain.Variant) error {
+	const fn = "UpdateVariants"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Int("variants count", len(variants)))
+
+	filter := bson.M{"key": key, "is_active": true}
+	update := bson.M{"$set": bson.M{"variants": newVariantDTOs(variants)}}
+
+	result, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
+
+// UpdateDisabled disables the alias or enables it again
+func (a *AliasRepository) UpdateDisabled(ctx context.Context, key string, disabled bool) error {
+	const fn = "UpdateDisabled"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Bool("disabled", disabled))
+
+	filter := bson.M{"key": key, "is_active": true}
+	update := bson.M{"$set": bson.M{"disabled": disabled}}
+
+	result, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
+
+// UpdateActivation sets the time the alias starts redirecting at, zero time activates it immediately
+func (a *AliasRepository) UpdateActivation(ctx context.Context, key string, activatesAt time.Time) error {
+	const fn = "UpdateActivation"
+	ctx, span := tracing.Start(ctx, a.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", a.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Time("activates_at", activatesAt))
+
+	filter := bson.M{"key": key, "is_active": true}
+	update := bson.M{"$set": bson.M{"activates_at": optionalTime(activatesAt)}}
+
+	result, err := a.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CampaignDTO is DTO for CampaignCollectionName collection
+type CampaignDTO struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	Params    map[string]string  `bson:"params"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (d CampaignDTO) toDomain() domain.Campaign {
+	return domain.Campaign{
+		ID:        d.ID.Hex(),
+		Name:      d.Name,
+		Params:    d.Params,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+type CampaignRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCampaignRepository creates a new CampaignRepository
+func NewCampaignRepository(collection *mongo.Collection) *CampaignRepository {
+	return &CampaignRepository{collection: collection}
+}
+
+func (r *CampaignRepository) Name() string {
+	return "mongodb::CampaignRepository"
+}
+
+// Save saves a new campaign, the name of the campaign is unique
+func (r *CampaignRepository) Save(ctx context.Context, campaign domain.Campaign) error {
+	const fn = "Save"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", campaign.Name))
+
+	document := CampaignDTO{
+		Name:      campaign.Name,
+		Params:    campaign.Params,
+		CreatedAt: campaign.CreatedAt,
+	}
+	if _, err := r.collection.InsertOne(ctx, document); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrCampaignAlreadyExists
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// Find gets the campaign by name
+func (r *CampaignRepository) Find(ctx context.Context, name string) (*domain.Campaign, error) {
+	const fn = "Find"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", name))
+
+	result := r.collection.FindOne(ctx, bson.M{"name": name})
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, domain.ErrCampaignNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", fn, result.Err())
+	}
+	doc := new(CampaignDTO)
+	if err := result.Decode(doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	campaign := doc.toDomain()
+	return &campaign, nil
+}
+
+// List returns all campaigns ordered by name
+func (r *CampaignRepository) List(ctx context.Context) ([]domain.Campaign, error) {
+	const fn = "List"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn))
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var docs []CampaignDTO
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	campaigns := make([]domain.Campaign, len(docs))
+	for index, doc := range docs {
+		campaigns[index] = doc.toDomain()
+	}
+	return campaigns, nil
+}
+
+// Remove deletes the campaign
+func (r *CampaignRepository) Remove(ctx context.Context, name string) error {
+	const fn = "Remove"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", name))
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrCampaignNotFound
+	}
+	return nil
+}
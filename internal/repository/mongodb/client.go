@@ -0,0 +1,29 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Connect connects to the deployment at uri and pings it. The credential is used unless its auth source is empty.
+func Connect(ctx context.Context, uri string, credential options.Credential, serverSelectionTimeout time.Duration) (*mongo.Client, error) {
+	clientOptions := options.Client().
+		ApplyURI(uri).
+		SetServerSelectionTimeout(serverSelectionTimeout)
+	if credential.AuthSource != "" {
+		clientOptions.SetAuth(credential)
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", uri, err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+// LockDTO is DTO for LockCollectionName collection
+type LockDTO struct {
+	Name      string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// LockRepository grants named leases shared by the replicas of the service.
+// A lease is held by a single owner until it expires or is released, the holder extends it by acquiring it again.
+type LockRepository struct {
+	collection *mongo.Collection
+	owner      string
+}
+
+// NewLockRepository creates a new LockRepository acquiring the leases on behalf of the owner
+func NewLockRepository(collection *mongo.Collection, owner string) *LockRepository {
+	return &LockRepository{collection: collection, owner: owner}
+}
+
+func (r *LockRepository) Name() string {
+	return "mongodb::LockRepository"
+}
+
+// Acquire takes or extends the lease for ttl, it reports false if the lease is held by another owner
+func (r *LockRepository) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	const fn = "Acquire"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("lock", name),
+		zap.String("owner", r.owner))
+
+	now := time.Now()
+	// a lease held by another owner does not match the filter, so the upsert fails on the duplicate _id
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{bson.M{"owner": r.owner}, bson.M{"expires_at": bson.M{"$lte": now}}},
+	}
+	update := bson.M{"$set": bson.M{"owner": r.owner, "expires_at": now.Add(ttl)}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", fn, err)
+	}
+	return true, nil
+}
+
+// Release gives the lease up, so that another owner does not wait for it to expire. A lease held by another owner is kept.
+func (r *LockRepository) Release(ctx context.Context, name string) error {
+	const fn = "Release"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("lock", name),
+		zap.String("owner", r.owner))
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": name, "owner": r.owner}); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
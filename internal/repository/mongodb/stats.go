@@ -2,27 +2,60 @@ package mongodb
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	"net/url"
 	"time"
 )
 
 type eventDocument struct {
-	OccurredAt time.Time `bson:"occurred_at"` // time when event occurred
-	Key        string    `bson:"key"`
-	URL        *url.URL  `bson:"url"`
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	OccurredAt time.Time          `bson:"occurred_at"` // time when event occurred
+	Key        string             `bson:"key"`
+	URL        *url.URL           `bson:"url"`
+	Campaign   string             `bson:"campaign,omitempty"` // campaign the alias was attached to
+}
+
+// clickDocument is the document of ClicksCollectionName collection
+type clickDocument struct {
+	Key         string         `bson:"key"`
+	Total       int            `bson:"total"`
+	Rules       map[string]int `bson:"rules,omitempty"`
+	Variants    map[string]int `bson:"variants,omitempty"`
+	LastClickAt time.Time      `bson:"last_click_at"`
+}
+
+// clickEventDocument is the document of ClickEventsCollectionName collection
+type clickEventDocument struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Key        string             `bson:"key"`
+	Campaign   string             `bson:"campaign,omitempty"`
+	Rule       string             `bson:"rule,omitempty"`
+	Variant    string             `bson:"variant,omitempty"`
+	OccurredAt time.Time          `bson:"occurred_at"`
 }
 
 type StatisticsRepository struct {
 	collection *mongo.Collection
+	clicks     *mongo.Collection
+	events     *mongo.Collection
 }
 
-// NewStatisticsRepository creates a new StatisticsRepository
-func NewStatisticsRepository(collection *mongo.Collection) *StatisticsRepository {
+// NewStatisticsRepository creates a new StatisticsRepository keeping the expiration events, the click counters
+// and the click log in separate collections
+func NewStatisticsRepository(collection *mongo.Collection, clicks *mongo.Collection, events *mongo.Collection) *StatisticsRepository {
 	return &StatisticsRepository{
 		collection: collection,
+		clicks:     clicks,
+		events:     events,
 	}
 }
 
@@ -33,7 +66,9 @@ func (r *StatisticsRepository) Name() string {
 // PushStats pushes data with statistics into collection
 func (r *StatisticsRepository) PushStats(ctx context.Context, event domain.AliasExpired) error {
 	const fn = "PushEvent"
-	zap.S().Infow("repo",
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
 		zap.String("name", r.Name()),
 		zap.String("fn", fn),
 		zap.String("event", event.String()),
@@ -42,6 +77,7 @@ func (r *StatisticsRepository) PushStats(ctx context.Context, event domain.Alias
 		OccurredAt: event.OccurredAt,
 		Key:        event.Key,
 		URL:        event.URL,
+		Campaign:   event.Campaign,
 	}
 
 	if _, err := r.collection.InsertOne(ctx, newEventDoc); err != nil {
@@ -49,3 +85,302 @@ func (r *StatisticsRepository) PushStats(ctx context.Context, event domain.Alias
 	}
 	return nil
 }
+
+// RecordClick increments the click counters of the alias, per matched rule and A/B variant as well
+func (r *StatisticsRepository) RecordClick(ctx context.Context, event domain.AliasUsed) error {
+	const fn = "RecordClick"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("event", event.String()),
+		zap.String("alias key", event.Key))
+
+	increment := bson.M{"total": 1}
+	if event.Rule != "" {
+		increment["rules."+event.Rule] = 1
+	}
+	if event.Variant != "" {
+		increment["variants."+event.Variant] = 1
+	}
+	update := bson.M{
+		"$inc": increment,
+		"$max": bson.M{"last_click_at": event.OccurredAt},
+	}
+	opts := options.Update().SetUpsert(true)
+	if _, err := r.clicks.UpdateOne(ctx, bson.M{"key": event.Key}, update, opts); err != nil {
+		return domain.ErrStatsCollectingFailed
+	}
+
+	click := clickEventDocument{
+		Key:        event.Key,
+		Campaign:   event.Campaign,
+		Rule:       event.Rule,
+		Variant:    event.Variant,
+		OccurredAt: event.OccurredAt,
+	}
+	if _, err := r.events.InsertOne(ctx, click); err != nil {
+		return domain.ErrStatsCollectingFailed
+	}
+	return nil
+}
+
+// ListClicks returns the clicks matching the filter ordered by ID, at most filter.Limit of them.
+// The IDs are the object IDs generated by the instances, so the clicks recorded meanwhile by different instances
+// within the same second may be missed by the next page.
+func (r *StatisticsRepository) ListClicks(ctx context.Context, filter domain.ClickFilter) ([]domain.Click, error) {
+	const fn = "ListClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("key", filter.Key),
+		zap.String("campaign", filter.Campaign),
+		zap.String("after", filter.After),
+		zap.Int("limit", filter.Limit))
+
+	query := bson.M{}
+	if filter.After != "" {
+		after, err := primitive.ObjectIDFromHex(filter.After)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", domain.ErrInvalidListFilter)
+		}
+		query["_id"] = bson.M{"$gt": after}
+	}
+	if filter.Key != "" {
+		query["key"] = filter.Key
+	}
+	if filter.Campaign != "" {
+		query["campaign"] = filter.Campaign
+	}
+	occurredAt := bson.M{}
+	if !filter.From.IsZero() {
+		occurredAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		occurredAt["$lt"] = filter.To
+	}
+	if len(occurredAt) > 0 {
+		query["occurred_at"] = occurredAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(filter.Limit))
+	cursor, err := r.events.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var documents []clickEventDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	clicks := make([]domain.Click, len(documents))
+	for index, document := range documents {
+		clicks[index] = domain.Click{
+			ID:         document.ID.Hex(),
+			Key:        document.Key,
+			Campaign:   document.Campaign,
+			Rule:       document.Rule,
+			Variant:    document.Variant,
+			OccurredAt: document.OccurredAt,
+		}
+	}
+	return clicks, nil
+}
+
+// PurgeClicks deletes the clicks of the log occurred before the given time, the click counters are kept.
+// It returns the number of the deleted clicks.
+func (r *StatisticsRepository) PurgeClicks(ctx context.Context, occurredBefore time.Time) (int64, error) {
+	const fn = "PurgeClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.Time("occurred before", occurredBefore))
+
+	// served by the occurred_at index
+	result, err := r.events.DeleteMany(ctx, bson.M{"occurred_at": bson.M{"$lt": occurredBefore}})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Clicks returns the click counters of the alias, zero counters if it has never been clicked
+func (r *StatisticsRepository) Clicks(ctx context.Context, key string) (*domain.ClickStats, error) {
+	const fn = "Clicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("alias key", key))
+
+	doc := clickDocument{Key: key}
+	err := r.clicks.FindOne(ctx, bson.M{"key": key}).Decode(&doc)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stats := &domain.ClickStats{
+		Key:         key,
+		Total:       doc.Total,
+		Rules:       doc.Rules,
+		Variants:    doc.Variants,
+		LastClickAt: doc.LastClickAt,
+	}
+	if stats.Rules == nil {
+		stats.Rules = make(map[string]int)
+	}
+	if stats.Variants == nil {
+		stats.Variants = make(map[string]int)
+	}
+	return stats, nil
+}
+
+// SetClicks replaces the click counters of the alias
+func (r *StatisticsRepository) SetClicks(ctx context.Context, stats domain.ClickStats) error {
+	const fn = "SetClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("alias key", stats.Key))
+
+	document := clickDocument{
+		Key:         stats.Key,
+		Total:       stats.Total,
+		Rules:       stats.Rules,
+		Variants:    stats.Variants,
+		LastClickAt: stats.LastClickAt,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.clicks.ReplaceOne(ctx, bson.M{"key": stats.Key}, document, opts); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// AppendClicks adds the clicks to the log. The IDs of the clicks are kept if they are object IDs,
+// so the clicks already in the log are skipped.
+func (r *StatisticsRepository) AppendClicks(ctx context.Context, clicks []domain.Click) error {
+	const fn = "AppendClicks"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.Int("clicks count", len(clicks)))
+
+	if len(clicks) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(clicks))
+	for index, click := range clicks {
+		id, _ := primitive.ObjectIDFromHex(click.ID)
+		documents[index] = clickEventDocument{
+			ID:         id,
+			Key:        click.Key,
+			Campaign:   click.Campaign,
+			Rule:       click.Rule,
+			Variant:    click.Variant,
+			OccurredAt: click.OccurredAt,
+		}
+	}
+	if err := insertNew(ctx, r.events, documents); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// ListExpirations returns the expiration records with IDs greater than after ordered by ID, at most limit of them
+func (r *StatisticsRepository) ListExpirations(ctx context.Context, after string, limit int) ([]domain.Expiration, error) {
+	const fn = "ListExpirations"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.String("after", after),
+		zap.Int("limit", limit))
+
+	query := bson.M{}
+	if after != "" {
+		id, err := primitive.ObjectIDFromHex(after)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", domain.ErrInvalidListFilter)
+		}
+		query["_id"] = bson.M{"$gt": id}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	var documents []eventDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	expirations := make([]domain.Expiration, len(documents))
+	for index, document := range documents {
+		expirations[index] = domain.Expiration{
+			ID:         document.ID.Hex(),
+			Key:        document.Key,
+			URL:        document.URL,
+			Campaign:   document.Campaign,
+			OccurredAt: document.OccurredAt,
+		}
+	}
+	return expirations, nil
+}
+
+// AppendExpirations adds the expiration records. The IDs of the records are kept if they are object IDs,
+// so the records already stored are skipped.
+func (r *StatisticsRepository) AppendExpirations(ctx context.Context, expirations []domain.Expiration) error {
+	const fn = "AppendExpirations"
+	ctx, span := tracing.Start(ctx, r.Name()+"."+fn)
+	defer span.End()
+	logging.FromContext(ctx).Infow("repo",
+		zap.String("name", r.Name()),
+		zap.String("fn", fn),
+		zap.Int("expirations count", len(expirations)))
+
+	if len(expirations) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(expirations))
+	for index, expiration := range expirations {
+		id, _ := primitive.ObjectIDFromHex(expiration.ID)
+		documents[index] = eventDocument{
+			ID:         id,
+			OccurredAt: expiration.OccurredAt,
+			Key:        expiration.Key,
+			URL:        expiration.URL,
+			Campaign:   expiration.Campaign,
+		}
+	}
+	if err := insertNew(ctx, r.collection, documents); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// insertNew inserts the documents, the ones with the IDs already taken are skipped
+func insertNew(ctx context.Context, collection *mongo.Collection, documents []interface{}) error {
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if err == nil || !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return err
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr.WriteError) {
+			return err
+		}
+	}
+	return nil
+}
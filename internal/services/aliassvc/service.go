@@ -3,38 +3,74 @@ package aliassvc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
 	"go.uber.org/zap"
+	"io"
+	"math/rand/v2"
+	"net/url"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	keyLength     = 8
 	maxGoroutines = 10
+	// importBatchSize is the number of the imported rows validated and saved at once
+	importBatchSize = 1000
 )
 
 type Alias struct {
-	repo         aliasRepo
-	expiredQ     eventProducer
-	usedQ        eventProducer
-	keyGenerator keyGenerator
+	repo            aliasRepo
+	expiredQ        eventProducer
+	usedQ           eventProducer
+	keyGenerator    keyGenerator
+	campaigns       campaignRepo
+	defaultRedirect atomic.Int64
+	// random picks the A/B variant of new visitors, returns a number in range [0, n)
+	random    func(n int) int
+	now       func() time.Time
+	blocklist atomic.Pointer[domain.Blocklist]
 }
 
 // NewAlias creates a new alias service
-func NewAlias(expiredQ eventProducer, usedQ eventProducer, repo aliasRepo, keyGenerator keyGenerator) *Alias {
-	return &Alias{
+func NewAlias(expiredQ eventProducer, usedQ eventProducer, repo aliasRepo, keyGenerator keyGenerator, campaigns campaignRepo) *Alias {
+	s := &Alias{
 		expiredQ:     expiredQ,
 		usedQ:        usedQ,
 		repo:         repo,
 		keyGenerator: keyGenerator,
+		campaigns:    campaigns,
+		random:       rand.IntN,
+		now:          time.Now,
 	}
+	s.defaultRedirect.Store(int64(domain.RedirectTemporary))
+	s.blocklist.Store(domain.NewBlocklist(nil))
+	return s
 }
 
 type aliasRepo interface {
 	Save(ctx context.Context, aliases []domain.Alias) error
 	Find(ctx context.Context, key string) (*domain.Alias, error)
-	Remove(ctx context.Context, key string) error
+	ExistingKeys(ctx context.Context, keys []string) ([]string, error)
+	Remove(ctx context.Context, key string, deletedAt time.Time) error
+	Restore(ctx context.Context, key string) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	Expire(ctx context.Context, now time.Time, limit int) ([]domain.Alias, error)
+	UpdateRules(ctx context.Context, key string, rules []domain.RedirectRule) error
+	UpdateVariants(ctx context.Context, key string, variants []domain.Variant) error
+	UpdateDisabled(ctx context.Context, key string, disabled bool) error
+	UpdateActivation(ctx context.Context, key string, activatesAt time.Time) error
+	List(ctx context.Context, filter domain.ListFilter, now time.Time) ([]domain.Alias, error)
+}
+
+type campaignRepo interface {
+	Find(ctx context.Context, name string) (*domain.Campaign, error)
 }
 
 type eventProducer interface {
@@ -49,10 +85,71 @@ func (s *Alias) Name() string {
 	return "Alias"
 }
 
+// SetDefaultRedirectType sets the redirect type of aliases created without an explicit one
+func (s *Alias) SetDefaultRedirectType(redirectType domain.RedirectType) error {
+	if !redirectType.IsValid() {
+		return fmt.Errorf("%w: %d", domain.ErrInvalidRedirectType, redirectType)
+	}
+	s.defaultRedirect.Store(int64(redirectType))
+	return nil
+}
+
+// SetBlockedDomains replaces the domains the aliases must not lead to
+func (s *Alias) SetBlockedDomains(domains []string) {
+	s.blocklist.Store(domain.NewBlocklist(domains))
+}
+
+// checkDestinations fails if any of the urls leads to a blocked domain
+func (s *Alias) checkDestinations(urls ...*url.URL) error {
+	blocklist := s.blocklist.Load()
+	for _, target := range urls {
+		if blocklist.Blocks(target) {
+			return fmt.Errorf("%w: %s", domain.ErrDestinationBlocked, target.Hostname())
+		}
+	}
+	return nil
+}
+
+// DefaultRedirectType returns the redirect type of aliases created without an explicit one
+func (s *Alias) DefaultRedirectType() domain.RedirectType {
+	return domain.RedirectType(s.defaultRedirect.Load())
+}
+
+// redirectType resolves the redirect type of the alias to be created
+func (s *Alias) redirectType(request domain.CreateRequest) (domain.RedirectType, error) {
+	// the browsers would keep following a cached redirect after the alias is used up or expired
+	limited := !request.Params.IsPermanent || !request.ExpiresAt.IsZero()
+	if request.RedirectType == domain.RedirectDefault {
+		redirectType := s.DefaultRedirectType()
+		if limited && redirectType.IsCacheable() {
+			return domain.RedirectTemporary, nil
+		}
+		return redirectType, nil
+	}
+	if !request.RedirectType.IsValid() {
+		return domain.RedirectDefault, fmt.Errorf("%w: %d", domain.ErrInvalidRedirectType, request.RedirectType)
+	}
+	if limited && request.RedirectType.IsCacheable() {
+		return domain.RedirectDefault, domain.ErrCacheableRedirectForLimitedAlias
+	}
+	return request.RedirectType, nil
+}
+
+// passthrough fills the unset fields of the passthrough policy with defaults
+func passthrough(policy domain.PassthroughPolicy) domain.PassthroughPolicy {
+	if policy.Mode == "" {
+		policy.Mode = domain.PassthroughNone
+	}
+	if policy.OnConflict == "" {
+		policy.OnConflict = domain.QueryConflictKeepTarget
+	}
+	return policy
+}
+
 // Create creates a set of shortened links for the given origin links
 func (s *Alias) Create(ctx context.Context, requests []domain.CreateRequest) ([]domain.Alias, error) {
 	fn := "Create"
-	zap.S().Infow("service",
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.Int("requests count", len(requests)))
@@ -62,6 +159,21 @@ func (s *Alias) Create(ctx context.Context, requests []domain.CreateRequest) ([]
 		alias domain.Alias
 	}
 
+	createdAt := s.now().UTC()
+
+	redirectTypes := make([]domain.RedirectType, len(requests))
+	campaigns := make(map[string]struct{})
+	for index, request := range requests {
+		redirectType, err := s.checkRequest(ctx, request, campaigns)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		redirectTypes[index] = redirectType
+	}
+	if err := s.checkKeys(ctx, requests); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
 	// validate request
 	wg := sync.WaitGroup{}
 	semaphore := make(chan struct{}, maxGoroutines)
@@ -73,19 +185,24 @@ func (s *Alias) Create(ctx context.Context, requests []domain.CreateRequest) ([]
 		go func(index int) {
 			defer wg.Done()
 
-			key, err := s.keyGenerator.Generate(keyLength)
-			if err != nil {
-				errChan <- fmt.Errorf("%s: %w", fn, err)
+			key := requests[index].Key
+			var err error
+			if key == "" {
+				if key, err = s.keyGenerator.Generate(keyLength); err != nil {
+					errChan <- fmt.Errorf("%s: %w", fn, err)
+				}
+			}
+
+			var passwordHash []byte
+			if requests[index].Password != "" {
+				if passwordHash, err = domain.HashPassword(requests[index].Password); err != nil {
+					errChan <- fmt.Errorf("%s: %w", fn, err)
+				}
 			}
 
 			resultChan <- indexedResult{
 				index: index,
-				alias: domain.Alias{
-					Key:      key,
-					IsActive: true,
-					URL:      requests[index].URL,
-					Params:   requests[index].Params,
-				},
+				alias: newAlias(key, requests[index], redirectTypes[index], passwordHash, createdAt),
 			}
 
 		}(index)
@@ -99,7 +216,7 @@ func (s *Alias) Create(ctx context.Context, requests []domain.CreateRequest) ([]
 
 	for err := range errChan {
 		if err != nil {
-			zap.S().WithOptions(zap.AddStacktrace(zap.PanicLevel)).
+			logging.FromContext(ctx).WithOptions(zap.AddStacktrace(zap.PanicLevel)).
 				Errorw("service",
 					zap.String("name", s.Name()),
 					zap.String("fn", fn),
@@ -115,7 +232,7 @@ func (s *Alias) Create(ctx context.Context, requests []domain.CreateRequest) ([]
 
 	if err := s.repo.Save(ctx, aliases); err != nil {
 
-		zap.S().WithOptions(zap.AddStacktrace(zap.PanicLevel)).
+		logging.FromContext(ctx).WithOptions(zap.AddStacktrace(zap.PanicLevel)).
 			Errorw("service",
 				zap.String("name", s.Name()),
 				zap.String("fn", fn),
@@ -128,9 +245,80 @@ func (s *Alias) Create(ctx context.Context, requests []domain.CreateRequest) ([]
 	return aliases, nil
 }
 
+// checkRequest validates the creation request and resolves the redirect type of the alias.
+// The campaigns found are remembered in checked, so that every campaign is looked up once.
+func (s *Alias) checkRequest(ctx context.Context, request domain.CreateRequest, checked map[string]struct{}) (domain.RedirectType, error) {
+	redirectType, err := s.redirectType(request)
+	if err != nil {
+		return domain.RedirectDefault, err
+	}
+	if err := request.Passthrough.Validate(); err != nil {
+		return domain.RedirectDefault, err
+	}
+	if err := domain.ValidateExpiration(request.ExpiresAt, request.ActivatesAt, s.now()); err != nil {
+		return domain.RedirectDefault, err
+	}
+	if err := s.checkDestinations(request.URL); err != nil {
+		return domain.RedirectDefault, err
+	}
+	if _, found := checked[request.Campaign]; request.Campaign != "" && !found {
+		if _, err := s.campaigns.Find(ctx, request.Campaign); err != nil {
+			return domain.RedirectDefault, err
+		}
+		checked[request.Campaign] = struct{}{}
+	}
+	return redirectType, nil
+}
+
+// checkKeys validates the keys supplied instead of the generated ones, the keys must be free
+func (s *Alias) checkKeys(ctx context.Context, requests []domain.CreateRequest) error {
+	supplied := make([]string, 0)
+	for _, request := range requests {
+		if request.Key == "" {
+			continue
+		}
+		if err := domain.ValidateKey(request.Key); err != nil {
+			return err
+		}
+		if slices.Contains(supplied, request.Key) {
+			return fmt.Errorf("%w: %s", domain.ErrKeyAlreadyExists, request.Key)
+		}
+		supplied = append(supplied, request.Key)
+	}
+	if len(supplied) == 0 {
+		return nil
+	}
+	existing, err := s.repo.ExistingKeys(ctx, supplied)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%w: %s", domain.ErrKeyAlreadyExists, existing[0])
+	}
+	return nil
+}
+
+// newAlias creates the alias of the checked creation request
+func newAlias(key string, request domain.CreateRequest, redirectType domain.RedirectType, passwordHash []byte, createdAt time.Time) domain.Alias {
+	return domain.Alias{
+		Key:          key,
+		IsActive:     true,
+		URL:          request.URL,
+		Params:       request.Params,
+		RedirectType: redirectType,
+		Passthrough:  passthrough(request.Passthrough),
+		Campaign:     request.Campaign,
+		PasswordHash: passwordHash,
+		Interstitial: request.Interstitial,
+		CreatedAt:    createdAt,
+		ActivatesAt:  utcTime(request.ActivatesAt),
+		ExpiresAt:    utcTime(request.ExpiresAt),
+	}
+}
+
 func (s *Alias) FindOriginalURL(ctx context.Context, key string) (*domain.Alias, error) {
 	fn := "FindOriginalURL"
-	zap.S().Infow("service",
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
@@ -141,24 +329,34 @@ func (s *Alias) FindOriginalURL(ctx context.Context, key string) (*domain.Alias,
 	return alias, nil
 }
 
-func (s *Alias) Use(ctx context.Context, alias *domain.Alias) (*domain.Alias, error) {
+// Use registers a visit of the alias and returns the destination of the visitor, chosen by the first matching
+// redirect rule, the A/B variant of the visitor or the fallback URL of the alias
+func (s *Alias) Use(ctx context.Context, alias *domain.Alias, visitor domain.Visitor) (*domain.Visit, error) {
 	fn := "Use"
-	zap.S().Infow("service",
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.String("key", alias.Key))
 
-	if alias.Params.IsPermanent {
-		return alias, nil
+	if alias.Disabled {
+		return nil, fmt.Errorf("%s: %w", fn, domain.ErrAliasDisabled)
+	}
+	if err := s.checkActivated(alias); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	// check if alias is expired and send event with publisher
-	if alias.Params.TriesLeft == 0 {
+	// check if alias is expired and send event with publisher, unless the reaper has done it already
+	if alias.IsExpired(s.now()) {
+		if !alias.ExpiredAt.IsZero() {
+			return nil, domain.ErrAliasExpired
+		}
 		event := alias.Expired()
+		event.TraceContext = tracing.Inject(ctx)
+		event.RequestID = logging.RequestID(ctx)
 
 		s.expiredQ.Produce(event)
 
-		zap.S().Infow("service",
+		logging.FromContext(ctx).Infow("service",
 			zap.String("name", s.Name()),
 			zap.String("fn", fn),
 			zap.String("published", event.String()),
@@ -166,38 +364,574 @@ func (s *Alias) Use(ctx context.Context, alias *domain.Alias) (*domain.Alias, er
 		return nil, domain.ErrAliasExpired
 	}
 
-	zap.S().Infow("service",
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.String("alias", alias.Type()),
 		zap.String("key", alias.Key),
 		zap.Int("tries left", alias.Params.TriesLeft))
 
-	// publish event
+	destination := *alias
 	event := alias.Redirected()
+	if rule := alias.Match(visitor); rule != nil {
+		destination.URL = rule.URL
+		event.Rule = rule.Name
+	} else if variant := alias.PickVariant(visitor.Variant, s.random); variant != nil {
+		destination.URL = variant.URL
+		event.Variant = variant.Name
+	}
+	if err := s.checkDestinations(destination.URL); err != nil {
+		logging.FromContext(ctx).Warnw("service",
+			zap.String("name", s.Name()),
+			zap.String("fn", fn),
+			zap.String("key", alias.Key),
+			zap.Error(err))
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	// publish event
+	event.TraceContext = tracing.Inject(ctx)
+	event.RequestID = logging.RequestID(ctx)
 
 	s.usedQ.Produce(event)
 
-	zap.S().Infow("service",
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.String("publish", event.String()),
+		zap.String("rule", event.Rule),
+		zap.String("variant", event.Variant),
 	)
 
-	return alias, nil
+	return &domain.Visit{Alias: &destination, Rule: event.Rule, Variant: event.Variant}, nil
 }
 
-// Remove removes the alias link
+// Preview tells where the alias leads the visitor without using it.
+// extraPath and query are the path following the key and the query of the visited link, as for RedirectURL.
+func (s *Alias) Preview(ctx context.Context, alias *domain.Alias, visitor domain.Visitor, extraPath string, query url.Values) (*domain.Preview, error) {
+	fn := "Preview"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", alias.Key))
+
+	if alias.Disabled {
+		return nil, fmt.Errorf("%s: %w", fn, domain.ErrAliasDisabled)
+	}
+	if err := s.checkActivated(alias); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if alias.IsExpired(s.now()) {
+		return nil, fmt.Errorf("%s: %w", fn, domain.ErrAliasExpired)
+	}
+
+	preview := &domain.Preview{
+		Key:         alias.Key,
+		Type:        alias.Type(),
+		IsPermanent: alias.Params.IsPermanent,
+		TriesLeft:   alias.Params.TriesLeft,
+		CreatedAt:   alias.CreatedAt,
+		Protected:   alias.IsProtected(),
+	}
+	if preview.Protected {
+		return preview, nil
+	}
+
+	var destinations []*url.URL
+	switch rule, variant := alias.Match(visitor), alias.AssignedVariant(visitor.Variant); {
+	case rule != nil:
+		destinations = []*url.URL{rule.URL}
+	case variant != nil:
+		destinations = []*url.URL{variant.URL}
+	case len(alias.Variants) > 0:
+		for _, variant := range alias.Variants {
+			destinations = append(destinations, variant.URL)
+		}
+	default:
+		destinations = []*url.URL{alias.URL}
+	}
+
+	if err := s.checkDestinations(destinations...); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	for _, destination := range destinations {
+		resolved := *alias
+		resolved.URL = destination
+		target, err := s.RedirectURL(ctx, &resolved, extraPath, query)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		preview.Destinations = append(preview.Destinations, target)
+	}
+	return preview, nil
+}
+
+// Rules returns the ordered redirect rules of the alias, the URLs of a protected alias are blank
+func (s *Alias) Rules(ctx context.Context, key string) ([]domain.RedirectRule, error) {
+	fn := "Rules"
+	alias, err := s.FindOriginalURL(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return alias.Redacted().Rules, nil
+}
+
+// SetRules replaces the ordered redirect rules of the alias
+func (s *Alias) SetRules(ctx context.Context, key string, rules []domain.RedirectRule) error {
+	fn := "SetRules"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Int("rules count", len(rules)))
+
+	if err := domain.ValidateRules(rules); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	for _, rule := range rules {
+		if err := s.checkDestinations(rule.URL); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+	if err := s.repo.UpdateRules(ctx, key, rules); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// CheckPassword verifies the password of a protected alias before it is used
+func (s *Alias) CheckPassword(ctx context.Context, alias *domain.Alias, password string) error {
+	fn := "CheckPassword"
+	err := alias.CheckPassword(password)
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", alias.Key),
+		zap.Bool("accepted", err == nil))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// Variants returns the A/B variants of the alias, the URLs of a protected alias are blank
+func (s *Alias) Variants(ctx context.Context, key string) ([]domain.Variant, error) {
+	fn := "Variants"
+	alias, err := s.FindOriginalURL(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return alias.Redacted().Variants, nil
+}
+
+// SetVariants replaces the A/B variants of the alias, an empty list turns the split off
+func (s *Alias) SetVariants(ctx context.Context, key string, variants []domain.Variant) error {
+	fn := "SetVariants"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Int("variants count", len(variants)))
+
+	if err := domain.ValidateVariants(variants); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	for _, variant := range variants {
+		if err := s.checkDestinations(variant.URL); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+	if err := s.repo.UpdateVariants(ctx, key, variants); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// RedirectURL builds the url the visitor of the alias is redirected to.
+// extraPath is the escaped path following the key in the visited link, query is the query of the visited link.
+func (s *Alias) RedirectURL(ctx context.Context, alias *domain.Alias, extraPath string, query url.Values) (*url.URL, error) {
+	fn := "RedirectURL"
+	if alias.Campaign == "" {
+		return alias.Target(nil, extraPath, query), nil
+	}
+
+	campaign, err := s.campaigns.Find(ctx, alias.Campaign)
+	switch {
+	case errors.Is(err, domain.ErrCampaignNotFound):
+		// the campaign was removed after the alias had been created
+		logging.FromContext(ctx).Warnw("service",
+			zap.String("name", s.Name()),
+			zap.String("fn", fn),
+			zap.String("key", alias.Key),
+			zap.String("campaign", alias.Campaign),
+			zap.Error(err))
+		return alias.Target(nil, extraPath, query), nil
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return alias.Target(campaign, extraPath, query), nil
+}
+
+// SetDisabled disables the alias or enables it again
+func (s *Alias) SetDisabled(ctx context.Context, key string, disabled bool) error {
+	fn := "SetDisabled"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Bool("disabled", disabled))
+
+	if err := s.repo.UpdateDisabled(ctx, key, disabled); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// SetActivation sets the time the alias starts redirecting at, zero time activates it immediately
+func (s *Alias) SetActivation(ctx context.Context, key string, activatesAt time.Time) error {
+	fn := "SetActivation"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key),
+		zap.Time("activatesAt", activatesAt))
+
+	if err := s.repo.UpdateActivation(ctx, key, utcTime(activatesAt)); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// Available fails if the alias cannot be followed now: it is disabled, not active yet or has no uses left
+func (s *Alias) Available(ctx context.Context, alias *domain.Alias) error {
+	fn := "Available"
+	if alias.Disabled {
+		return fmt.Errorf("%s: %w", fn, domain.ErrAliasDisabled)
+	}
+	if err := s.checkActivated(alias); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if alias.IsExpired(s.now()) {
+		return fmt.Errorf("%s: %w", fn, domain.ErrAliasExpired)
+	}
+	return nil
+}
+
+// checkActivated fails if the scheduled alias is not active yet
+func (s *Alias) checkActivated(alias *domain.Alias) error {
+	if alias.IsScheduled(s.now()) {
+		return fmt.Errorf("%w: activates at %s", domain.ErrAliasNotActive, alias.ActivatesAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// utcTime normalizes the time to UTC, keeping the zero time
+func utcTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// List returns a page of the aliases matching the filter ordered by key
+func (s *Alias) List(ctx context.Context, filter domain.ListFilter) (*domain.AliasPage, error) {
+	fn := "List"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("status", string(filter.Status)),
+		zap.String("campaign", filter.Campaign),
+		zap.Int("limit", filter.Limit))
+
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = domain.DefaultListLimit
+	}
+	// one more alias tells whether there is a next page
+	filter.Limit = limit + 1
+	aliases, err := s.repo.List(ctx, filter, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	page := &domain.AliasPage{Aliases: aliases}
+	if len(aliases) > limit {
+		page.Aliases = aliases[:limit]
+		page.Next = page.Aliases[limit-1].Key
+	}
+	return page, nil
+}
+
+// ExportAliases passes all the aliases matching the filter to emit page by page, ordered by key. The filter limit is
+// the page size, MaxListLimit if zero, and the filter cursor is the key the export starts after. An error of emit
+// stops the export. It returns the number of the exported aliases.
+func (s *Alias) ExportAliases(ctx context.Context, filter domain.ListFilter, emit func([]domain.Alias) error) (int, error) {
+	fn := "ExportAliases"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("status", string(filter.Status)),
+		zap.String("campaign", filter.Campaign))
+
+	if err := filter.Validate(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = domain.MaxListLimit
+	}
+	// the statuses of all the pages are taken at the same time
+	now := s.now()
+	exported := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return exported, fmt.Errorf("%s: %w", fn, err)
+		}
+		aliases, err := s.repo.List(ctx, filter, now)
+		if err != nil {
+			return exported, fmt.Errorf("%s: %w", fn, err)
+		}
+		if len(aliases) > 0 {
+			if err := emit(aliases); err != nil {
+				return exported, fmt.Errorf("%s: %w", fn, err)
+			}
+			exported += len(aliases)
+		}
+		if len(aliases) < filter.Limit {
+			return exported, nil
+		}
+		filter.After = aliases[len(aliases)-1].Key
+	}
+}
+
+// Remove moves the alias link to the trash, it stops redirecting but can be restored until purged
 func (s *Alias) Remove(ctx context.Context, key string) error {
 	fn := "Remove"
-	zap.S().Infow("service",
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.String("key", key))
 
-	if err := s.repo.Remove(ctx, key); err != nil {
+	if err := s.repo.Remove(ctx, key, s.now().UTC()); err != nil {
 		return fmt.Errorf("%s: %w", fn, err)
 	}
 
 	return nil
 }
+
+// Restore takes the alias link back from the trash with all its settings
+func (s *Alias) Restore(ctx context.Context, key string) error {
+	fn := "Restore"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key))
+
+	if err := s.repo.Restore(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ReapExpired deactivates the aliases with no uses left or past their expiration time in batches of batchSize and
+// publishes an AliasExpired event for each of them. The deactivated aliases are not moved to the trash, they stay
+// listed with the expired status. It returns the number of the reaped aliases.
+func (s *Alias) ReapExpired(ctx context.Context, batchSize int) (int, error) {
+	fn := "ReapExpired"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.Int("batch size", batchSize))
+
+	if batchSize <= 0 {
+		batchSize = domain.DefaultListLimit
+	}
+	now := s.now().UTC()
+	reaped := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return reaped, fmt.Errorf("%s: %w", fn, err)
+		}
+		aliases, err := s.repo.Expire(ctx, now, batchSize)
+		if err != nil {
+			return reaped, fmt.Errorf("%s: %w", fn, err)
+		}
+		for _, alias := range aliases {
+			event := alias.Expired()
+			event.TraceContext = tracing.Inject(ctx)
+			event.RequestID = logging.RequestID(ctx)
+			s.expiredQ.Produce(event)
+			reaped++
+		}
+		if len(aliases) < batchSize {
+			return reaped, nil
+		}
+	}
+}
+
+// PurgeTrash deletes the aliases which have been in the trash longer than the retention period for good
+func (s *Alias) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	fn := "PurgeTrash"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.Duration("retention", retention))
+
+	purged, err := s.repo.Purge(ctx, s.now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	return purged, nil
+}
+
+// Import creates the aliases read from the source in batches of importBatchSize rows. Every row is validated on its
+// own and the invalid ones are reported failed without stopping the import, the supplied keys are preserved and the
+// missing ones are generated. The results of every batch are passed to report once it is processed, an error of the
+// source, the storage or the report aborts the import, the batches saved before stay in place.
+func (s *Alias) Import(ctx context.Context, source domain.ImportSource, options domain.ImportOptions,
+	report func([]domain.ImportResult) error) (*domain.ImportSummary, error) {
+	fn := "Import"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.Bool("dry run", options.DryRun))
+
+	summary := &domain.ImportSummary{DryRun: options.DryRun}
+	// the keys supplied by the rows read so far, the saved ones are found by the repository as well,
+	// but nothing is saved in dry-run mode
+	seen := make(map[string]struct{})
+	campaigns := make(map[string]struct{})
+	for eof := false; !eof; {
+		if err := ctx.Err(); err != nil {
+			return summary, fmt.Errorf("%s: %w", fn, err)
+		}
+		rows := make([]domain.ImportRow, 0, importBatchSize)
+		for len(rows) < importBatchSize {
+			row, err := source.Next()
+			if errors.Is(err, io.EOF) {
+				eof = true
+				break
+			}
+			if err != nil {
+				return summary, fmt.Errorf("%s: %w", fn, err)
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		results, err := s.importBatch(ctx, rows, options, seen, campaigns)
+		if err != nil {
+			return summary, fmt.Errorf("%s: %w", fn, err)
+		}
+		for _, result := range results {
+			summary.Add(result)
+		}
+		if err := report(results); err != nil {
+			return summary, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+	return summary, nil
+}
+
+// importBatch validates the rows and saves the valid ones unless in dry-run mode
+func (s *Alias) importBatch(ctx context.Context, rows []domain.ImportRow, options domain.ImportOptions,
+	seen map[string]struct{}, campaigns map[string]struct{}) ([]domain.ImportResult, error) {
+	createdAt := s.now().UTC()
+	results := make([]domain.ImportResult, len(rows))
+	aliases := make(map[int]domain.Alias, len(rows))
+	supplied := make([]string, 0, len(rows))
+	for index, row := range rows {
+		results[index] = domain.ImportResult{Line: row.Line, Key: row.Key, Status: domain.ImportFailed}
+		alias, err := s.importRow(ctx, row, seen, campaigns, createdAt)
+		if err != nil {
+			results[index].Err = err
+			continue
+		}
+		aliases[index] = alias
+		if row.Key != "" {
+			supplied = append(supplied, row.Key)
+		}
+	}
+
+	if len(supplied) > 0 {
+		existing, err := s.repo.ExistingKeys(ctx, supplied)
+		if err != nil {
+			return nil, err
+		}
+		taken := make(map[string]struct{}, len(existing))
+		for _, key := range existing {
+			taken[key] = struct{}{}
+		}
+		for index, alias := range aliases {
+			if _, ok := taken[alias.Key]; ok && rows[index].Key != "" {
+				results[index].Err = domain.ErrKeyAlreadyExists
+				delete(aliases, index)
+			}
+		}
+	}
+
+	valid := make([]domain.Alias, 0, len(aliases))
+	for index := range rows {
+		alias, ok := aliases[index]
+		if !ok {
+			continue
+		}
+		if options.DryRun {
+			results[index].Status = domain.ImportValid
+			continue
+		}
+		if alias.Key == "" {
+			key, err := s.keyGenerator.Generate(keyLength)
+			if err != nil {
+				return nil, err
+			}
+			alias.Key = key
+		}
+		results[index].Key = alias.Key
+		results[index].Status = domain.ImportCreated
+		valid = append(valid, alias)
+	}
+
+	if len(valid) > 0 {
+		if err := s.repo.Save(ctx, valid); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// importRow validates the row and creates its alias, the key is left empty unless supplied
+func (s *Alias) importRow(ctx context.Context, row domain.ImportRow, seen map[string]struct{},
+	campaigns map[string]struct{}, createdAt time.Time) (domain.Alias, error) {
+	if row.Err != nil {
+		return domain.Alias{}, row.Err
+	}
+	if row.Key != "" {
+		if err := domain.ValidateKey(row.Key); err != nil {
+			return domain.Alias{}, err
+		}
+		if _, ok := seen[row.Key]; ok {
+			return domain.Alias{}, domain.ErrKeyAlreadyExists
+		}
+	}
+	redirectType, err := s.checkRequest(ctx, row.Request, campaigns)
+	if err != nil {
+		return domain.Alias{}, err
+	}
+	var passwordHash []byte
+	if row.Request.Password != "" {
+		if passwordHash, err = domain.HashPassword(row.Request.Password); err != nil {
+			return domain.Alias{}, err
+		}
+	}
+	// a rejected row does not take its key, a later valid row may supply it
+	if row.Key != "" {
+		seen[row.Key] = struct{}{}
+	}
+	return newAlias(row.Key, row.Request, redirectType, passwordHash, createdAt), nil
+}
@@ -7,16 +7,22 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/xloki21/alias/internal/domain"
 	"github.com/xloki21/alias/internal/services/aliassvc/mocks"
+	"io"
 	"net/url"
 	"testing"
+	"time"
 )
 
+// testNow is the current time of the services created by NewTestHelper
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
 type TestHelper struct {
-	expiredQ *mocks.MockEventProducer
-	usedQ    *mocks.MockEventProducer
-	repo     *mocks.MockAliasRepo
-	keyGen   *mocks.MockKeyGenerator
-	service  *Alias
+	expiredQ  *mocks.MockEventProducer
+	usedQ     *mocks.MockEventProducer
+	repo      *mocks.MockAliasRepo
+	keyGen    *mocks.MockKeyGenerator
+	campaigns *mocks.MockCampaignRepo
+	service   *Alias
 }
 
 func NewTestHelper(t *testing.T) *TestHelper {
@@ -24,12 +30,16 @@ func NewTestHelper(t *testing.T) *TestHelper {
 	expiredQ := mocks.NewMockEventProducer(t)
 	usedQ := mocks.NewMockEventProducer(t)
 	keyGen := mocks.NewMockKeyGenerator(t)
+	campaigns := mocks.NewMockCampaignRepo(t)
+	service := NewAlias(expiredQ, usedQ, repo, keyGen, campaigns)
+	service.now = func() time.Time { return testNow }
 	return &TestHelper{
-		expiredQ: expiredQ,
-		usedQ:    usedQ,
-		repo:     repo,
-		keyGen:   keyGen,
-		service:  NewAlias(expiredQ, usedQ, repo, keyGen)}
+		expiredQ:  expiredQ,
+		usedQ:     usedQ,
+		repo:      repo,
+		keyGen:    keyGen,
+		campaigns: campaigns,
+		service:   service}
 }
 
 func TestAlias_Create(t *testing.T) {
@@ -60,10 +70,16 @@ func TestAlias_Create(t *testing.T) {
 				aliases := make([]domain.Alias, len(args.requests))
 				for index, request := range args.requests {
 					aliases[index] = domain.Alias{
-						Key:      randomKey,
-						URL:      request.URL,
-						IsActive: true,
-						Params:   request.Params,
+						Key:          randomKey,
+						URL:          request.URL,
+						IsActive:     true,
+						Params:       request.Params,
+						RedirectType: domain.RedirectTemporary,
+						Passthrough: domain.PassthroughPolicy{
+							Mode:       domain.PassthroughNone,
+							OnConflict: domain.QueryConflictKeepTarget,
+						},
+						CreatedAt: testNow,
 					}
 				}
 
@@ -89,6 +105,142 @@ func TestAlias_Create(t *testing.T) {
 			},
 			expectErr: assert.AnError,
 		},
+		{
+			name: "create aliases failed due to unknown campaign",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					URL:      &url.URL{Scheme: "http", Host: "host.test"},
+					Params:   domain.TTLParams{IsPermanent: true},
+					Campaign: "spring-sale",
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				th.campaigns.On("Find", args.ctx, "spring-sale").Return(nil, domain.ErrCampaignNotFound)
+				return nil
+			},
+			expectErr: domain.ErrCampaignNotFound,
+		},
+		{
+			name: "create aliases failed due to too short password",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					URL:      &url.URL{Scheme: "http", Host: "host.test"},
+					Params:   domain.TTLParams{IsPermanent: true},
+					Password: "abc",
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				th.keyGen.On("Generate", keyLength).Return("random-key", nil)
+				return nil
+			},
+			expectErr: domain.ErrInvalidPassword,
+		},
+		{
+			name: "create alias with expiration time successfully",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					Key:       "promo",
+					URL:       &url.URL{Scheme: "http", Host: "host.test"},
+					Params:    domain.TTLParams{IsPermanent: true},
+					ExpiresAt: testNow.Add(time.Hour).In(time.FixedZone("UTC+3", 3*60*60)),
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				th.repo.On("ExistingKeys", args.ctx, []string{"promo"}).Return([]string{}, nil)
+				aliases := []domain.Alias{{
+					Key:          "promo",
+					URL:          args.requests[0].URL,
+					IsActive:     true,
+					Params:       args.requests[0].Params,
+					RedirectType: domain.RedirectTemporary,
+					Passthrough: domain.PassthroughPolicy{
+						Mode:       domain.PassthroughNone,
+						OnConflict: domain.QueryConflictKeepTarget,
+					},
+					CreatedAt: testNow,
+					ExpiresAt: testNow.Add(time.Hour),
+				}}
+				th.repo.On("Save", args.ctx, aliases).Return(nil)
+				return aliases
+			},
+		},
+		{
+			name: "create alias failed due to expiration time in the past",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					URL:       &url.URL{Scheme: "http", Host: "host.test"},
+					Params:    domain.TTLParams{IsPermanent: true},
+					ExpiresAt: testNow.Add(-time.Minute),
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				return nil
+			},
+			expectErr: domain.ErrInvalidExpiration,
+		},
+		{
+			name: "create alias with custom key successfully",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					Key:    "promo",
+					URL:    &url.URL{Scheme: "http", Host: "host.test"},
+					Params: domain.TTLParams{IsPermanent: true},
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				th.repo.On("ExistingKeys", args.ctx, []string{"promo"}).Return([]string{}, nil)
+				aliases := []domain.Alias{{
+					Key:          "promo",
+					URL:          args.requests[0].URL,
+					IsActive:     true,
+					Params:       args.requests[0].Params,
+					RedirectType: domain.RedirectTemporary,
+					Passthrough: domain.PassthroughPolicy{
+						Mode:       domain.PassthroughNone,
+						OnConflict: domain.QueryConflictKeepTarget,
+					},
+					CreatedAt: testNow,
+				}}
+				th.repo.On("Save", args.ctx, aliases).Return(nil)
+				return aliases
+			},
+		},
+		{
+			name: "create alias failed due to taken custom key",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					Key:    "promo",
+					URL:    &url.URL{Scheme: "http", Host: "host.test"},
+					Params: domain.TTLParams{IsPermanent: true},
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				th.repo.On("ExistingKeys", args.ctx, []string{"promo"}).Return([]string{"promo"}, nil)
+				return nil
+			},
+			expectErr: domain.ErrKeyAlreadyExists,
+		},
+		{
+			name: "create alias failed due to reserved custom key",
+			args: args{
+				ctx: context.Background(),
+				requests: []domain.CreateRequest{{
+					Key:    "api",
+					URL:    &url.URL{Scheme: "http", Host: "host.test"},
+					Params: domain.TTLParams{IsPermanent: true},
+				}},
+			},
+			mockFunc: func(th *TestHelper, args args) []domain.Alias {
+				return nil
+			},
+			expectErr: domain.ErrInvalidKey,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -105,6 +257,91 @@ func TestAlias_Create(t *testing.T) {
 	}
 }
 
+func TestAlias_redirectType(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name            string
+		defaultRedirect domain.RedirectType
+		request         domain.CreateRequest
+		expected        domain.RedirectType
+		expectErr       error
+	}{
+		{
+			name:            "default redirect type is used when not set",
+			defaultRedirect: domain.RedirectFound,
+			request:         domain.CreateRequest{Params: domain.TTLParams{IsPermanent: true}},
+			expected:        domain.RedirectFound,
+		},
+		{
+			name:            "cacheable default is downgraded for usage-limited alias",
+			defaultRedirect: domain.RedirectMovedPermanently,
+			request:         domain.CreateRequest{Params: domain.TTLParams{TriesLeft: 3}},
+			expected:        domain.RedirectTemporary,
+		},
+		{
+			name:            "explicit redirect type overrides the default",
+			defaultRedirect: domain.RedirectTemporary,
+			request: domain.CreateRequest{
+				Params:       domain.TTLParams{IsPermanent: true},
+				RedirectType: domain.RedirectPermanent,
+			},
+			expected: domain.RedirectPermanent,
+		},
+		{
+			name:            "explicit cacheable redirect type is rejected for usage-limited alias",
+			defaultRedirect: domain.RedirectTemporary,
+			request: domain.CreateRequest{
+				Params:       domain.TTLParams{TriesLeft: 3},
+				RedirectType: domain.RedirectMovedPermanently,
+			},
+			expectErr: domain.ErrCacheableRedirectForLimitedAlias,
+		},
+		{
+			name:            "cacheable default is downgraded for expiring alias",
+			defaultRedirect: domain.RedirectMovedPermanently,
+			request: domain.CreateRequest{
+				Params:    domain.TTLParams{IsPermanent: true},
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+			expected: domain.RedirectTemporary,
+		},
+		{
+			name:            "explicit cacheable redirect type is rejected for expiring alias",
+			defaultRedirect: domain.RedirectTemporary,
+			request: domain.CreateRequest{
+				Params:       domain.TTLParams{IsPermanent: true},
+				RedirectType: domain.RedirectPermanent,
+				ExpiresAt:    time.Now().Add(time.Hour),
+			},
+			expectErr: domain.ErrCacheableRedirectForLimitedAlias,
+		},
+		{
+			name:            "unsupported redirect type is rejected",
+			defaultRedirect: domain.RedirectTemporary,
+			request: domain.CreateRequest{
+				Params:       domain.TTLParams{IsPermanent: true},
+				RedirectType: domain.RedirectType(303),
+			},
+			expectErr: domain.ErrInvalidRedirectType,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			th := NewTestHelper(t)
+			require.NoError(t, th.service.SetDefaultRedirectType(testCase.defaultRedirect))
+
+			got, gotErr := th.service.redirectType(testCase.request)
+			require.ErrorIs(t, gotErr, testCase.expectErr)
+			if testCase.expectErr == nil {
+				require.Equal(t, testCase.expected, got)
+			}
+		})
+	}
+}
+
 func TestAlias_FindOriginalURL(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -162,44 +399,216 @@ func TestAlias_FindOriginalURL(t *testing.T) {
 func TestAlias_Use(t *testing.T) {
 	t.Parallel()
 
+	appStoreURL := &url.URL{Scheme: "https", Host: "apps.apple.test", Path: "/app"}
+	germanURL := &url.URL{Scheme: "https", Host: "host.test", Path: "/de"}
+	targeted := TestAlias(t, true)
+	targeted.Rules = []domain.RedirectRule{
+		{Name: "ios", Platforms: []domain.Platform{domain.PlatformIOS}, URL: appStoreURL},
+		{Name: "german", Languages: []string{"de"}, URL: germanURL},
+	}
+
+	controlURL := &url.URL{Scheme: "https", Host: "host.test", Path: "/a"}
+	treatmentURL := &url.URL{Scheme: "https", Host: "host.test", Path: "/b"}
+	split := targeted
+	split.Variants = []domain.Variant{
+		{Name: "control", URL: controlURL, Weight: 3},
+		{Name: "treatment", URL: treatmentURL, Weight: 1},
+	}
+
+	disabled := TestAlias(t, true)
+	disabled.Disabled = true
+	scheduled := TestAlias(t, false)
+	scheduled.ActivatesAt = testNow.Add(time.Hour)
+	activated := TestAlias(t, false)
+	activated.ActivatesAt = testNow
+	outdated := TestAlias(t, true)
+	outdated.ExpiresAt = testNow
+	reaped := TestExpiredAlias(t)
+	reaped.ExpiredAt = testNow.Add(-time.Hour)
+
 	testData := []domain.Alias{
 		TestExpiredAlias(t),
 		TestAlias(t, false),
 		TestAlias(t, true),
+		targeted,
+		split,
+		disabled,
+		scheduled,
+		activated,
+		outdated,
+		reaped,
 	}
 
 	type args struct {
-		ctx   context.Context
-		alias *domain.Alias
+		ctx     context.Context
+		alias   *domain.Alias
+		visitor domain.Visitor
+		random  int
+		blocked []string
 	}
 	tests := []struct {
 		name      string
 		args      args
-		mockFunc  func(*TestHelper, args) *domain.Alias
+		mockFunc  func(*TestHelper, args) *domain.Visit
 		expectErr error
 	}{
 		{
 			name: "use expired alias",
 			args: args{ctx: context.Background(), alias: &testData[0]},
-			mockFunc: func(th *TestHelper, args args) *domain.Alias {
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
 				th.expiredQ.On("Produce", mock.AnythingOfType("AliasExpired"))
 				return nil
 			},
 			expectErr: domain.ErrAliasExpired,
 		},
+		{
+			name: "use alias at its expiration time",
+			args: args{ctx: context.Background(), alias: &testData[8]},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.expiredQ.On("Produce", mock.AnythingOfType("AliasExpired"))
+				return nil
+			},
+			expectErr: domain.ErrAliasExpired,
+		},
+		{
+			name: "use alias deactivated by the reaper",
+			args: args{ctx: context.Background(), alias: &testData[9]},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				// the reaper has published the event already
+				return nil
+			},
+			expectErr: domain.ErrAliasExpired,
+		},
+		{
+			name: "use disabled alias",
+			args: args{ctx: context.Background(), alias: &testData[5]},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				return nil
+			},
+			expectErr: domain.ErrAliasDisabled,
+		},
+		{
+			name: "use alias before its activation",
+			args: args{ctx: context.Background(), alias: &testData[6]},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				return nil
+			},
+			expectErr: domain.ErrAliasNotActive,
+		},
+		{
+			name: "use alias at its activation time",
+			args: args{ctx: context.Background(), alias: &testData[7]},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.AnythingOfType("AliasUsed"))
+				return &domain.Visit{Alias: args.alias}
+			},
+		},
+		{
+			name: "destination of the visitor is blocked",
+			args: args{ctx: context.Background(), alias: &testData[3], blocked: []string{"apple.test"}, visitor: domain.Visitor{
+				UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			}},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				return nil
+			},
+			expectErr: domain.ErrDestinationBlocked,
+		},
 		{
 			name: "use valid alias with ttl successfully",
 			args: args{ctx: context.Background(), alias: &testData[1]},
-			mockFunc: func(th *TestHelper, args args) *domain.Alias {
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
 				th.usedQ.On("Produce", mock.AnythingOfType("AliasUsed"))
-				return args.alias
+				return &domain.Visit{Alias: args.alias}
 			},
 		},
 		{
 			name: "use valid permanent alias",
 			args: args{ctx: context.Background(), alias: &testData[2]},
-			mockFunc: func(th *TestHelper, args args) *domain.Alias {
-				return args.alias
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.AnythingOfType("AliasUsed"))
+				return &domain.Visit{Alias: args.alias}
+			},
+		},
+		{
+			name: "first matching rule wins",
+			args: args{ctx: context.Background(), alias: &testData[3], visitor: domain.Visitor{
+				UserAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+				AcceptLanguage: "de-DE,de;q=0.9",
+			}},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.MatchedBy(func(event domain.AliasUsed) bool {
+					return event.Rule == "ios"
+				}))
+				expected := *args.alias
+				expected.URL = appStoreURL
+				return &domain.Visit{Alias: &expected, Rule: "ios"}
+			},
+		},
+		{
+			name: "language rule matches",
+			args: args{ctx: context.Background(), alias: &testData[3], visitor: domain.Visitor{
+				UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+				AcceptLanguage: "de-AT,en;q=0.5",
+			}},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.MatchedBy(func(event domain.AliasUsed) bool {
+					return event.Rule == "german"
+				}))
+				expected := *args.alias
+				expected.URL = germanURL
+				return &domain.Visit{Alias: &expected, Rule: "german"}
+			},
+		},
+		{
+			name: "fallback url is used when no rule matches",
+			args: args{ctx: context.Background(), alias: &testData[3], visitor: domain.Visitor{
+				UserAgent:      "Mozilla/5.0 (X11; Linux x86_64)",
+				AcceptLanguage: "en-US",
+			}},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.MatchedBy(func(event domain.AliasUsed) bool {
+					return event.Rule == ""
+				}))
+				return &domain.Visit{Alias: args.alias}
+			},
+		},
+		{
+			name: "new visitor is assigned a variant by weight",
+			args: args{ctx: context.Background(), alias: &testData[4], random: 3},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.MatchedBy(func(event domain.AliasUsed) bool {
+					return event.Rule == "" && event.Variant == "treatment"
+				}))
+				expected := *args.alias
+				expected.URL = treatmentURL
+				return &domain.Visit{Alias: &expected, Variant: "treatment"}
+			},
+		},
+		{
+			name: "returning visitor keeps the variant",
+			args: args{ctx: context.Background(), alias: &testData[4], random: 3, visitor: domain.Visitor{Variant: "control"}},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.MatchedBy(func(event domain.AliasUsed) bool {
+					return event.Variant == "control"
+				}))
+				expected := *args.alias
+				expected.URL = controlURL
+				return &domain.Visit{Alias: &expected, Variant: "control"}
+			},
+		},
+		{
+			name: "matching rule takes precedence over variants",
+			args: args{ctx: context.Background(), alias: &testData[4], visitor: domain.Visitor{
+				UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+				Variant:   "control",
+			}},
+			mockFunc: func(th *TestHelper, args args) *domain.Visit {
+				th.usedQ.On("Produce", mock.MatchedBy(func(event domain.AliasUsed) bool {
+					return event.Rule == "ios" && event.Variant == ""
+				}))
+				expected := *args.alias
+				expected.URL = appStoreURL
+				return &domain.Visit{Alias: &expected, Rule: "ios"}
 			},
 		},
 	}
@@ -208,14 +617,379 @@ func TestAlias_Use(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			th := NewTestHelper(t)
+			th.service.random = func(int) int { return tt.args.random }
+			th.service.SetBlockedDomains(tt.args.blocked)
 			wants := tt.mockFunc(th, tt.args)
-			got, err := th.service.Use(tt.args.ctx, tt.args.alias)
+			got, err := th.service.Use(tt.args.ctx, tt.args.alias, tt.args.visitor)
 			assert.Equal(t, wants, got)
 			assert.ErrorIs(t, err, tt.expectErr)
 		})
 	}
 }
 
+func TestAlias_CheckPassword(t *testing.T) {
+	t.Parallel()
+	hash, err := domain.HashPassword("secret")
+	require.NoError(t, err)
+	protected := &domain.Alias{Key: "key", PasswordHash: hash}
+
+	th := NewTestHelper(t)
+	require.NoError(t, th.service.CheckPassword(context.Background(), protected, "secret"))
+	require.ErrorIs(t, th.service.CheckPassword(context.Background(), protected, "guess"), domain.ErrWrongPassword)
+	require.NoError(t, th.service.CheckPassword(context.Background(), &domain.Alias{Key: "key"}, ""))
+}
+
+func TestAlias_Preview(t *testing.T) {
+	t.Parallel()
+	hash, err := domain.HashPassword("secret")
+	require.NoError(t, err)
+	appStoreURL := &url.URL{Scheme: "https", Host: "apps.apple.test", Path: "/app"}
+	variantA := &url.URL{Scheme: "https", Host: "a.test", Path: "/"}
+	variantB := &url.URL{Scheme: "https", Host: "b.test", Path: "/"}
+	base := domain.Alias{
+		Key:         "key",
+		URL:         &url.URL{Scheme: "https", Host: "host.test", Path: "/page"},
+		Params:      domain.TTLParams{TriesLeft: 3},
+		Passthrough: domain.PassthroughPolicy{Mode: domain.PassthroughQuery},
+		CreatedAt:   testNow,
+		Rules:       []domain.RedirectRule{{Name: "ios", Platforms: []domain.Platform{domain.PlatformIOS}, URL: appStoreURL}},
+	}
+	split := base
+	split.Variants = []domain.Variant{{Name: "a", URL: variantA, Weight: 1}, {Name: "b", URL: variantB, Weight: 1}}
+	protected := base
+	protected.PasswordHash = hash
+	exhausted := base
+	exhausted.Params.TriesLeft = 0
+	disabled := base
+	disabled.Disabled = true
+
+	tests := []struct {
+		name      string
+		alias     domain.Alias
+		visitor   domain.Visitor
+		blocked   []string
+		expected  []string
+		expectErr error
+	}{
+		{name: "fallback url with passthrough", alias: base, expected: []string{"https://host.test/page?utm_source=visitor"}},
+		{
+			name:     "matching rule",
+			alias:    base,
+			visitor:  domain.Visitor{UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"},
+			expected: []string{"https://apps.apple.test/app?utm_source=visitor"},
+		},
+		{name: "assigned variant", alias: split, visitor: domain.Visitor{Variant: "b"}, expected: []string{"https://b.test/?utm_source=visitor"}},
+		{name: "all variants of new visitor", alias: split, expected: []string{"https://a.test/?utm_source=visitor", "https://b.test/?utm_source=visitor"}},
+		{name: "protected alias hides destination", alias: protected},
+		{name: "exhausted alias", alias: exhausted, expectErr: domain.ErrAliasExpired},
+		{name: "disabled alias", alias: disabled, expectErr: domain.ErrAliasDisabled},
+		{name: "one of the variants is blocked", alias: split, blocked: []string{"b.test"}, expectErr: domain.ErrDestinationBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			th.service.SetBlockedDomains(tt.blocked)
+			got, err := th.service.Preview(context.Background(), &tt.alias, tt.visitor, "", url.Values{"utm_source": {"visitor"}})
+			require.ErrorIs(t, err, tt.expectErr)
+			if tt.expectErr != nil {
+				return
+			}
+			require.Equal(t, tt.alias.IsProtected(), got.Protected)
+			require.Equal(t, 3, got.TriesLeft)
+			require.Equal(t, testNow, got.CreatedAt)
+			destinations := make([]string, len(got.Destinations))
+			for index, destination := range got.Destinations {
+				destinations[index] = destination.String()
+			}
+			require.ElementsMatch(t, tt.expected, destinations)
+		})
+	}
+}
+
+func TestAlias_RedirectURL(t *testing.T) {
+	t.Parallel()
+	campaign := &domain.Campaign{
+		Name:   "spring-sale",
+		Params: map[string]string{"utm_source": "newsletter", "utm_campaign": "spring"},
+	}
+
+	tests := []struct {
+		name      string
+		campaign  string
+		mockFunc  func(*TestHelper)
+		expected  string
+		expectErr error
+	}{
+		{
+			name:     "alias without campaign",
+			expected: "http://host.test/page?utm_source=visitor",
+		},
+		{
+			name:     "campaign parameters are applied",
+			campaign: campaign.Name,
+			mockFunc: func(th *TestHelper) {
+				th.campaigns.On("Find", mock.Anything, campaign.Name).Return(campaign, nil)
+			},
+			expected: "http://host.test/page?utm_campaign=spring&utm_source=newsletter",
+		},
+		{
+			name:     "removed campaign is ignored",
+			campaign: campaign.Name,
+			mockFunc: func(th *TestHelper) {
+				th.campaigns.On("Find", mock.Anything, campaign.Name).Return(nil, domain.ErrCampaignNotFound)
+			},
+			expected: "http://host.test/page?utm_source=visitor",
+		},
+		{
+			name:     "campaign lookup failure",
+			campaign: campaign.Name,
+			mockFunc: func(th *TestHelper) {
+				th.campaigns.On("Find", mock.Anything, campaign.Name).Return(nil, assert.AnError)
+			},
+			expectErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			if tt.mockFunc != nil {
+				tt.mockFunc(th)
+			}
+			alias := &domain.Alias{
+				Key:         "key",
+				URL:         &url.URL{Scheme: "http", Host: "host.test", Path: "/page"},
+				Passthrough: domain.PassthroughPolicy{Mode: domain.PassthroughQuery},
+				Campaign:    tt.campaign,
+			}
+
+			got, err := th.service.RedirectURL(context.Background(), alias, "", url.Values{"utm_source": {"visitor"}})
+			require.ErrorIs(t, err, tt.expectErr)
+			if tt.expectErr == nil {
+				require.Equal(t, tt.expected, got.String())
+			}
+		})
+	}
+}
+
+func TestAlias_SetRules(t *testing.T) {
+	t.Parallel()
+	validRules := []domain.RedirectRule{
+		{Name: "android", Platforms: []domain.Platform{domain.PlatformAndroid}, URL: &url.URL{Scheme: "https", Host: "play.test"}},
+	}
+
+	tests := []struct {
+		name      string
+		rules     []domain.RedirectRule
+		mockFunc  func(*TestHelper, []domain.RedirectRule)
+		expectErr error
+	}{
+		{
+			name:  "rules are saved",
+			rules: validRules,
+			mockFunc: func(th *TestHelper, rules []domain.RedirectRule) {
+				th.repo.On("UpdateRules", mock.Anything, "key", rules).Return(nil)
+			},
+		},
+		{
+			name:  "rules of unknown alias",
+			rules: validRules,
+			mockFunc: func(th *TestHelper, rules []domain.RedirectRule) {
+				th.repo.On("UpdateRules", mock.Anything, "key", rules).Return(domain.ErrAliasNotFound)
+			},
+			expectErr: domain.ErrAliasNotFound,
+		},
+		{
+			name: "invalid rules are rejected",
+			rules: []domain.RedirectRule{
+				{Name: "no-conditions", URL: &url.URL{Scheme: "https", Host: "host.test"}},
+			},
+			expectErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "rule leading to a blocked domain is rejected",
+			rules: []domain.RedirectRule{
+				{Name: "android", Platforms: []domain.Platform{domain.PlatformAndroid}, URL: &url.URL{Scheme: "https", Host: "www.blocked.test"}},
+			},
+			expectErr: domain.ErrDestinationBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			th.service.SetBlockedDomains([]string{"blocked.test"})
+			if tt.mockFunc != nil {
+				tt.mockFunc(th, tt.rules)
+			}
+			assert.ErrorIs(t, th.service.SetRules(context.Background(), "key", tt.rules), tt.expectErr)
+		})
+	}
+}
+
+func TestAlias_SetVariants(t *testing.T) {
+	t.Parallel()
+	validVariants := []domain.Variant{
+		{Name: "a", URL: &url.URL{Scheme: "https", Host: "a.test"}, Weight: 1},
+		{Name: "b", URL: &url.URL{Scheme: "https", Host: "b.test"}, Weight: 1},
+	}
+
+	tests := []struct {
+		name      string
+		variants  []domain.Variant
+		mockFunc  func(*TestHelper, []domain.Variant)
+		expectErr error
+	}{
+		{
+			name:     "variants are saved",
+			variants: validVariants,
+			mockFunc: func(th *TestHelper, variants []domain.Variant) {
+				th.repo.On("UpdateVariants", mock.Anything, "key", variants).Return(nil)
+			},
+		},
+		{
+			name: "split is turned off",
+			mockFunc: func(th *TestHelper, variants []domain.Variant) {
+				th.repo.On("UpdateVariants", mock.Anything, "key", variants).Return(nil)
+			},
+		},
+		{
+			name:     "variants of unknown alias",
+			variants: validVariants,
+			mockFunc: func(th *TestHelper, variants []domain.Variant) {
+				th.repo.On("UpdateVariants", mock.Anything, "key", variants).Return(domain.ErrAliasNotFound)
+			},
+			expectErr: domain.ErrAliasNotFound,
+		},
+		{
+			name:      "single variant is rejected",
+			variants:  validVariants[:1],
+			expectErr: domain.ErrInvalidVariant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			if tt.mockFunc != nil {
+				tt.mockFunc(th, tt.variants)
+			}
+			assert.ErrorIs(t, th.service.SetVariants(context.Background(), "key", tt.variants), tt.expectErr)
+		})
+	}
+}
+
+func TestAlias_SetDisabled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		disabled  bool
+		repoErr   error
+		expectErr error
+	}{
+		{name: "alias is disabled", disabled: true},
+		{name: "alias is enabled", disabled: false},
+		{name: "unknown alias", disabled: true, repoErr: domain.ErrAliasNotFound, expectErr: domain.ErrAliasNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			th.repo.On("UpdateDisabled", mock.Anything, "key", tt.disabled).Return(tt.repoErr)
+			assert.ErrorIs(t, th.service.SetDisabled(context.Background(), "key", tt.disabled), tt.expectErr)
+		})
+	}
+}
+
+func TestAlias_SetActivation(t *testing.T) {
+	t.Parallel()
+	local := time.Date(2030, 5, 1, 9, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	tests := []struct {
+		name        string
+		activatesAt time.Time
+		stored      time.Time
+		repoErr     error
+		expectErr   error
+	}{
+		{name: "activation is scheduled in UTC", activatesAt: local, stored: local.UTC()},
+		{name: "schedule is cleared", activatesAt: time.Time{}, stored: time.Time{}},
+		{name: "unknown alias", activatesAt: local, stored: local.UTC(), repoErr: domain.ErrAliasNotFound, expectErr: domain.ErrAliasNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			th.repo.On("UpdateActivation", mock.Anything, "key", tt.stored).Return(tt.repoErr)
+			assert.ErrorIs(t, th.service.SetActivation(context.Background(), "key", tt.activatesAt), tt.expectErr)
+		})
+	}
+}
+
+func TestAlias_List(t *testing.T) {
+	t.Parallel()
+	aliases := []domain.Alias{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+
+	tests := []struct {
+		name      string
+		filter    domain.ListFilter
+		repoLimit int
+		found     []domain.Alias
+		expected  *domain.AliasPage
+		expectErr error
+	}{
+		{
+			name:      "last page",
+			filter:    domain.ListFilter{Status: domain.StatusScheduled, Limit: 3},
+			repoLimit: 4,
+			found:     aliases,
+			expected:  &domain.AliasPage{Aliases: aliases},
+		},
+		{
+			name:      "page with the next one",
+			filter:    domain.ListFilter{After: "0", Limit: 2},
+			repoLimit: 3,
+			found:     aliases,
+			expected:  &domain.AliasPage{Aliases: aliases[:2], Next: "b"},
+		},
+		{
+			name:      "default limit",
+			filter:    domain.ListFilter{},
+			repoLimit: domain.DefaultListLimit + 1,
+			found:     aliases,
+			expected:  &domain.AliasPage{Aliases: aliases},
+		},
+		{
+			name:      "invalid filter",
+			filter:    domain.ListFilter{Status: "removed"},
+			expectErr: domain.ErrInvalidListFilter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			if tt.expectErr == nil {
+				repoFilter := tt.filter
+				repoFilter.Limit = tt.repoLimit
+				th.repo.On("List", mock.Anything, repoFilter, testNow).Return(tt.found, nil)
+			}
+			got, err := th.service.List(context.Background(), tt.filter)
+			require.ErrorIs(t, err, tt.expectErr)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
 func TestAlias_Remove(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -233,7 +1007,7 @@ func TestAlias_Remove(t *testing.T) {
 			name: "remove alias successfully",
 			args: args{ctx: context.Background(), key: "lookup-key"},
 			mockFunc: func(th *TestHelper, args args) *domain.Alias {
-				th.repo.On("Remove", args.ctx, args.key).Return(nil)
+				th.repo.On("Remove", args.ctx, args.key, testNow).Return(nil)
 				return nil
 			},
 		},
@@ -241,7 +1015,7 @@ func TestAlias_Remove(t *testing.T) {
 			name: "alias not found on remove",
 			args: args{ctx: context.Background(), key: "lookup-key"},
 			mockFunc: func(th *TestHelper, args args) *domain.Alias {
-				th.repo.On("Remove", args.ctx, args.key).Return(domain.ErrAliasNotFound)
+				th.repo.On("Remove", args.ctx, args.key, testNow).Return(domain.ErrAliasNotFound)
 				return nil
 			},
 			expectErr: domain.ErrAliasNotFound,
@@ -259,3 +1033,248 @@ func TestAlias_Remove(t *testing.T) {
 	}
 
 }
+
+func TestAlias_Restore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		repoErr   error
+		expectErr error
+	}{
+		{name: "restore alias successfully"},
+		{name: "alias is not in the trash", repoErr: domain.ErrAliasNotFound, expectErr: domain.ErrAliasNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			th.repo.On("Restore", mock.Anything, "lookup-key").Return(tt.repoErr)
+			assert.ErrorIs(t, th.service.Restore(context.Background(), "lookup-key"), tt.expectErr)
+		})
+	}
+}
+
+func TestAlias_PurgeTrash(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		purged    int64
+		repoErr   error
+		expectErr error
+	}{
+		{name: "aliases deleted before the retention period are purged", purged: 3},
+		{name: "storage failure", repoErr: assert.AnError, expectErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			th := NewTestHelper(t)
+			th.repo.On("Purge", mock.Anything, testNow.Add(-24*time.Hour)).Return(tt.purged, tt.repoErr)
+			purged, err := th.service.PurgeTrash(context.Background(), 24*time.Hour)
+			require.ErrorIs(t, err, tt.expectErr)
+			assert.Equal(t, tt.purged, purged)
+		})
+	}
+}
+
+func TestAlias_ReapExpired(t *testing.T) {
+	t.Parallel()
+
+	t.Run("aliases are reaped in batches", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.repo.On("Expire", mock.Anything, testNow, 2).
+			Return([]domain.Alias{{Key: "a"}, {Key: "b"}}, nil).Once()
+		th.repo.On("Expire", mock.Anything, testNow, 2).
+			Return([]domain.Alias{{Key: "c"}}, nil).Once()
+		th.expiredQ.On("Produce", mock.AnythingOfType("AliasExpired")).Times(3)
+
+		reaped, err := th.service.ReapExpired(context.Background(), 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, reaped)
+		th.repo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("storage failure stops the reaping", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.repo.On("Expire", mock.Anything, testNow, 2).
+			Return([]domain.Alias{{Key: "a"}, {Key: "b"}}, nil).Once()
+		th.repo.On("Expire", mock.Anything, testNow, 2).Return(nil, assert.AnError).Once()
+		th.expiredQ.On("Produce", mock.AnythingOfType("AliasExpired")).Times(2)
+
+		reaped, err := th.service.ReapExpired(context.Background(), 2)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 2, reaped)
+	})
+}
+
+// rowSource is an import source reading the rows of a slice
+type rowSource struct {
+	rows []domain.ImportRow
+	err  error
+}
+
+func (s *rowSource) Next() (domain.ImportRow, error) {
+	if len(s.rows) == 0 {
+		if s.err != nil {
+			return domain.ImportRow{}, s.err
+		}
+		return domain.ImportRow{}, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func TestAlias_Import(t *testing.T) {
+	t.Parallel()
+	target, _ := url.Parse("https://example.com")
+	request := domain.CreateRequest{URL: target, Params: domain.TTLParams{IsPermanent: true}}
+	rows := func() []domain.ImportRow {
+		return []domain.ImportRow{
+			{Line: 2, Key: "promo", Request: request},
+			{Line: 3, Request: request},
+			{Line: 4, Err: assert.AnError},
+			{Line: 5, Key: "api", Request: request},
+			{Line: 6, Key: "promo", Request: request},
+			{Line: 7, Key: "taken", Request: request},
+			{Line: 8, Key: "retry", Request: domain.CreateRequest{URL: target, RedirectType: domain.RedirectType(303)}},
+			{Line: 9, Key: "retry", Request: request},
+		}
+	}
+	failed := []domain.ImportResult{
+		{Line: 4, Status: domain.ImportFailed, Err: assert.AnError},
+		{Line: 5, Key: "api", Status: domain.ImportFailed, Err: domain.ErrInvalidKey},
+		{Line: 6, Key: "promo", Status: domain.ImportFailed, Err: domain.ErrKeyAlreadyExists},
+		{Line: 7, Key: "taken", Status: domain.ImportFailed, Err: domain.ErrKeyAlreadyExists},
+		// the rejected row does not take the key of the next one
+		{Line: 8, Key: "retry", Status: domain.ImportFailed, Err: domain.ErrInvalidRedirectType},
+	}
+
+	check := func(t *testing.T, expected []domain.ImportResult, got []domain.ImportResult) {
+		t.Helper()
+		require.Len(t, got, len(expected))
+		for index := range expected {
+			assert.Equal(t, expected[index].Line, got[index].Line)
+			assert.Equal(t, expected[index].Key, got[index].Key)
+			assert.Equal(t, expected[index].Status, got[index].Status)
+			assert.ErrorIs(t, got[index].Err, expected[index].Err)
+		}
+	}
+
+	t.Run("valid rows are saved, invalid ones are reported", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.repo.On("ExistingKeys", mock.Anything, []string{"promo", "taken", "retry"}).Return([]string{"taken"}, nil)
+		th.keyGen.On("Generate", keyLength).Return("generated", nil).Once()
+		th.repo.On("Save", mock.Anything, mock.MatchedBy(func(aliases []domain.Alias) bool {
+			return len(aliases) == 3 && aliases[0].Key == "promo" && aliases[1].Key == "generated" &&
+				aliases[2].Key == "retry" && aliases[0].CreatedAt.Equal(testNow) && aliases[1].IsActive
+		})).Return(nil).Once()
+
+		var reported []domain.ImportResult
+		summary, err := th.service.Import(context.Background(), &rowSource{rows: rows()}, domain.ImportOptions{},
+			func(results []domain.ImportResult) error {
+				reported = append(reported, results...)
+				return nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, &domain.ImportSummary{Rows: 8, Imported: 3, Failed: 5}, summary)
+		check(t, append(append([]domain.ImportResult{
+			{Line: 2, Key: "promo", Status: domain.ImportCreated},
+			{Line: 3, Key: "generated", Status: domain.ImportCreated},
+		}, failed...), domain.ImportResult{Line: 9, Key: "retry", Status: domain.ImportCreated}), reported)
+	})
+
+	t.Run("nothing is saved in dry-run mode", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.repo.On("ExistingKeys", mock.Anything, []string{"promo", "taken", "retry"}).Return([]string{"taken"}, nil)
+
+		var reported []domain.ImportResult
+		summary, err := th.service.Import(context.Background(), &rowSource{rows: rows()},
+			domain.ImportOptions{DryRun: true}, func(results []domain.ImportResult) error {
+				reported = append(reported, results...)
+				return nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, &domain.ImportSummary{Rows: 8, Imported: 3, Failed: 5, DryRun: true}, summary)
+		check(t, append(append([]domain.ImportResult{
+			{Line: 2, Key: "promo", Status: domain.ImportValid},
+			{Line: 3, Status: domain.ImportValid},
+		}, failed...), domain.ImportResult{Line: 9, Key: "retry", Status: domain.ImportValid}), reported)
+	})
+
+	t.Run("source failure aborts the import", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		source := &rowSource{err: assert.AnError}
+
+		summary, err := th.service.Import(context.Background(), source, domain.ImportOptions{},
+			func([]domain.ImportResult) error {
+				t.Fatal("nothing must be reported")
+				return nil
+			})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 0, summary.Rows)
+	})
+
+	t.Run("storage failure aborts the import", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.keyGen.On("Generate", keyLength).Return("generated", nil)
+		th.repo.On("Save", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		_, err := th.service.Import(context.Background(),
+			&rowSource{rows: []domain.ImportRow{{Line: 1, Request: request}}}, domain.ImportOptions{},
+			func([]domain.ImportResult) error { return nil })
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestAlias_ExportAliases(t *testing.T) {
+	t.Parallel()
+	first := domain.ListFilter{Campaign: "spring", Limit: 2}
+	second := domain.ListFilter{Campaign: "spring", After: "b", Limit: 2}
+
+	t.Run("aliases are exported page by page", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.repo.On("List", mock.Anything, first, testNow).Return([]domain.Alias{{Key: "a"}, {Key: "b"}}, nil)
+		th.repo.On("List", mock.Anything, second, testNow).Return([]domain.Alias{}, nil)
+
+		var keys []string
+		exported, err := th.service.ExportAliases(context.Background(), first, func(aliases []domain.Alias) error {
+			for _, alias := range aliases {
+				keys = append(keys, alias.Key)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, exported)
+		assert.Equal(t, []string{"a", "b"}, keys)
+	})
+
+	t.Run("emit failure stops the export", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		th.repo.On("List", mock.Anything, first, testNow).Return([]domain.Alias{{Key: "a"}, {Key: "b"}}, nil)
+
+		exported, err := th.service.ExportAliases(context.Background(), first, func([]domain.Alias) error {
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 0, exported)
+	})
+
+	t.Run("invalid filter is rejected", func(t *testing.T) {
+		t.Parallel()
+		th := NewTestHelper(t)
+		_, err := th.service.ExportAliases(context.Background(), domain.ListFilter{Status: "unknown"},
+			func([]domain.Alias) error { return nil })
+		assert.ErrorIs(t, err, domain.ErrInvalidListFilter)
+	})
+}
@@ -0,0 +1,91 @@
+package campaignsvc
+
+import (
+	"context"
+	"fmt"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"time"
+)
+
+type campaignRepo interface {
+	Save(ctx context.Context, campaign domain.Campaign) error
+	Find(ctx context.Context, name string) (*domain.Campaign, error)
+	List(ctx context.Context) ([]domain.Campaign, error)
+	Remove(ctx context.Context, name string) error
+}
+
+type Campaign struct {
+	repo campaignRepo
+}
+
+// NewCampaign creates a new campaign service
+func NewCampaign(repo campaignRepo) *Campaign {
+	return &Campaign{repo: repo}
+}
+
+func (s *Campaign) Name() string {
+	return "Campaign"
+}
+
+// Create validates and saves a new campaign
+func (s *Campaign) Create(ctx context.Context, campaign domain.Campaign) (*domain.Campaign, error) {
+	const fn = "Create"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", campaign.Name))
+
+	if err := campaign.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	campaign.CreatedAt = time.Now().UTC()
+	if err := s.repo.Save(ctx, campaign); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return &campaign, nil
+}
+
+// Find returns the campaign by name
+func (s *Campaign) Find(ctx context.Context, name string) (*domain.Campaign, error) {
+	const fn = "Find"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", name))
+
+	campaign, err := s.repo.Find(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return campaign, nil
+}
+
+// List returns all campaigns
+func (s *Campaign) List(ctx context.Context) ([]domain.Campaign, error) {
+	const fn = "List"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn))
+
+	campaigns, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return campaigns, nil
+}
+
+// Remove removes the campaign. Aliases attached to it are redirected without the campaign parameters.
+func (s *Campaign) Remove(ctx context.Context, name string) error {
+	const fn = "Remove"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("campaign", name))
+
+	if err := s.repo.Remove(ctx, name); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
@@ -3,9 +3,16 @@ package managersvc
 import (
 	"context"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"sync/atomic"
+	"time"
 )
 
+const heartbeatInterval = time.Second
+
 type aliasRepository interface {
 	DecreaseTTLCounter(ctx context.Context, key string) error
 }
@@ -15,12 +22,15 @@ type eventConsumer interface {
 }
 
 type Manager struct {
+	heartbeat atomic.Int64
+	done      chan struct{}
 	consumer  eventConsumer
 	aliasRepo aliasRepository
 }
 
 func NewManager(aliasRepo aliasRepository, consumer eventConsumer) *Manager {
 	return &Manager{
+		done:      make(chan struct{}),
 		consumer:  consumer,
 		aliasRepo: aliasRepo,
 	}
@@ -30,18 +40,66 @@ func (m *Manager) Name() string {
 	return "Manager"
 }
 
+// Process consumes events in background, beating the heartbeat while the consumer is alive.
+// It stops when the queue is closed and drained, or when ctx is done.
 func (m *Manager) Process(ctx context.Context) {
 	go func() {
-		for event := range m.consumer.Consume() {
-			m.processEvent(ctx, event)
+		defer close(m.done)
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		m.beat()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-m.consumer.Consume():
+				if !ok {
+					return
+				}
+				m.processEvent(ctx, event)
+				m.beat()
+			case <-ticker.C:
+				m.beat()
+			}
 		}
 	}()
 }
 
+// Wait waits until the event consumer stops or ctx is done
+func (m *Manager) Wait(ctx context.Context) error {
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) beat() {
+	m.heartbeat.Store(time.Now().UnixNano())
+}
+
+// Heartbeat returns the time the event consumer was last seen alive
+func (m *Manager) Heartbeat() time.Time {
+	last := m.heartbeat.Load()
+	if last == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, last)
+}
+
 func (m *Manager) processEvent(ctx context.Context, msg any) {
 	const fn = "processEvent"
 	event := msg.(domain.AliasUsed)
-	zap.S().Infow("service",
+
+	ctx, span := tracing.Start(logging.WithRequestID(tracing.Extract(ctx, event.TraceContext), event.RequestID), m.Name()+"."+fn,
+		attribute.String("event", event.String()),
+		attribute.String("alias.key", event.Key))
+	defer span.End()
+
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", m.Name()),
 		zap.String("fn", fn),
 		zap.String("received", event.String()),
@@ -50,7 +108,7 @@ func (m *Manager) processEvent(ctx context.Context, msg any) {
 
 	if !event.Params.IsPermanent {
 		if err := m.aliasRepo.DecreaseTTLCounter(ctx, event.Alias.Key); err != nil {
-			zap.S().Errorw("service",
+			logging.FromContext(ctx).Errorw("service",
 				zap.String("name", m.Name()),
 				zap.String("fn", fn),
 				zap.String("error", err.Error()))
@@ -2,12 +2,24 @@ package statssvc
 
 import (
 	"context"
+	"fmt"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"github.com/xloki21/alias/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"sync/atomic"
+	"time"
 )
 
+const heartbeatInterval = time.Second
+
 type statsRepository interface {
 	PushStats(ctx context.Context, event domain.AliasExpired) error
+	RecordClick(ctx context.Context, event domain.AliasUsed) error
+	Clicks(ctx context.Context, key string) (*domain.ClickStats, error)
+	ListClicks(ctx context.Context, filter domain.ClickFilter) ([]domain.Click, error)
+	PurgeClicks(ctx context.Context, occurredBefore time.Time) (int64, error)
 }
 
 type eventConsumer interface {
@@ -15,7 +27,10 @@ type eventConsumer interface {
 }
 
 type Statistics struct {
+	heartbeat atomic.Int64
+	done      chan struct{}
 	consumer  eventConsumer
+	clicks    eventConsumer
 	statsRepo statsRepository
 }
 
@@ -23,36 +38,190 @@ func (s *Statistics) Name() string {
 	return "Statistics"
 }
 
+// Process consumes expiration and click events in background, beating the heartbeat while the consumer is alive.
+// It stops when both queues are closed and drained, or when ctx is done.
 func (s *Statistics) Process(ctx context.Context) {
 	go func() {
-		for event := range s.consumer.Consume() {
-			s.processEvent(ctx, event)
+		defer close(s.done)
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		// a closed queue is replaced with nil channel which is never selected
+		expired, clicks := s.consumer.Consume(), s.clicks.Consume()
+
+		s.beat()
+		for expired != nil || clicks != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-expired:
+				if !ok {
+					expired = nil
+					continue
+				}
+				s.processEvent(ctx, event)
+				s.beat()
+			case event, ok := <-clicks:
+				if !ok {
+					clicks = nil
+					continue
+				}
+				s.processClick(ctx, event)
+				s.beat()
+			case <-ticker.C:
+				s.beat()
+			}
 		}
 	}()
 }
 
+// Wait waits until the event consumer stops or ctx is done
+func (s *Statistics) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Statistics) beat() {
+	s.heartbeat.Store(time.Now().UnixNano())
+}
+
+// Heartbeat returns the time the event consumer was last seen alive
+func (s *Statistics) Heartbeat() time.Time {
+	last := s.heartbeat.Load()
+	if last == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, last)
+}
+
 func (s *Statistics) processEvent(ctx context.Context, msg any) {
 	const fn = "processEvent"
 	event := msg.(domain.AliasExpired)
-	zap.S().Infow("service",
+
+	ctx, span := tracing.Start(logging.WithRequestID(tracing.Extract(ctx, event.TraceContext), event.RequestID), s.Name()+"."+fn,
+		attribute.String("event", event.String()),
+		attribute.String("alias.key", event.Key),
+		attribute.String("alias.campaign", event.Campaign))
+	defer span.End()
+
+	logging.FromContext(ctx).Infow("service",
 		zap.String("name", s.Name()),
 		zap.String("fn", fn),
 		zap.String("received", event.String()),
 		zap.String("alias key", event.Key),
+		zap.String("campaign", event.Campaign),
 	)
 
 	err := s.statsRepo.PushStats(ctx, event)
 	if err != nil {
-		zap.S().Errorw("service",
+		logging.FromContext(ctx).Errorw("service",
+			zap.String("name", s.Name()),
+			zap.String("fn", fn),
+			zap.String("error", err.Error()))
+	}
+}
+
+func (s *Statistics) processClick(ctx context.Context, msg any) {
+	const fn = "processClick"
+	event := msg.(domain.AliasUsed)
+
+	ctx, span := tracing.Start(logging.WithRequestID(tracing.Extract(ctx, event.TraceContext), event.RequestID), s.Name()+"."+fn,
+		attribute.String("event", event.String()),
+		attribute.String("alias.key", event.Key),
+		attribute.String("alias.rule", event.Rule),
+		attribute.String("alias.variant", event.Variant))
+	defer span.End()
+
+	if err := s.statsRepo.RecordClick(ctx, event); err != nil {
+		logging.FromContext(ctx).Errorw("service",
 			zap.String("name", s.Name()),
 			zap.String("fn", fn),
 			zap.String("error", err.Error()))
 	}
 }
 
-func NewStatistics(statsRepo statsRepository, consumer eventConsumer) *Statistics {
+// Clicks returns the click counters of the alias
+func (s *Statistics) Clicks(ctx context.Context, key string) (*domain.ClickStats, error) {
+	const fn = "Clicks"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", key))
+
+	stats, err := s.statsRepo.Clicks(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return stats, nil
+}
+
+// ExportClicks passes all the clicks of the log matching the filter to emit page by page, in the order of recording.
+// The filter limit is the page size, MaxListLimit if zero, and the filter cursor is the ID the export starts after.
+// An error of emit stops the export. It returns the number of the exported clicks.
+func (s *Statistics) ExportClicks(ctx context.Context, filter domain.ClickFilter, emit func([]domain.Click) error) (int, error) {
+	const fn = "ExportClicks"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.String("key", filter.Key),
+		zap.String("campaign", filter.Campaign),
+		zap.Time("from", filter.From),
+		zap.Time("to", filter.To))
+
+	if err := filter.Validate(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = domain.MaxListLimit
+	}
+	exported := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return exported, fmt.Errorf("%s: %w", fn, err)
+		}
+		clicks, err := s.statsRepo.ListClicks(ctx, filter)
+		if err != nil {
+			return exported, fmt.Errorf("%s: %w", fn, err)
+		}
+		if len(clicks) > 0 {
+			if err := emit(clicks); err != nil {
+				return exported, fmt.Errorf("%s: %w", fn, err)
+			}
+			exported += len(clicks)
+		}
+		if len(clicks) < filter.Limit {
+			return exported, nil
+		}
+		filter.After = clicks[len(clicks)-1].ID
+	}
+}
+
+// PurgeClicks deletes the clicks of the log older than the retention period, the click counters are kept
+func (s *Statistics) PurgeClicks(ctx context.Context, retention time.Duration) (int64, error) {
+	const fn = "PurgeClicks"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", s.Name()),
+		zap.String("fn", fn),
+		zap.Duration("retention", retention))
+
+	purged, err := s.statsRepo.PurgeClicks(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	return purged, nil
+}
+
+// NewStatistics creates a new statistics service consuming expiration events and click (alias used) events
+func NewStatistics(statsRepo statsRepository, consumer eventConsumer, clicks eventConsumer) *Statistics {
 	return &Statistics{
+		done:      make(chan struct{}),
 		consumer:  consumer,
+		clicks:    clicks,
 		statsRepo: statsRepo,
 	}
 }
@@ -0,0 +1,268 @@
+package transfersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"maps"
+	"slices"
+)
+
+type aliasRepository interface {
+	Scan(ctx context.Context, after string, limit int) ([]domain.Alias, error)
+	ExistingKeys(ctx context.Context, keys []string) ([]string, error)
+	Save(ctx context.Context, aliases []domain.Alias) error
+}
+
+type campaignRepository interface {
+	List(ctx context.Context) ([]domain.Campaign, error)
+	Save(ctx context.Context, campaign domain.Campaign) error
+}
+
+type statsRepository interface {
+	Clicks(ctx context.Context, key string) (*domain.ClickStats, error)
+	SetClicks(ctx context.Context, stats domain.ClickStats) error
+	ListClicks(ctx context.Context, filter domain.ClickFilter) ([]domain.Click, error)
+	AppendClicks(ctx context.Context, clicks []domain.Click) error
+	ListExpirations(ctx context.Context, after string, limit int) ([]domain.Expiration, error)
+	AppendExpirations(ctx context.Context, expirations []domain.Expiration) error
+}
+
+// Storage is the repositories of a storage taking part in a transfer
+type Storage struct {
+	Aliases   aliasRepository
+	Campaigns campaignRepository
+	Stats     statsRepository
+}
+
+// Stage is a part of the storage copied as a whole
+type Stage string
+
+const (
+	StageCampaigns Stage = "campaigns"
+	StageAliases   Stage = "aliases"
+	// StageCounters copies the click counters of the copied aliases
+	StageCounters    Stage = "counters"
+	StageClicks      Stage = "clicks"
+	StageExpirations Stage = "expirations"
+	// StageDone is the stage of a finished transfer
+	StageDone Stage = "done"
+)
+
+// Stages are copied in this order
+var Stages = []Stage{StageCampaigns, StageAliases, StageCounters, StageClicks, StageExpirations}
+
+// Checkpoint is the progress of a transfer, a transfer is resumed from the last saved checkpoint
+type Checkpoint struct {
+	// Stage is the stage being copied, empty for a transfer not started yet
+	Stage Stage `json:"stage"`
+	// After is the key or ID of the last record copied in the stage
+	After   string        `json:"after,omitempty"`
+	Copied  map[Stage]int `json:"copied"`
+	Skipped map[Stage]int `json:"skipped"`
+}
+
+// clone returns a copy of the checkpoint not sharing the counters with it
+func (c Checkpoint) clone() Checkpoint {
+	c.Copied, c.Skipped = maps.Clone(c.Copied), maps.Clone(c.Skipped)
+	if c.Copied == nil {
+		c.Copied = make(map[Stage]int)
+	}
+	if c.Skipped == nil {
+		c.Skipped = make(map[Stage]int)
+	}
+	return c
+}
+
+// batch is the outcome of copying a batch of a stage
+type batch struct {
+	// last is the key or ID of the last record of the batch
+	last    string
+	copied  int
+	skipped int
+	// done is set by the last batch of the stage
+	done bool
+}
+
+type Transfer struct {
+	source    Storage
+	target    Storage
+	batchSize int
+}
+
+// NewTransfer creates a new service copying the source storage to the target one by batches of batchSize records
+func NewTransfer(source, target Storage, batchSize int) *Transfer {
+	return &Transfer{source: source, target: target, batchSize: batchSize}
+}
+
+func (t *Transfer) Name() string {
+	return "Transfer"
+}
+
+// Copy copies the source storage to the target one, continuing from the checkpoint. The checkpoint is passed to save
+// after every batch, the transfer stops on the error returned by save. The records already in the target storage
+// are kept: the aliases and the campaigns with the taken keys and names are skipped, the click counters are replaced,
+// the clicks and the expirations with the taken IDs are skipped by the storages keeping the IDs.
+func (t *Transfer) Copy(ctx context.Context, checkpoint Checkpoint, save func(Checkpoint) error) (Checkpoint, error) {
+	const fn = "Copy"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", t.Name()),
+		zap.String("fn", fn),
+		zap.String("stage", string(checkpoint.Stage)),
+		zap.String("after", checkpoint.After))
+
+	checkpoint = checkpoint.clone()
+	if checkpoint.Stage == "" {
+		checkpoint.Stage = Stages[0]
+	}
+	if checkpoint.Stage != StageDone && !slices.Contains(Stages, checkpoint.Stage) {
+		return checkpoint, fmt.Errorf("%s: unknown stage %q", fn, checkpoint.Stage)
+	}
+
+	for index := slices.Index(Stages, checkpoint.Stage); index >= 0 && index < len(Stages); index++ {
+		stage := Stages[index]
+		for {
+			if err := ctx.Err(); err != nil {
+				return checkpoint, fmt.Errorf("%s: %w", fn, err)
+			}
+			copied, err := t.copyBatch(ctx, stage, checkpoint.After)
+			if err != nil {
+				return checkpoint, fmt.Errorf("%s: %s: %w", fn, stage, err)
+			}
+			checkpoint.Copied[stage] += copied.copied
+			checkpoint.Skipped[stage] += copied.skipped
+			if copied.done {
+				break
+			}
+			checkpoint.After = copied.last
+			if err := save(checkpoint.clone()); err != nil {
+				return checkpoint, fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+		checkpoint.Stage, checkpoint.After = StageDone, ""
+		if index+1 < len(Stages) {
+			checkpoint.Stage = Stages[index+1]
+		}
+		if err := save(checkpoint.clone()); err != nil {
+			return checkpoint, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+	return checkpoint, nil
+}
+
+func (t *Transfer) copyBatch(ctx context.Context, stage Stage, after string) (batch, error) {
+	switch stage {
+	case StageCampaigns:
+		return t.copyCampaigns(ctx)
+	case StageAliases:
+		return t.copyAliases(ctx, after)
+	case StageCounters:
+		return t.copyCounters(ctx, after)
+	case StageClicks:
+		return t.copyClicks(ctx, after)
+	default:
+		return t.copyExpirations(ctx, after)
+	}
+}
+
+// copyCampaigns copies all the campaigns at once, there are few of them
+func (t *Transfer) copyCampaigns(ctx context.Context) (batch, error) {
+	campaigns, err := t.source.Campaigns.List(ctx)
+	if err != nil {
+		return batch{}, err
+	}
+	copied := batch{done: true}
+	for _, campaign := range campaigns {
+		err := t.target.Campaigns.Save(ctx, campaign)
+		switch {
+		case errors.Is(err, domain.ErrCampaignAlreadyExists):
+			copied.skipped++
+		case err != nil:
+			return batch{}, err
+		default:
+			copied.copied++
+		}
+	}
+	return copied, nil
+}
+
+func (t *Transfer) copyAliases(ctx context.Context, after string) (batch, error) {
+	aliases, err := t.source.Aliases.Scan(ctx, after, t.batchSize)
+	if err != nil || len(aliases) == 0 {
+		return batch{done: true}, err
+	}
+	keys := make([]string, len(aliases))
+	for index, alias := range aliases {
+		keys[index] = alias.Key
+	}
+	existing, err := t.target.Aliases.ExistingKeys(ctx, keys)
+	if err != nil {
+		return batch{}, err
+	}
+	missing := slices.DeleteFunc(aliases, func(alias domain.Alias) bool {
+		return slices.Contains(existing, alias.Key)
+	})
+	if len(missing) > 0 {
+		if err := t.target.Aliases.Save(ctx, missing); err != nil {
+			return batch{}, err
+		}
+	}
+	return batch{
+		last:    keys[len(keys)-1],
+		copied:  len(missing),
+		skipped: len(existing),
+		done:    len(keys) < t.batchSize,
+	}, nil
+}
+
+// copyCounters copies the click counters of the aliases of the source storage, the aliases never clicked are passed
+func (t *Transfer) copyCounters(ctx context.Context, after string) (batch, error) {
+	aliases, err := t.source.Aliases.Scan(ctx, after, t.batchSize)
+	if err != nil || len(aliases) == 0 {
+		return batch{done: true}, err
+	}
+	copied := batch{last: aliases[len(aliases)-1].Key, done: len(aliases) < t.batchSize}
+	for _, alias := range aliases {
+		stats, err := t.source.Stats.Clicks(ctx, alias.Key)
+		if err != nil {
+			return batch{}, err
+		}
+		if stats.Total == 0 && stats.LastClickAt.IsZero() {
+			continue
+		}
+		if err := t.target.Stats.SetClicks(ctx, *stats); err != nil {
+			return batch{}, err
+		}
+		copied.copied++
+	}
+	return copied, nil
+}
+
+func (t *Transfer) copyClicks(ctx context.Context, after string) (batch, error) {
+	clicks, err := t.source.Stats.ListClicks(ctx, domain.ClickFilter{After: after, Limit: t.batchSize})
+	if err != nil || len(clicks) == 0 {
+		return batch{done: true}, err
+	}
+	if err := t.target.Stats.AppendClicks(ctx, clicks); err != nil {
+		return batch{}, err
+	}
+	return batch{last: clicks[len(clicks)-1].ID, copied: len(clicks), done: len(clicks) < t.batchSize}, nil
+}
+
+func (t *Transfer) copyExpirations(ctx context.Context, after string) (batch, error) {
+	expirations, err := t.source.Stats.ListExpirations(ctx, after, t.batchSize)
+	if err != nil || len(expirations) == 0 {
+		return batch{done: true}, err
+	}
+	if err := t.target.Stats.AppendExpirations(ctx, expirations); err != nil {
+		return batch{}, err
+	}
+	return batch{
+		last:   expirations[len(expirations)-1].ID,
+		copied: len(expirations),
+		done:   len(expirations) < t.batchSize,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package transfersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/repository/inmemory"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStorage() Storage {
+	return Storage{
+		Aliases:   inmemory.NewAliasRepository(),
+		Campaigns: inmemory.NewCampaignRepository(),
+		Stats:     inmemory.NewStatisticsRepository(),
+	}
+}
+
+// newTestSource fills a storage with aliases of every kind, a campaign, clicks and expirations
+func newTestSource(t *testing.T, aliasCount int) Storage {
+	t.Helper()
+	ctx := context.Background()
+	storage := newTestStorage()
+	target, _ := url.Parse("https://example.com/landing")
+
+	require.NoError(t, storage.Campaigns.Save(ctx, domain.Campaign{
+		Name:      "spring",
+		Params:    map[string]string{"utm_source": "newsletter"},
+		CreatedAt: testNow,
+	}))
+	aliases := make([]domain.Alias, aliasCount)
+	for index := range aliases {
+		aliases[index] = domain.Alias{
+			Key:       fmt.Sprintf("key-%03d", index),
+			URL:       target,
+			IsActive:  true,
+			Params:    domain.TTLParams{TriesLeft: index % 3, IsPermanent: index%3 == 0},
+			CreatedAt: testNow.Add(time.Duration(index) * time.Minute),
+		}
+	}
+	aliases[1].Campaign = "spring"
+	aliases[2].Rules = []domain.RedirectRule{{Name: "ios", Platforms: []domain.Platform{"ios"}, URL: target}}
+	aliases[3].IsActive, aliases[3].DeletedAt = false, testNow
+	require.NoError(t, storage.Aliases.Save(ctx, aliases))
+
+	for index := 0; index < aliasCount; index += 2 {
+		used := aliases[index].Redirected()
+		used.OccurredAt = testNow.Add(time.Duration(index) * time.Second)
+		require.NoError(t, storage.Stats.(*inmemory.StatisticsRepository).RecordClick(ctx, used))
+	}
+	expired := aliases[1].Expired()
+	expired.OccurredAt = testNow
+	require.NoError(t, storage.Stats.(*inmemory.StatisticsRepository).PushStats(ctx, expired))
+	return storage
+}
+
+func requireMatch(t *testing.T, comparisons []Comparison) {
+	t.Helper()
+	require.Len(t, comparisons, len(Stages))
+	for _, comparison := range comparisons {
+		assert.True(t, comparison.Match(), "stage %s: %+v", comparison.Stage, comparison)
+	}
+}
+
+func TestTransfer_Copy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	source := newTestSource(t, 7)
+	target := newTestStorage()
+	transfer := NewTransfer(source, target, 2)
+
+	var saved []Checkpoint
+	checkpoint, err := transfer.Copy(ctx, Checkpoint{}, func(checkpoint Checkpoint) error {
+		saved = append(saved, checkpoint)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StageDone, checkpoint.Stage)
+	assert.Equal(t, map[Stage]int{
+		StageCampaigns: 1, StageAliases: 7, StageCounters: 4, StageClicks: 4, StageExpirations: 1,
+	}, checkpoint.Copied)
+	assert.Equal(t, checkpoint, saved[len(saved)-1])
+
+	removed, err := target.Aliases.Scan(ctx, "key-002", 1)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.False(t, removed[0].IsActive)
+	assert.Equal(t, testNow, removed[0].DeletedAt)
+
+	comparisons, err := transfer.Verify(ctx)
+	require.NoError(t, err)
+	requireMatch(t, comparisons)
+}
+
+func TestTransfer_Copy_Resume(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	source := newTestSource(t, 7)
+	target := newTestStorage()
+	transfer := NewTransfer(source, target, 3)
+
+	// the first batch of aliases is copied, but its checkpoint is lost
+	var last Checkpoint
+	errSave := errors.New("disk full")
+	_, err := transfer.Copy(ctx, Checkpoint{}, func(checkpoint Checkpoint) error {
+		if checkpoint.Stage == StageAliases && checkpoint.After != "" {
+			return errSave
+		}
+		last = checkpoint
+		return nil
+	})
+	require.ErrorIs(t, err, errSave)
+	require.Equal(t, StageAliases, last.Stage)
+
+	checkpoint, err := transfer.Copy(ctx, last, func(Checkpoint) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, StageDone, checkpoint.Stage)
+	assert.Equal(t, 7-3, checkpoint.Copied[StageAliases])
+	assert.Equal(t, 3, checkpoint.Skipped[StageAliases])
+
+	comparisons, err := transfer.Verify(ctx)
+	require.NoError(t, err)
+	requireMatch(t, comparisons)
+
+	// a finished transfer copies nothing
+	again, err := transfer.Copy(ctx, checkpoint, func(Checkpoint) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint, again)
+}
+
+func TestTransfer_Verify(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	source := newTestSource(t, 5)
+	target := newTestStorage()
+	transfer := NewTransfer(source, target, 10)
+
+	// the target keeps another alias with one of the keys, it is not overwritten
+	other, _ := url.Parse("https://example.org")
+	require.NoError(t, target.Aliases.Save(ctx, []domain.Alias{{Key: "key-004", URL: other, IsActive: true}}))
+
+	checkpoint, err := transfer.Copy(ctx, Checkpoint{}, func(Checkpoint) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, checkpoint.Skipped[StageAliases])
+
+	comparisons, err := transfer.Verify(ctx)
+	require.NoError(t, err)
+	for _, comparison := range comparisons {
+		if comparison.Stage == StageAliases {
+			assert.False(t, comparison.Match())
+			assert.Equal(t, comparison.Source.Count, comparison.Target.Count)
+			assert.NotEqual(t, comparison.Source.Checksum, comparison.Target.Checksum)
+		} else {
+			assert.True(t, comparison.Match(), "stage %s", comparison.Stage)
+		}
+	}
+}
+
+func TestTransfer_Copy_UnknownStage(t *testing.T) {
+	t.Parallel()
+	transfer := NewTransfer(newTestStorage(), newTestStorage(), 10)
+
+	_, err := transfer.Copy(context.Background(), Checkpoint{Stage: "users"}, func(Checkpoint) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,315 @@
+package transfersvc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/infrastructure/logging"
+	"go.uber.org/zap"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// checksumModulus keeps the checksum the size of a single record hash
+var checksumModulus = new(big.Int).Lsh(big.NewInt(1), sha256.Size*8)
+
+// Digest sums up the records of a stage
+type Digest struct {
+	Count int
+	// Checksum is the sum of the hashes of the records, so it does not depend on the order the storage keeps them in
+	Checksum string
+}
+
+// Comparison is the outcome of comparing a stage of the storages
+type Comparison struct {
+	Stage  Stage
+	Source Digest
+	Target Digest
+}
+
+// Match reports whether the stage holds the same records in both storages
+func (c Comparison) Match() bool {
+	return c.Source == c.Target
+}
+
+// Verify compares the records of the storages stage by stage. The records are compared by the data kept by both
+// storages, so the IDs and the precision of the times beyond milliseconds are ignored.
+func (t *Transfer) Verify(ctx context.Context) ([]Comparison, error) {
+	const fn = "Verify"
+	logging.FromContext(ctx).Infow("service",
+		zap.String("name", t.Name()),
+		zap.String("fn", fn))
+
+	comparisons := make([]Comparison, len(Stages))
+	for index, stage := range Stages {
+		source, err := t.digest(ctx, t.source, stage)
+		if err != nil {
+			return nil, fmt.Errorf("%s: source %s: %w", fn, stage, err)
+		}
+		target, err := t.digest(ctx, t.target, stage)
+		if err != nil {
+			return nil, fmt.Errorf("%s: target %s: %w", fn, stage, err)
+		}
+		comparisons[index] = Comparison{Stage: stage, Source: source, Target: target}
+	}
+	return comparisons, nil
+}
+
+type checksum struct {
+	count int
+	sum   big.Int
+}
+
+func (c *checksum) add(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(data)
+	c.sum.Add(&c.sum, new(big.Int).SetBytes(hash[:]))
+	c.sum.Mod(&c.sum, checksumModulus)
+	c.count++
+	return nil
+}
+
+func (c *checksum) digest() Digest {
+	return Digest{Count: c.count, Checksum: fmt.Sprintf("%064x", &c.sum)}
+}
+
+func (t *Transfer) digest(ctx context.Context, storage Storage, stage Stage) (Digest, error) {
+	var sum checksum
+	switch stage {
+	case StageCampaigns:
+		campaigns, err := storage.Campaigns.List(ctx)
+		if err != nil {
+			return Digest{}, err
+		}
+		for _, campaign := range campaigns {
+			if err := sum.add(newCampaignRecord(campaign)); err != nil {
+				return Digest{}, err
+			}
+		}
+
+	case StageAliases, StageCounters:
+		for after := ""; ; {
+			aliases, err := storage.Aliases.Scan(ctx, after, t.batchSize)
+			if err != nil {
+				return Digest{}, err
+			}
+			for _, alias := range aliases {
+				if err := t.addAlias(ctx, &sum, storage, stage, alias); err != nil {
+					return Digest{}, err
+				}
+			}
+			if len(aliases) < t.batchSize {
+				break
+			}
+			after = aliases[len(aliases)-1].Key
+		}
+
+	case StageClicks:
+		for filter := (domain.ClickFilter{Limit: t.batchSize}); ; {
+			clicks, err := storage.Stats.ListClicks(ctx, filter)
+			if err != nil {
+				return Digest{}, err
+			}
+			for _, click := range clicks {
+				if err := sum.add(newClickRecord(click)); err != nil {
+					return Digest{}, err
+				}
+			}
+			if len(clicks) < t.batchSize {
+				break
+			}
+			filter.After = clicks[len(clicks)-1].ID
+		}
+
+	case StageExpirations:
+		for after := ""; ; {
+			expirations, err := storage.Stats.ListExpirations(ctx, after, t.batchSize)
+			if err != nil {
+				return Digest{}, err
+			}
+			for _, expiration := range expirations {
+				if err := sum.add(newExpirationRecord(expiration)); err != nil {
+					return Digest{}, err
+				}
+			}
+			if len(expirations) < t.batchSize {
+				break
+			}
+			after = expirations[len(expirations)-1].ID
+		}
+	}
+	return sum.digest(), nil
+}
+
+// addAlias adds the alias itself or its click counters, the counters of the aliases never clicked are left out
+func (t *Transfer) addAlias(ctx context.Context, sum *checksum, storage Storage, stage Stage, alias domain.Alias) error {
+	if stage == StageAliases {
+		return sum.add(newAliasRecord(alias))
+	}
+	stats, err := storage.Stats.Clicks(ctx, alias.Key)
+	if err != nil {
+		return err
+	}
+	if stats.Total == 0 && stats.LastClickAt.IsZero() {
+		return nil
+	}
+	return sum.add(newCountersRecord(*stats))
+}
+
+// canonicalTime drops the precision the storages may not keep
+func canonicalTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func canonicalURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+// the records below are the canonical forms of the stored data hashed by the checksums,
+// the empty collections are left out, as the storages may keep them either as nil or as empty ones
+
+type campaignRecord struct {
+	Name      string            `json:"name"`
+	Params    map[string]string `json:"params,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func newCampaignRecord(campaign domain.Campaign) campaignRecord {
+	return campaignRecord{Name: campaign.Name, Params: campaign.Params, CreatedAt: canonicalTime(campaign.CreatedAt)}
+}
+
+type ruleRecord struct {
+	Name      string            `json:"name"`
+	Platforms []domain.Platform `json:"platforms,omitempty"`
+	Languages []string          `json:"languages,omitempty"`
+	Countries []string          `json:"countries,omitempty"`
+	URL       string            `json:"url"`
+}
+
+type variantRecord struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+type aliasRecord struct {
+	Key          string          `json:"key"`
+	URL          string          `json:"url"`
+	IsActive     bool            `json:"is_active"`
+	TriesLeft    int             `json:"tries_left"`
+	IsPermanent  bool            `json:"is_permanent"`
+	RedirectType int             `json:"redirect_type"`
+	Passthrough  string          `json:"passthrough"`
+	OnConflict   string          `json:"query_conflict"`
+	Campaign     string          `json:"campaign"`
+	Rules        []ruleRecord    `json:"rules,omitempty"`
+	Variants     []variantRecord `json:"variants,omitempty"`
+	PasswordHash []byte          `json:"password_hash,omitempty"`
+	Interstitial bool            `json:"interstitial"`
+	Disabled     bool            `json:"disabled"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ActivatesAt  time.Time       `json:"activates_at"`
+	ExpiresAt    time.Time       `json:"expires_at"`
+	ExpiredAt    time.Time       `json:"expired_at"`
+	DeletedAt    time.Time       `json:"deleted_at"`
+}
+
+func newAliasRecord(alias domain.Alias) aliasRecord {
+	record := aliasRecord{
+		Key:          alias.Key,
+		URL:          canonicalURL(alias.URL),
+		IsActive:     alias.IsActive,
+		TriesLeft:    alias.Params.TriesLeft,
+		IsPermanent:  alias.Params.IsPermanent,
+		RedirectType: int(alias.RedirectType),
+		Passthrough:  string(alias.Passthrough.Mode),
+		OnConflict:   string(alias.Passthrough.OnConflict),
+		Campaign:     alias.Campaign,
+		PasswordHash: alias.PasswordHash,
+		Interstitial: alias.Interstitial,
+		Disabled:     alias.Disabled,
+		CreatedAt:    canonicalTime(alias.CreatedAt),
+		ActivatesAt:  canonicalTime(alias.ActivatesAt),
+		ExpiresAt:    canonicalTime(alias.ExpiresAt),
+		ExpiredAt:    canonicalTime(alias.ExpiredAt),
+		DeletedAt:    canonicalTime(alias.DeletedAt),
+	}
+	for _, rule := range alias.Rules {
+		record.Rules = append(record.Rules, ruleRecord{
+			Name:      rule.Name,
+			Platforms: rule.Platforms,
+			Languages: rule.Languages,
+			Countries: rule.Countries,
+			URL:       canonicalURL(rule.URL),
+		})
+	}
+	for _, variant := range alias.Variants {
+		record.Variants = append(record.Variants, variantRecord{
+			Name:   variant.Name,
+			URL:    canonicalURL(variant.URL),
+			Weight: variant.Weight,
+		})
+	}
+	return record
+}
+
+type countersRecord struct {
+	Key         string         `json:"key"`
+	Total       int            `json:"total"`
+	Rules       map[string]int `json:"rules,omitempty"`
+	Variants    map[string]int `json:"variants,omitempty"`
+	LastClickAt time.Time      `json:"last_click_at"`
+}
+
+func newCountersRecord(stats domain.ClickStats) countersRecord {
+	return countersRecord{
+		Key:         stats.Key,
+		Total:       stats.Total,
+		Rules:       stats.Rules,
+		Variants:    stats.Variants,
+		LastClickAt: canonicalTime(stats.LastClickAt),
+	}
+}
+
+type clickRecord struct {
+	Key        string    `json:"key"`
+	Campaign   string    `json:"campaign"`
+	Rule       string    `json:"rule"`
+	Variant    string    `json:"variant"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func newClickRecord(click domain.Click) clickRecord {
+	return clickRecord{
+		Key:        click.Key,
+		Campaign:   click.Campaign,
+		Rule:       click.Rule,
+		Variant:    click.Variant,
+		OccurredAt: canonicalTime(click.OccurredAt),
+	}
+}
+
+type expirationRecord struct {
+	Key        string    `json:"key"`
+	URL        string    `json:"url"`
+	Campaign   string    `json:"campaign"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func newExpirationRecord(expiration domain.Expiration) expirationRecord {
+	return expirationRecord{
+		Key:        expiration.Key,
+		URL:        canonicalURL(expiration.URL),
+		Campaign:   expiration.Campaign,
+		OccurredAt: canonicalTime(expiration.OccurredAt),
+	}
+}
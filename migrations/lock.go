@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// LockName is the lease held while the migrations run, the servers and aliasctl share it
+const LockName = "schema migrations"
+
+const (
+	// lockLease is extended every third of it while the migrations run, so a holder which died releases it soon
+	lockLease         = 30 * time.Second
+	lockRetryInterval = time.Second
+)
+
+// ErrLocked is returned if another replica holds the lease longer than the wait
+var ErrLocked = errors.New("migrations are run by another replica")
+
+// Locker grants named leases shared by the replicas of the service
+type Locker interface {
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name string) error
+}
+
+// Exclusive runs fn while holding the migrations lease, so that a single replica migrates the storage at a time.
+// It waits up to wait for the lease held by another replica, zero waits until ctx is done.
+func Exclusive(ctx context.Context, locker Locker, wait time.Duration, fn func() error) (err error) {
+	waitCtx := ctx
+	if wait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+	for {
+		acquired, err := locker.Acquire(waitCtx, LockName, lockLease)
+		if err != nil {
+			return fmt.Errorf("lock %s: %w", LockName, err)
+		}
+		if acquired {
+			break
+		}
+		zap.S().Infow("migrations", zap.String("state", "waiting for another replica to finish the migrations"))
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return ErrLocked
+		case <-time.After(lockRetryInterval):
+		}
+	}
+	defer func() {
+		// the lease is given up even if ctx is done, so the other replicas do not wait for it to expire
+		if releaseErr := locker.Release(context.WithoutCancel(ctx), LockName); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("release %s: %w", LockName, releaseErr))
+		}
+	}()
+
+	extendCtx, stopExtending := context.WithCancel(ctx)
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		ticker := time.NewTicker(lockLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-extendCtx.Done():
+				return
+			case <-ticker.C:
+				if acquired, err := locker.Acquire(extendCtx, LockName, lockLease); err != nil || !acquired {
+					zap.S().Warnw("migrations", zap.String("state", "failed to extend the lease"),
+						zap.Bool("acquired", acquired), zap.Error(err))
+				}
+			}
+		}
+	}()
+	defer func() {
+		stopExtending()
+		<-extended
+	}()
+
+	return fn()
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLocker struct {
+	acquired bool
+	err      error
+	released atomic.Bool
+}
+
+func (l *testLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return l.acquired, l.err
+}
+
+func (l *testLocker) Release(ctx context.Context, name string) error {
+	l.released.Store(true)
+	return nil
+}
+
+func TestExclusive(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		locker    *testLocker
+		runErr    error
+		runs      int32
+		released  bool
+		expectErr error
+	}{
+		{name: "lease holder runs the migrations", locker: &testLocker{acquired: true}, runs: 1, released: true},
+		{
+			name:      "failed migrations release the lease",
+			locker:    &testLocker{acquired: true},
+			runErr:    assert.AnError,
+			runs:      1,
+			released:  true,
+			expectErr: assert.AnError,
+		},
+		{name: "lease held by another replica", locker: &testLocker{acquired: false}, expectErr: ErrLocked},
+		{name: "lock failure", locker: &testLocker{err: assert.AnError}, expectErr: assert.AnError},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			var runs atomic.Int32
+			err := Exclusive(context.Background(), testCase.locker, 10*time.Millisecond, func() error {
+				runs.Add(1)
+				return testCase.runErr
+			})
+			assert.ErrorIs(t, err, testCase.expectErr)
+			assert.Equal(t, testCase.runs, runs.Load())
+			assert.Equal(t, testCase.released, testCase.locker.released.Load())
+		})
+	}
+}
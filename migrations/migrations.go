@@ -1,32 +1,159 @@
+// Package migrations keeps the schema migrations of the storages embedded into the binaries
 package migrations
 
 import (
 	"embed"
+	"errors"
+	"fmt"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	mg "github.com/golang-migrate/migrate/v4/database/mongodb"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/xloki21/alias/internal/repository"
 	"go.mongodb.org/mongo-driver/mongo"
+	"os"
 )
 
 //go:embed mongodb/*.json
 var MongoDBFilesFS embed.FS
 
+// ErrNoMigrations is returned for the storages keeping no schema, e.g. the in-memory one
+var ErrNoMigrations = errors.New("storage has no migrations")
+
+// files are the embedded migrations of the storage types, the directory of a type holds its migration files
+var files = map[repository.Type]struct {
+	fs  embed.FS
+	dir string
+}{
+	repository.MongoDB: {fs: MongoDBFilesFS, dir: "mongodb"},
+}
+
+// Migrator applies the embedded migrations of a storage
+type Migrator struct {
+	migrate *migrate.Migrate
+	source  source.Driver
+}
+
+// Status is the state of the storage schema
+type Status struct {
+	// Version is the last applied migration, zero if none is applied
+	Version uint
+	// Dirty is set if the last migration failed halfway, the schema must be fixed by hand and the version forced
+	Dirty bool
+	// Available are the versions of the embedded migrations in ascending order
+	Available []uint
+	// Pending are the available versions newer than the applied one
+	Pending []uint
+}
+
 func CreateMongoDBMigrator(client *mongo.Client, dbName string) (*migrate.Migrate, error) {
+	migrator, err := NewMongoDB(client, dbName)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.migrate, nil
+}
+
+// NewMongoDB creates the migrator of the MongoDB database. The migrator shares the client and must not be closed,
+// disconnect the client instead.
+func NewMongoDB(client *mongo.Client, dbName string) (*Migrator, error) {
 	dbDriver, err := mg.WithInstance(client, &mg.Config{
 		DatabaseName: dbName,
 	})
 	if err != nil {
 		return nil, err
 	}
+	return newMigrator(repository.MongoDB, dbName, dbDriver)
+}
 
-	sourceDriver, err := iofs.New(MongoDBFilesFS, "mongodb")
+func newMigrator(storage repository.Type, dbName string, dbDriver database.Driver) (*Migrator, error) {
+	embedded, ok := files[storage]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoMigrations, storage)
+	}
+	sourceDriver, err := iofs.New(embedded.fs, embedded.dir)
 	if err != nil {
 		return nil, err
 	}
 
-	migrator, err := migrate.NewWithInstance("base migrations", sourceDriver, "appdb", dbDriver)
+	migrator, err := migrate.NewWithInstance("base migrations", sourceDriver, dbName, dbDriver)
 	if err != nil {
 		return nil, err
 	}
-	return migrator, nil
+	return &Migrator{migrate: migrator, source: sourceDriver}, nil
+}
+
+// Supported reports whether the storage type has embedded migrations
+func Supported(storage repository.Type) bool {
+	_, ok := files[storage]
+	return ok
+}
+
+// Up applies all the pending migrations, it is a no-op if the schema is up to date
+func (m *Migrator) Up() error {
+	if err := m.migrate.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the given number of the applied migrations, all of them if steps is not positive
+func (m *Migrator) Down(steps int) error {
+	var err error
+	if steps > 0 {
+		err = m.migrate.Steps(-steps)
+	} else {
+		err = m.migrate.Down()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Force sets the version of the schema without running the migrations and clears the dirty flag.
+// It is used to recover from a failed migration, or to adopt a storage whose schema was created by other means.
+func (m *Migrator) Force(version int) error {
+	if version < 0 {
+		return fmt.Errorf("invalid version %d", version)
+	}
+	if version > 0 {
+		migration, _, err := m.source.ReadUp(uint(version))
+		if err != nil {
+			return fmt.Errorf("no migration of version %d: %w", version, err)
+		}
+		_ = migration.Close()
+	}
+	if version == 0 {
+		// golang-migrate stores the missing version as -1
+		version = database.NilVersion
+	}
+	return m.migrate.Force(version)
+}
+
+// Status returns the applied version of the schema and the available migrations
+func (m *Migrator) Status() (Status, error) {
+	var status Status
+	version, dirty, err := m.migrate.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+	case err != nil:
+		return Status{}, err
+	default:
+		status.Version, status.Dirty = version, dirty
+	}
+
+	next, err := m.source.First()
+	for err == nil {
+		status.Available = append(status.Available, next)
+		if next > status.Version {
+			status.Pending = append(status.Pending, next)
+		}
+		next, err = m.source.Next(next)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return Status{}, err
+	}
+	return status, nil
 }
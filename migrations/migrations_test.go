@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"testing"
+)
+
+// TestMigrations_NoUsers guards the embedded migrations, the servers apply them automatically, so the users are
+// created by the deployment (mongo-init.sh). The default user of the first migration is dropped by a later one.
+func TestMigrations_NoUsers(t *testing.T) {
+	t.Parallel()
+	for storage, embedded := range files {
+		// the names sort by version
+		names, err := fs.Glob(embedded.fs, embedded.dir+"/*.up.json")
+		require.NoError(t, err)
+		require.NotEmpty(t, names, storage)
+
+		users := map[string]bool{}
+		for _, name := range names {
+			content, err := embedded.fs.ReadFile(name)
+			require.NoError(t, err)
+			var commands []map[string]any
+			require.NoError(t, json.Unmarshal(content, &commands), name)
+			for _, command := range commands {
+				if user, ok := command["createUser"].(string); ok {
+					users[user] = true
+				}
+				if user, ok := command["dropUser"].(string); ok {
+					delete(users, user)
+				}
+				if _, ok := command["dropAllUsersFromDatabase"]; ok {
+					clear(users)
+				}
+			}
+		}
+		assert.Empty(t, users, storage)
+	}
+}
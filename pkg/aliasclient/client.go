@@ -0,0 +1,239 @@
+// Package aliasclient is the client of the alias service. New connects to the gRPC API, NewHTTP to the REST API,
+// both clients behave the same way.
+package aliasclient
+
+import (
+	"context"
+	"crypto/tls"
+	"google.golang.org/grpc"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// the errors of the calls are retried after 100ms, 200ms, 400ms and so on up to 2s
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 2 * time.Second
+)
+
+// transport makes the calls of the client over gRPC or HTTP, the errors are converted to *Error
+type transport interface {
+	create(ctx context.Context, urls []string, request createRequest) ([]string, error)
+	get(ctx context.Context, key string) (*Alias, error)
+	list(ctx context.Context, options ListOptions) (*Page, error)
+	remove(ctx context.Context, key string) error
+	restore(ctx context.Context, key string) error
+	setDisabled(ctx context.Context, key string, disabled bool) error
+	stats(ctx context.Context, key string) (*Stats, error)
+	health(ctx context.Context) error
+	close() error
+}
+
+// Client calls the alias service, it is safe for concurrent use
+type Client struct {
+	transport transport
+	timeout   time.Duration
+	retry     RetryPolicy
+}
+
+// settings are collected from the options of the client
+type settings struct {
+	token       string
+	tlsConfig   *tls.Config
+	timeout     time.Duration
+	retry       RetryPolicy
+	dialOptions []grpc.DialOption
+	httpClient  *http.Client
+}
+
+// Option configures the client
+type Option func(*settings)
+
+// WithToken sends the token as a bearer token with every call
+func WithToken(token string) Option {
+	return func(s *settings) { s.token = token }
+}
+
+// WithTLS connects over TLS, the connections are not encrypted by default.
+// The REST client connects over TLS to https addresses regardless of the option, the option configures it then.
+func WithTLS(config *tls.Config) Option {
+	return func(s *settings) { s.tlsConfig = config }
+}
+
+// WithTimeout limits every attempt of a call, 30 seconds by default, zero means no limit
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *settings) { s.timeout = timeout }
+}
+
+// WithRetry sets the retry policy of the idempotent calls
+func WithRetry(policy RetryPolicy) Option {
+	return func(s *settings) { s.retry = policy }
+}
+
+// WithGRPCDialOptions adds the options to the connection of the gRPC client
+func WithGRPCDialOptions(options ...grpc.DialOption) Option {
+	return func(s *settings) { s.dialOptions = append(s.dialOptions, options...) }
+}
+
+// WithHTTPClient makes the calls of the REST client with the HTTP client, its transport is used as is
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *settings) { s.httpClient = client }
+}
+
+func newSettings(options []Option) settings {
+	s := settings{
+		timeout: defaultTimeout,
+		retry: RetryPolicy{
+			MaxAttempts:    defaultMaxAttempts,
+			InitialBackoff: defaultInitialBackoff,
+			MaxBackoff:     defaultMaxBackoff,
+		},
+	}
+	for _, option := range options {
+		option(&s)
+	}
+	return s
+}
+
+// New creates a new client of the gRPC API at the target, e.g. localhost:8081
+func New(target string, options ...Option) (*Client, error) {
+	s := newSettings(options)
+	transport, err := newGRPCTransport(target, s)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{transport: transport, timeout: s.timeout, retry: s.retry}, nil
+}
+
+// NewHTTP creates a new client of the REST API at the base url, e.g. http://localhost:8080
+func NewHTTP(baseURL string, options ...Option) (*Client, error) {
+	s := newSettings(options)
+	transport, err := newHTTPTransport(baseURL, s)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{transport: transport, timeout: s.timeout, retry: s.retry}, nil
+}
+
+// Close releases the connections of the client
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+// Create creates an alias of the url and returns its short link
+func (c *Client) Create(ctx context.Context, url string, options ...CreateOption) (string, error) {
+	links, err := c.CreateMany(ctx, []string{url}, options...)
+	if err != nil {
+		return "", err
+	}
+	return links[0], nil
+}
+
+// CreateMany creates the aliases of the urls at once and returns their short links in the order of the urls.
+// Either all the aliases are created or none of them.
+func (c *Client) CreateMany(ctx context.Context, urls []string, options ...CreateOption) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, &Error{Kind: ErrInvalidArgument, Message: "no urls given"}
+	}
+	var request createRequest
+	for _, option := range options {
+		option(&request)
+	}
+	if request.key != "" && len(urls) > 1 {
+		return nil, &Error{Kind: ErrInvalidArgument, Message: "a key may be given with a single url only"}
+	}
+
+	var links []string
+	err := c.once(ctx, func(ctx context.Context) (err error) {
+		links, err = c.transport.create(ctx, urls, request)
+		return err
+	})
+	return links, err
+}
+
+// Get returns the alias unless it is in the trash
+func (c *Client) Get(ctx context.Context, key string) (*Alias, error) {
+	var alias *Alias
+	err := c.idempotent(ctx, func(ctx context.Context) (err error) {
+		alias, err = c.transport.get(ctx, key)
+		return err
+	})
+	return alias, err
+}
+
+// List returns a page of the aliases, pass the NextCursor of the page in the options to get the next one
+func (c *Client) List(ctx context.Context, options ListOptions) (*Page, error) {
+	var page *Page
+	err := c.idempotent(ctx, func(ctx context.Context) (err error) {
+		page, err = c.transport.list(ctx, options)
+		return err
+	})
+	return page, err
+}
+
+// Remove moves the alias to the trash
+func (c *Client) Remove(ctx context.Context, key string) error {
+	return c.once(ctx, func(ctx context.Context) error {
+		return c.transport.remove(ctx, key)
+	})
+}
+
+// Restore takes the alias back from the trash
+func (c *Client) Restore(ctx context.Context, key string) error {
+	return c.once(ctx, func(ctx context.Context) error {
+		return c.transport.restore(ctx, key)
+	})
+}
+
+// Disable stops the alias from redirecting
+func (c *Client) Disable(ctx context.Context, key string) error {
+	return c.idempotent(ctx, func(ctx context.Context) error {
+		return c.transport.setDisabled(ctx, key, true)
+	})
+}
+
+// Enable lets the disabled alias redirect again
+func (c *Client) Enable(ctx context.Context, key string) error {
+	return c.idempotent(ctx, func(ctx context.Context) error {
+		return c.transport.setDisabled(ctx, key, false)
+	})
+}
+
+// Stats returns the click counters of the alias
+func (c *Client) Stats(ctx context.Context, key string) (*Stats, error) {
+	var stats *Stats
+	err := c.idempotent(ctx, func(ctx context.Context) (err error) {
+		stats, err = c.transport.stats(ctx, key)
+		return err
+	})
+	return stats, err
+}
+
+// Health returns nil if the service is ready to serve the requests, an error of ErrUnavailable kind otherwise
+func (c *Client) Health(ctx context.Context) error {
+	return c.idempotent(ctx, c.transport.health)
+}
+
+// once makes a call which is not safe to repeat
+func (c *Client) once(ctx context.Context, call func(ctx context.Context) error) error {
+	return c.attempt(ctx, call)
+}
+
+// idempotent makes a call which is safe to repeat, retrying it by the retry policy
+func (c *Client) idempotent(ctx context.Context, call func(ctx context.Context) error) error {
+	return c.retry.do(ctx, func(ctx context.Context) error {
+		return c.attempt(ctx, call)
+	})
+}
+
+// attempt makes a single attempt of the call limited by the timeout
+func (c *Client) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	return call(ctx)
+}
@@ -0,0 +1,304 @@
+package aliasclient
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xloki21/alias/internal/controller/grpcc"
+	"github.com/xloki21/alias/internal/controller/grpcc/interceptors"
+	"github.com/xloki21/alias/internal/controller/httpc"
+	"github.com/xloki21/alias/internal/controller/httpc/mw"
+	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
+	"github.com/xloki21/alias/internal/infrastructure/health"
+	"github.com/xloki21/alias/internal/infrastructure/lifecycle"
+	"github.com/xloki21/alias/internal/infrastructure/squeue"
+	"github.com/xloki21/alias/internal/repository/inmemory"
+	"github.com/xloki21/alias/internal/services/aliassvc"
+	"github.com/xloki21/alias/internal/services/campaignsvc"
+	"github.com/xloki21/alias/internal/services/statssvc"
+	"github.com/xloki21/alias/pkg/keygen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testBaseURL    = "http://aliases.test"
+	bufferSize     = 1 << 20
+	testRetryDelay = time.Millisecond
+)
+
+// testServer serves the gRPC and the REST API of an in-memory service over in-process connections
+type testServer struct {
+	grpcListener *bufconn.Listener
+	httpListener *bufconn.Listener
+	// failures is the number of the next gRPC calls failed as unavailable, calls counts all the gRPC calls
+	failures atomic.Int32
+	calls    atomic.Int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	server := &testServer{grpcListener: bufconn.Listen(bufferSize), httpListener: bufconn.Listen(bufferSize)}
+
+	expiredQ, usedQ, clickedQ := squeue.NewWithCapacity(16), squeue.NewWithCapacity(16), squeue.NewWithCapacity(16)
+	campaignRepo := inmemory.NewCampaignRepository()
+	aliasService := aliassvc.NewAlias(expiredQ, usedQ, inmemory.NewAliasRepository(),
+		keygen.NewURLSafeRandomStringGenerator(), campaignRepo)
+	campaignService := campaignsvc.NewCampaign(campaignRepo)
+	statsService := statssvc.NewStatistics(inmemory.NewStatisticsRepository(), expiredQ, clickedQ)
+	readiness := health.New(lifecycle.New())
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(server.failingInterceptor, interceptors.RequestIDInterceptor))
+	aliasapi.RegisterAliasAPIServer(grpcServer,
+		grpcc.NewController(aliasService, campaignService, statsService, readiness, testBaseURL))
+	healthpb.RegisterHealthServer(grpcServer, grpchealth.NewServer())
+	go func() { _ = grpcServer.Serve(server.grpcListener) }()
+
+	controller := httpc.NewController(aliasService, campaignService, statsService, readiness, testBaseURL)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/alias", mw.Use(controller.Aliases, mw.RequestID))
+	mux.HandleFunc("/api/v1/alias/{key}/info", mw.Use(controller.Info, mw.RequestID))
+	mux.HandleFunc("/api/v1/alias/{key}/restore", mw.Use(controller.Restore, mw.RequestID))
+	mux.HandleFunc("/api/v1/alias/{key}/disable", mw.Use(controller.Disable, mw.RequestID))
+	mux.HandleFunc("/api/v1/alias/{key}/enable", mw.Use(controller.Enable, mw.RequestID))
+	mux.HandleFunc("/api/v1/alias/{key}/stats", mw.Use(controller.Stats, mw.RequestID))
+	mux.HandleFunc("/api/v1/alias/{key}", mw.Use(controller.RemoveAlias, mw.RequestID))
+	mux.HandleFunc("/readyz", mw.Use(controller.Readyz, mw.RequestID))
+	httpServer := &http.Server{Handler: mux}
+	go func() { _ = httpServer.Serve(server.httpListener) }()
+
+	t.Cleanup(func() {
+		grpcServer.Stop()
+		_ = httpServer.Close()
+	})
+	return server
+}
+
+func (s *testServer) failingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	s.calls.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return handler(ctx, req)
+}
+
+var testRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: testRetryDelay, MaxBackoff: testRetryDelay}
+
+func (s *testServer) grpcClient(t *testing.T) *Client {
+	t.Helper()
+	client, err := New("passthrough:///bufnet", WithRetry(testRetry), WithGRPCDialOptions(
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.grpcListener.DialContext(ctx)
+		})))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (s *testServer) httpClient(t *testing.T) *Client {
+	t.Helper()
+	transport := &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return s.httpListener.DialContext(ctx)
+	}}
+	client, err := NewHTTP(testBaseURL, WithRetry(testRetry), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+	transports := []struct {
+		name   string
+		client *Client
+		// prefix keeps the keys of the transports apart, they share the storage
+		prefix string
+	}{
+		{name: "gRPC", client: server.grpcClient(t), prefix: "grpc"},
+		{name: "REST", client: server.httpClient(t), prefix: "rest"},
+	}
+
+	for _, transport := range transports {
+		t.Run(transport.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, key := transport.client, transport.prefix+"-promo"
+
+			require.NoError(t, client.Health(ctx))
+
+			expiresAt := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+			link, err := client.Create(ctx, "https://example.com/landing", WithKey(key), WithMaxUsage(2),
+				WithRedirectType(http.StatusFound), WithExpiration(expiresAt))
+			require.NoError(t, err)
+			assert.Equal(t, testBaseURL+"/"+key, link)
+
+			alias, err := client.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, key, alias.Key)
+			assert.Equal(t, "https://example.com/landing", alias.Target)
+			assert.Equal(t, StatusActive, alias.Status)
+			assert.False(t, alias.Permanent)
+			assert.EqualValues(t, 2, alias.TriesLeft)
+			assert.Equal(t, http.StatusFound, alias.RedirectType)
+			assert.False(t, alias.CreatedAt.IsZero())
+			assert.True(t, expiresAt.Equal(alias.ExpiresAt))
+
+			links, err := client.CreateMany(ctx, []string{"https://example.com/a", "https://example.org/b"})
+			require.NoError(t, err)
+			assert.Len(t, links, 2)
+
+			page, err := client.List(ctx, ListOptions{Limit: 1000})
+			require.NoError(t, err)
+			assert.Contains(t, keysOf(page.Aliases), key)
+
+			require.NoError(t, client.Disable(ctx, key))
+			alias, err = client.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, StatusDisabled, alias.Status)
+			require.NoError(t, client.Enable(ctx, key))
+
+			stats, err := client.Stats(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, key, stats.Key)
+			assert.Zero(t, stats.Clicks)
+
+			require.NoError(t, client.Remove(ctx, key))
+			_, err = client.Get(ctx, key)
+			assert.ErrorIs(t, err, ErrNotFound)
+			require.NoError(t, client.Restore(ctx, key))
+			_, err = client.Get(ctx, key)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+	ctx := context.Background()
+	clients := map[string]*Client{"gRPC": server.grpcClient(t), "REST": server.httpClient(t)}
+	_, err := clients["gRPC"].Create(ctx, "https://example.com", WithKey("taken"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		call       func(client *Client) error
+		kind       error
+		code       string
+		violations []FieldViolation
+	}{
+		{
+			name: "missing alias",
+			call: func(client *Client) error {
+				_, err := client.Get(ctx, "missing")
+				return err
+			},
+			kind: ErrNotFound,
+			code: "ALIAS_NOT_FOUND",
+		},
+		{
+			name: "taken key",
+			call: func(client *Client) error {
+				_, err := client.Create(ctx, "https://example.org", WithKey("taken"))
+				return err
+			},
+			kind: ErrAlreadyExists,
+			code: "KEY_ALREADY_EXISTS",
+		},
+		{
+			name: "invalid url",
+			call: func(client *Client) error {
+				_, err := client.CreateMany(ctx, []string{"https://example.org", "ftp://example.org"})
+				return err
+			},
+			kind:       ErrInvalidArgument,
+			code:       "INVALID_URL",
+			violations: []FieldViolation{{Field: "urls[1]", Description: "invalid url: scheme must be http or https"}},
+		},
+		{
+			name: "invalid filter",
+			call: func(client *Client) error {
+				_, err := client.List(ctx, ListOptions{Status: "lost"})
+				return err
+			},
+			kind: ErrInvalidArgument,
+			code: "INVALID_FILTER",
+			violations: []FieldViolation{{
+				Field: "status", Description: "must be one of active, scheduled, disabled, expired, deleted",
+			}},
+		},
+	}
+	for _, testCase := range testCases {
+		for name, client := range clients {
+			t.Run(testCase.name+" over "+name, func(t *testing.T) {
+				err := testCase.call(client)
+				require.ErrorIs(t, err, testCase.kind)
+				var apiErr *Error
+				require.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, testCase.code, apiErr.Code)
+				assert.Equal(t, testCase.violations, apiErr.Violations)
+				assert.NotEmpty(t, apiErr.RequestID)
+			})
+		}
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+	client := server.grpcClient(t)
+	ctx := context.Background()
+
+	// the idempotent calls are retried
+	server.failures.Store(2)
+	_, err := client.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.EqualValues(t, 3, server.calls.Swap(0))
+
+	// up to the limit of the attempts
+	server.failures.Store(3)
+	_, err = client.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.EqualValues(t, 3, server.calls.Swap(0))
+
+	// the others are not
+	server.failures.Store(1)
+	_, err = client.Create(ctx, "https://example.com")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.EqualValues(t, 1, server.calls.Swap(0))
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	t.Parallel()
+	listener := bufconn.Listen(bufferSize)
+	require.NoError(t, listener.Close())
+	transport := &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}}
+	client, err := NewHTTP(testBaseURL, WithRetry(testRetry), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, client.Health(context.Background()), ErrUnavailable)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, client.Health(ctx), context.Canceled)
+}
+
+func keysOf(aliases []Alias) []string {
+	keys := make([]string, len(aliases))
+	for index, alias := range aliases {
+		keys[index] = alias.Key
+	}
+	return keys
+}
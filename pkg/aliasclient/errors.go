@@ -0,0 +1,147 @@
+package aliasclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// errorDomain is the domain of the ErrorInfo details of the service errors
+const errorDomain = "alias"
+
+// The kinds of the errors returned by the service, test them with errors.Is.
+// The deadlines and the cancellations are reported as context.DeadlineExceeded and context.Canceled.
+var (
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyExists     = errors.New("already exists")
+	ErrPermissionDenied  = errors.New("permission denied")
+	ErrUnauthenticated   = errors.New("unauthenticated")
+	ErrResourceExhausted = errors.New("resource exhausted")
+	ErrUnimplemented     = errors.New("unimplemented")
+	ErrUnavailable       = errors.New("service unavailable")
+	ErrInternal          = errors.New("internal error")
+)
+
+// FieldViolation describes a problem with a single field of the request
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error is an error returned by the service
+type Error struct {
+	// Kind is one of the Err* errors of the package, context.DeadlineExceeded or context.Canceled
+	Kind error
+	// Code is the machine-readable code of the error, e.g. KEY_ALREADY_EXISTS or ALIAS_EXPIRED
+	Code    string
+	Message string
+	// Violations are the problems with the fields of the request
+	Violations []FieldViolation
+	// RequestID identifies the call in the logs of the service, if it is known
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// grpcKinds maps the gRPC codes to the kinds of the errors
+var grpcKinds = map[codes.Code]error{
+	codes.InvalidArgument:   ErrInvalidArgument,
+	codes.OutOfRange:        ErrInvalidArgument,
+	codes.NotFound:          ErrNotFound,
+	codes.AlreadyExists:     ErrAlreadyExists,
+	codes.PermissionDenied:  ErrPermissionDenied,
+	codes.Unauthenticated:   ErrUnauthenticated,
+	codes.ResourceExhausted: ErrResourceExhausted,
+	codes.Unimplemented:     ErrUnimplemented,
+	codes.Unavailable:       ErrUnavailable,
+	codes.DeadlineExceeded:  context.DeadlineExceeded,
+	codes.Canceled:          context.Canceled,
+}
+
+// httpKinds maps the HTTP statuses to the kinds of the errors
+var httpKinds = map[int]error{
+	http.StatusBadRequest:           ErrInvalidArgument,
+	http.StatusUnsupportedMediaType: ErrInvalidArgument,
+	http.StatusUnauthorized:         ErrUnauthenticated,
+	http.StatusForbidden:            ErrPermissionDenied,
+	http.StatusNotFound:             ErrNotFound,
+	http.StatusGone:                 ErrNotFound,
+	http.StatusConflict:             ErrAlreadyExists,
+	http.StatusMethodNotAllowed:     ErrUnimplemented,
+	http.StatusNotImplemented:       ErrUnimplemented,
+	http.StatusTooManyRequests:      ErrResourceExhausted,
+	http.StatusBadGateway:           ErrUnavailable,
+	http.StatusServiceUnavailable:   ErrUnavailable,
+	http.StatusGatewayTimeout:       ErrUnavailable,
+}
+
+// fromGRPC converts the error of a gRPC call, the errors which are not gRPC statuses are returned as is
+func fromGRPC(err error, requestID string) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	apiErr := &Error{Kind: ErrInternal, Message: st.Message(), RequestID: requestID}
+	if kind, ok := grpcKinds[st.Code()]; ok {
+		apiErr.Kind = kind
+	}
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.GetDomain() == errorDomain {
+				apiErr.Code = detail.GetReason()
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range detail.GetFieldViolations() {
+				apiErr.Violations = append(apiErr.Violations, FieldViolation{
+					Field:       violation.GetField(),
+					Description: violation.GetDescription(),
+				})
+			}
+		}
+	}
+	return apiErr
+}
+
+// fromHTTP converts the JSON error response of the REST API
+func fromHTTP(statusCode int, body errorBody, requestID string) error {
+	apiErr := &Error{Kind: ErrInternal, Code: body.Code, Message: body.Message, RequestID: body.RequestID}
+	if kind, ok := httpKinds[statusCode]; ok {
+		apiErr.Kind = kind
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = requestID
+	}
+	for _, violation := range body.Details {
+		apiErr.Violations = append(apiErr.Violations, FieldViolation(violation))
+	}
+	return apiErr
+}
+
+// errorBody is the JSON error response of the REST API
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details []struct {
+		Field       string `json:"field"`
+		Description string `json:"description"`
+	} `json:"details"`
+	RequestID string `json:"request_id"`
+}
@@ -0,0 +1,213 @@
+package aliasclient
+
+import (
+	"context"
+	aliasapi "github.com/xloki21/alias/internal/gen/go/pbuf/alias"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// metadataRequestID is the gRPC response header carrying the request ID
+const metadataRequestID = "x-request-id"
+
+type grpcTransport struct {
+	conn         *grpc.ClientConn
+	api          aliasapi.AliasAPIClient
+	healthClient healthpb.HealthClient
+}
+
+func newGRPCTransport(target string, s settings) (*grpcTransport, error) {
+	transportCredentials := insecure.NewCredentials()
+	if s.tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(s.tlsConfig.Clone())
+	}
+	options := []grpc.DialOption{grpc.WithTransportCredentials(transportCredentials)}
+	if s.token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(bearerToken{token: s.token, secure: s.tlsConfig != nil}))
+	}
+	options = append(options, s.dialOptions...)
+
+	conn, err := grpc.NewClient(target, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcTransport{conn: conn, api: aliasapi.NewAliasAPIClient(conn), healthClient: healthpb.NewHealthClient(conn)}, nil
+}
+
+// call makes the call with the response header read into header, so the errors carry the request ID
+func call[T any](ctx context.Context, fn func(ctx context.Context, options ...grpc.CallOption) (T, error)) (T, error) {
+	var header metadata.MD
+	response, err := fn(ctx, grpc.Header(&header))
+	if err != nil {
+		var requestID string
+		if values := header.Get(metadataRequestID); len(values) > 0 {
+			requestID = values[0]
+		}
+		return response, fromGRPC(err, requestID)
+	}
+	return response, nil
+}
+
+func (t *grpcTransport) create(ctx context.Context, urls []string, request createRequest) ([]string, error) {
+	message := &aliasapi.CreateRequest{
+		Urls:          urls,
+		MaxUsageCount: request.maxUsage,
+		RedirectType:  aliasapi.RedirectType(request.redirectType),
+		Campaign:      request.campaign,
+		Password:      request.password,
+		Interstitial:  request.interstitial,
+		Key:           request.key,
+	}
+	if !request.activatesAt.IsZero() {
+		message.ActivatesAt = timestamppb.New(request.activatesAt)
+	}
+	if !request.expiresAt.IsZero() {
+		message.ExpiresAt = timestamppb.New(request.expiresAt)
+	}
+	response, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*aliasapi.CreateResponse, error) {
+		return t.api.Create(ctx, message, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetUrls(), nil
+}
+
+func (t *grpcTransport) get(ctx context.Context, key string) (*Alias, error) {
+	response, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*aliasapi.AliasInfo, error) {
+		return t.api.GetAlias(ctx, &aliasapi.KeyRequest{Key: key}, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	alias := aliasFromInfo(response)
+	return &alias, nil
+}
+
+func (t *grpcTransport) list(ctx context.Context, options ListOptions) (*Page, error) {
+	request := &aliasapi.ListAliasesRequest{
+		Status:   string(options.Status),
+		Campaign: options.Campaign,
+		Limit:    int32(options.Limit),
+		Cursor:   options.Cursor,
+	}
+	response, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*aliasapi.AliasList, error) {
+		return t.api.ListAliases(ctx, request, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{Aliases: make([]Alias, len(response.GetAliases())), NextCursor: response.GetNextCursor()}
+	for index, info := range response.GetAliases() {
+		page.Aliases[index] = aliasFromInfo(info)
+	}
+	return page, nil
+}
+
+func (t *grpcTransport) remove(ctx context.Context, key string) error {
+	_, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*emptypb.Empty, error) {
+		return t.api.Remove(ctx, &aliasapi.KeyRequest{Key: key}, options...)
+	})
+	return err
+}
+
+func (t *grpcTransport) restore(ctx context.Context, key string) error {
+	_, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*emptypb.Empty, error) {
+		return t.api.Restore(ctx, &aliasapi.KeyRequest{Key: key}, options...)
+	})
+	return err
+}
+
+func (t *grpcTransport) setDisabled(ctx context.Context, key string, disabled bool) error {
+	method := t.api.EnableAlias
+	if disabled {
+		method = t.api.DisableAlias
+	}
+	_, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*emptypb.Empty, error) {
+		return method(ctx, &aliasapi.KeyRequest{Key: key}, options...)
+	})
+	return err
+}
+
+func (t *grpcTransport) stats(ctx context.Context, key string) (*Stats, error) {
+	response, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*aliasapi.StatsResponse, error) {
+		return t.api.GetStats(ctx, &aliasapi.KeyRequest{Key: key}, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Stats{
+		Key:         response.GetKey(),
+		Clicks:      response.GetClicks(),
+		Rules:       response.GetRules(),
+		Variants:    response.GetVariants(),
+		LastClickAt: timeOf(response.GetLastClickAt()),
+	}, nil
+}
+
+// health checks the standard health service, which reports the readiness of the storage
+func (t *grpcTransport) health(ctx context.Context) error {
+	response, err := call(ctx, func(ctx context.Context, options ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+		return t.healthClient.Check(ctx, &healthpb.HealthCheckRequest{}, options...)
+	})
+	if err != nil {
+		return err
+	}
+	if response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return &Error{Kind: ErrUnavailable, Message: "service is " + response.GetStatus().String()}
+	}
+	return nil
+}
+
+func (t *grpcTransport) close() error {
+	return t.conn.Close()
+}
+
+func aliasFromInfo(info *aliasapi.AliasInfo) Alias {
+	return Alias{
+		Key:          info.GetKey(),
+		URL:          info.GetUrl(),
+		Target:       info.GetTarget(),
+		Status:       Status(info.GetStatus()),
+		Permanent:    info.GetIsPermanent(),
+		TriesLeft:    info.GetTriesLeft(),
+		RedirectType: int(info.GetRedirectType()),
+		Campaign:     info.GetCampaign(),
+		Protected:    info.GetProtected(),
+		Interstitial: info.GetInterstitial(),
+		CreatedAt:    timeOf(info.GetCreatedAt()),
+		ActivatesAt:  timeOf(info.GetActivatesAt()),
+		ExpiresAt:    timeOf(info.GetExpiresAt()),
+		ExpiredAt:    timeOf(info.GetExpiredAt()),
+		DeletedAt:    timeOf(info.GetDeletedAt()),
+	}
+}
+
+// timeOf returns the time of the timestamp, zero if it is not set
+func timeOf(timestamp *timestamppb.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return timestamp.AsTime()
+}
+
+// bearerToken passes the token in the authorization metadata
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+func (b bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + b.token}, nil
+}
+
+// RequireTransportSecurity lets the token go over plaintext connections only if TLS is not asked for
+func (b bearerToken) RequireTransportSecurity() bool {
+	return b.secure
+}
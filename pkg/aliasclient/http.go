@@ -0,0 +1,290 @@
+package aliasclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	endpointAlias  = "/api/v1/alias"
+	endpointReadyz = "/readyz"
+	// headerRequestID is the HTTP response header carrying the request ID
+	headerRequestID = "X-Request-ID"
+	// maxErrorBodySize limits the error responses read by the client
+	maxErrorBodySize = 64 << 10
+)
+
+type httpTransport struct {
+	baseURL *url.URL
+	client  *http.Client
+	token   string
+}
+
+func newHTTPTransport(baseURL string, s settings) (*httpTransport, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("base url %q must be an absolute http or https url", baseURL)
+	}
+
+	client := &http.Client{}
+	if s.httpClient != nil {
+		*client = *s.httpClient
+	} else {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if s.tlsConfig != nil {
+			transport.TLSClientConfig = s.tlsConfig.Clone()
+		}
+		client.Transport = transport
+	}
+	// the redirects of the service are its answers, e.g. to a short link, not a way to reach the API
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &httpTransport{baseURL: parsed, client: client, token: s.token}, nil
+}
+
+// aliasPayload is an alias in the responses of the REST API
+type aliasPayload struct {
+	Key          string     `json:"key"`
+	URL          string     `json:"url"`
+	Target       string     `json:"target"`
+	Status       string     `json:"status"`
+	IsPermanent  bool       `json:"isPermanent"`
+	TriesLeft    int64      `json:"triesLeft"`
+	RedirectType int        `json:"redirectType"`
+	Campaign     string     `json:"campaign"`
+	Protected    bool       `json:"protected"`
+	Interstitial bool       `json:"interstitial"`
+	CreatedAt    *time.Time `json:"createdAt"`
+	ActivatesAt  *time.Time `json:"activatesAt"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	ExpiredAt    *time.Time `json:"expiredAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+}
+
+func (p aliasPayload) alias() Alias {
+	return Alias{
+		Key:          p.Key,
+		URL:          p.URL,
+		Target:       p.Target,
+		Status:       Status(p.Status),
+		Permanent:    p.IsPermanent,
+		TriesLeft:    p.TriesLeft,
+		RedirectType: p.RedirectType,
+		Campaign:     p.Campaign,
+		Protected:    p.Protected,
+		Interstitial: p.Interstitial,
+		CreatedAt:    timeOrZero(p.CreatedAt),
+		ActivatesAt:  timeOrZero(p.ActivatesAt),
+		ExpiresAt:    timeOrZero(p.ExpiresAt),
+		ExpiredAt:    timeOrZero(p.ExpiredAt),
+		DeletedAt:    timeOrZero(p.DeletedAt),
+	}
+}
+
+// timeOrZero returns the time, zero if it is missing in the response
+func timeOrZero(value *time.Time) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+	return *value
+}
+
+func (t *httpTransport) create(ctx context.Context, urls []string, request createRequest) ([]string, error) {
+	query := url.Values{}
+	if request.maxUsage != nil {
+		query.Set("maxUsageCount", strconv.FormatUint(*request.maxUsage, 10))
+	}
+	if request.key != "" {
+		query.Set("key", request.key)
+	}
+	if request.campaign != "" {
+		query.Set("campaign", request.campaign)
+	}
+	if request.redirectType != 0 {
+		query.Set("redirectType", strconv.Itoa(request.redirectType))
+	}
+	if request.interstitial {
+		query.Set("interstitial", "true")
+	}
+	if !request.activatesAt.IsZero() {
+		query.Set("activatesAt", request.activatesAt.Format(time.RFC3339))
+	}
+	if !request.expiresAt.IsZero() {
+		query.Set("expiresAt", request.expiresAt.Format(time.RFC3339))
+	}
+	// the password goes in the body, so it never shows up in the access logs
+	body := struct {
+		URLs     []string `json:"urls"`
+		Password string   `json:"password,omitempty"`
+	}{URLs: urls, Password: request.password}
+
+	var response struct {
+		URLs []string `json:"urls"`
+	}
+	if err := t.do(ctx, http.MethodPost, endpointAlias, query, body, &response); err != nil {
+		return nil, err
+	}
+	return response.URLs, nil
+}
+
+func (t *httpTransport) get(ctx context.Context, key string) (*Alias, error) {
+	var response aliasPayload
+	if err := t.do(ctx, http.MethodGet, aliasPath(key, "info"), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	alias := response.alias()
+	return &alias, nil
+}
+
+func (t *httpTransport) list(ctx context.Context, options ListOptions) (*Page, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"status": string(options.Status), "campaign": options.Campaign, "cursor": options.Cursor,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+
+	var response struct {
+		Aliases    []aliasPayload `json:"aliases"`
+		NextCursor string         `json:"nextCursor"`
+	}
+	if err := t.do(ctx, http.MethodGet, endpointAlias, query, nil, &response); err != nil {
+		return nil, err
+	}
+	page := &Page{Aliases: make([]Alias, len(response.Aliases)), NextCursor: response.NextCursor}
+	for index, payload := range response.Aliases {
+		page.Aliases[index] = payload.alias()
+	}
+	return page, nil
+}
+
+func (t *httpTransport) remove(ctx context.Context, key string) error {
+	return t.do(ctx, http.MethodDelete, aliasPath(key, ""), nil, nil, nil)
+}
+
+func (t *httpTransport) restore(ctx context.Context, key string) error {
+	return t.do(ctx, http.MethodPost, aliasPath(key, "restore"), nil, nil, nil)
+}
+
+func (t *httpTransport) setDisabled(ctx context.Context, key string, disabled bool) error {
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	return t.do(ctx, http.MethodPost, aliasPath(key, action), nil, nil, nil)
+}
+
+func (t *httpTransport) stats(ctx context.Context, key string) (*Stats, error) {
+	var response struct {
+		Key         string           `json:"key"`
+		Clicks      int64            `json:"clicks"`
+		Rules       map[string]int64 `json:"rules"`
+		Variants    map[string]int64 `json:"variants"`
+		LastClickAt *time.Time       `json:"lastClickAt"`
+	}
+	if err := t.do(ctx, http.MethodGet, aliasPath(key, "stats"), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &Stats{
+		Key:         response.Key,
+		Clicks:      response.Clicks,
+		Rules:       response.Rules,
+		Variants:    response.Variants,
+		LastClickAt: timeOrZero(response.LastClickAt),
+	}, nil
+}
+
+// health checks the readiness endpoint, which answers 503 with the failed components while the service is not ready
+func (t *httpTransport) health(ctx context.Context) error {
+	return t.do(ctx, http.MethodGet, endpointReadyz, nil, nil, nil)
+}
+
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// aliasPath is the path of the alias endpoint, action is the last segment of the path if not empty
+func aliasPath(key, action string) string {
+	path := endpointAlias + "/" + url.PathEscape(key)
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+// do sends the request with the JSON body if it is not nil and decodes the JSON response into response
+// if it is not nil, the error responses are converted to *Error
+func (t *httpTransport) do(ctx context.Context, method, path string, query url.Values, body, response any) error {
+	target := t.baseURL.JoinPath(path)
+	target.RawQuery = query.Encode()
+
+	var content io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), content)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if t.token != "" {
+		request.Header.Set("Authorization", "Bearer "+t.token)
+	}
+
+	answer, err := t.client.Do(request)
+	if err != nil {
+		return transportError(err)
+	}
+	defer answer.Body.Close()
+
+	if answer.StatusCode >= http.StatusBadRequest {
+		var errBody errorBody
+		data, _ := io.ReadAll(io.LimitReader(answer.Body, maxErrorBodySize))
+		_ = json.Unmarshal(data, &errBody)
+		return fromHTTP(answer.StatusCode, errBody, answer.Header.Get(headerRequestID))
+	}
+	if response == nil {
+		_, _ = io.Copy(io.Discard, answer.Body)
+		return nil
+	}
+	if err := json.NewDecoder(answer.Body).Decode(response); err != nil {
+		return &Error{Kind: ErrInternal, Message: fmt.Sprintf("invalid response: %s", err)}
+	}
+	return nil
+}
+
+// transportError converts the failure to reach the service, the service is unavailable unless the context is done
+func transportError(err error) error {
+	kind := ErrUnavailable
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = context.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		kind = context.Canceled
+	}
+	return &Error{Kind: kind, Message: err.Error()}
+}
@@ -0,0 +1,52 @@
+package aliasclient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy retries the idempotent calls failed because the service was unavailable, overloaded or too slow.
+// The other calls are never retried: the service could have done them before the failure.
+type RetryPolicy struct {
+	// MaxAttempts is the number of the attempts including the first one, 1 or less turns the retries off
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled for every next one up to MaxBackoff if it is set.
+	// The waits are randomized by up to a half to spread the retries of many clients.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// retryable reports whether the error of an attempt is worth retrying
+func retryable(err error) bool {
+	return errors.Is(err, ErrUnavailable) ||
+		errors.Is(err, ErrResourceExhausted) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// do makes the attempts until one of them succeeds, fails with an error not worth retrying or the context is done
+func (p RetryPolicy) do(ctx context.Context, attempt func(ctx context.Context) error) error {
+	backoff := p.InitialBackoff
+	for attempts := 1; ; attempts++ {
+		err := attempt(ctx)
+		if err == nil || attempts >= p.MaxAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := backoff
+		if wait > 0 {
+			wait -= rand.N(wait/2 + 1)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package aliasclient
+
+import "time"
+
+// Status is the state of an alias
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusScheduled Status = "scheduled"
+	StatusDisabled  Status = "disabled"
+	StatusExpired   Status = "expired"
+	StatusDeleted   Status = "deleted"
+)
+
+// Alias is a short link and its settings
+type Alias struct {
+	Key string
+	// URL is the short link
+	URL    string
+	Target string
+	Status Status
+	// Permanent aliases are never expired, the others redirect TriesLeft more times
+	Permanent bool
+	TriesLeft int64
+	// RedirectType is the HTTP status code of the redirect, zero for the configured default
+	RedirectType int
+	Campaign     string
+	Protected    bool
+	Interstitial bool
+	CreatedAt    time.Time
+	// ActivatesAt is zero for the aliases redirecting since their creation
+	ActivatesAt time.Time
+	// ExpiresAt is zero for the aliases never expiring by time
+	ExpiresAt time.Time
+	// ExpiredAt is set for the expired aliases deactivated by the server
+	ExpiredAt time.Time
+	// DeletedAt is set for the aliases in the trash
+	DeletedAt time.Time
+}
+
+// ListOptions filter the listed aliases, the zero value lists the first page of all the aliases but the removed ones
+type ListOptions struct {
+	Status   Status
+	Campaign string
+	// Limit is the size of the page, the default of the server if zero
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+}
+
+// Page is a page of the aliases ordered by key
+type Page struct {
+	Aliases []Alias
+	// NextCursor is empty on the last page
+	NextCursor string
+}
+
+// Stats are the click counters of an alias
+type Stats struct {
+	Key    string
+	Clicks int64
+	// Rules are the clicks per matched redirect rule
+	Rules map[string]int64
+	// Variants are the clicks per A/B variant
+	Variants    map[string]int64
+	LastClickAt time.Time
+}
+
+// createRequest is the request built by the create options
+type createRequest struct {
+	maxUsage     *uint64
+	key          string
+	campaign     string
+	redirectType int
+	password     string
+	interstitial bool
+	activatesAt  time.Time
+	expiresAt    time.Time
+}
+
+// CreateOption configures the created aliases
+type CreateOption func(*createRequest)
+
+// WithMaxUsage expires the alias after n redirects, the aliases are permanent by default
+func WithMaxUsage(n uint64) CreateOption {
+	return func(r *createRequest) { r.maxUsage = &n }
+}
+
+// WithKey creates the alias with the key instead of a generated one, only a single url may be given with a key
+func WithKey(key string) CreateOption {
+	return func(r *createRequest) { r.key = key }
+}
+
+// WithCampaign attaches the aliases to the campaign, its parameters are added to the target urls
+func WithCampaign(name string) CreateOption {
+	return func(r *createRequest) { r.campaign = name }
+}
+
+// WithRedirectType sets the HTTP status code of the redirect: 301, 302, 307 or 308
+func WithRedirectType(code int) CreateOption {
+	return func(r *createRequest) { r.redirectType = code }
+}
+
+// WithPassword makes the visitors enter the password before they are redirected
+func WithPassword(password string) CreateOption {
+	return func(r *createRequest) { r.password = password }
+}
+
+// WithInterstitial shows the preview page before every redirect
+func WithInterstitial() CreateOption {
+	return func(r *createRequest) { r.interstitial = true }
+}
+
+// WithActivation makes the aliases start redirecting at the time
+func WithActivation(at time.Time) CreateOption {
+	return func(r *createRequest) { r.activatesAt = at }
+}
+
+// WithExpiration makes the aliases stop redirecting at the time
+func WithExpiration(at time.Time) CreateOption {
+	return func(r *createRequest) { r.expiresAt = at }
+}
@@ -0,0 +1,226 @@
+// Package qrcode renders QR codes as PNG or SVG images
+package qrcode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	qr "github.com/skip2/go-qrcode"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Format is the image format of the QR code
+type Format string
+
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+)
+
+// Level is the error correction level, a higher level survives more damage of the printed code but makes it denser
+type Level string
+
+const (
+	Low      Level = "L" // 7% of the code may be lost
+	Medium   Level = "M" // 15%
+	Quartile Level = "Q" // 25%
+	High     Level = "H" // 30%
+)
+
+const (
+	DefaultSize   = 256
+	DefaultMargin = 4 // quiet zone required by the standard
+	MinSize       = 21
+	MaxSize       = 4096
+	MaxMargin     = 64
+)
+
+var (
+	ErrInvalidFormat = errors.New("format must be png or svg")
+	ErrInvalidLevel  = errors.New("level must be one of L, M, Q, H")
+	ErrInvalidSize   = fmt.Errorf("size must be between %d and %d", MinSize, MaxSize)
+	ErrInvalidMargin = fmt.Errorf("margin must be between 0 and %d", MaxMargin)
+	ErrInvalidColor  = errors.New("color must be a hex RRGGBB or RRGGBBAA value")
+)
+
+var recoveryLevels = map[Level]qr.RecoveryLevel{
+	Low:      qr.Low,
+	Medium:   qr.Medium,
+	Quartile: qr.High,
+	High:     qr.Highest,
+}
+
+// Options describe the image of the QR code
+type Options struct {
+	Format Format
+	// Size is the width and height of the image in pixels. The image is larger if the code does not fit.
+	Size int
+	// Margin is the width of the quiet zone around the code in modules
+	Margin     int
+	Level      Level
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// DefaultOptions returns black on white PNG options with medium error correction
+func DefaultOptions() Options {
+	return Options{
+		Format:     PNG,
+		Size:       DefaultSize,
+		Margin:     DefaultMargin,
+		Level:      Medium,
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// ParseFormat parses the image format, case-insensitive
+func ParseFormat(value string) (Format, error) {
+	format := Format(strings.ToLower(value))
+	if format != PNG && format != SVG {
+		return "", ErrInvalidFormat
+	}
+	return format, nil
+}
+
+// ContentType returns the media type of the image
+func (f Format) ContentType() string {
+	if f == SVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// ParseLevel parses the error correction level, case-insensitive
+func ParseLevel(value string) (Level, error) {
+	level := Level(strings.ToUpper(value))
+	if _, ok := recoveryLevels[level]; !ok {
+		return "", ErrInvalidLevel
+	}
+	return level, nil
+}
+
+// ParseColor parses a hex color in form of RRGGBB or RRGGBBAA, optionally prefixed with #
+func ParseColor(value string) (color.NRGBA, error) {
+	value = strings.TrimPrefix(value, "#")
+	if len(value) != 6 && len(value) != 8 {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+	parsed := color.NRGBA{R: decoded[0], G: decoded[1], B: decoded[2], A: 0xff}
+	if len(decoded) == 4 {
+		parsed.A = decoded[3]
+	}
+	return parsed, nil
+}
+
+// Validate checks the options
+func (o Options) Validate() error {
+	if o.Format != PNG && o.Format != SVG {
+		return ErrInvalidFormat
+	}
+	if _, ok := recoveryLevels[o.Level]; !ok {
+		return ErrInvalidLevel
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return ErrInvalidSize
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return ErrInvalidMargin
+	}
+	return nil
+}
+
+// Encode renders the content as a QR code image
+func Encode(content string, options Options) ([]byte, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	code, err := qr.New(content, recoveryLevels[options.Level])
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	if options.Format == SVG {
+		return encodeSVG(modules, options), nil
+	}
+	return encodePNG(modules, options)
+}
+
+// layout returns the size of the image, the size of a module and the offset of the code centered in the image, all in pixels
+func layout(modules [][]bool, options Options) (imageSize, moduleSize, offset int) {
+	total := len(modules) + 2*options.Margin
+	moduleSize = max(options.Size/total, 1)
+	imageSize = max(options.Size, total*moduleSize)
+	return imageSize, moduleSize, (imageSize - len(modules)*moduleSize) / 2
+}
+
+func encodePNG(modules [][]bool, options Options) ([]byte, error) {
+	size, moduleSize, offset := layout(modules, options)
+	palette := color.Palette{options.Background, options.Foreground}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+	// the image is filled with the first color of the palette, the background
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < moduleSize; dy++ {
+				start := img.PixOffset(offset+x*moduleSize, offset+y*moduleSize+dy)
+				for dx := 0; dx < moduleSize; dx++ {
+					img.Pix[start+dx] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeSVG(modules [][]bool, options Options) []byte {
+	size, moduleSize, offset := layout(modules, options)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, size, size)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%"%s/>`, fill(options.Background))
+	fmt.Fprintf(&buf, `<path%s d="`, fill(options.Foreground))
+	for y, row := range modules {
+		// adjacent dark modules of the row are joined into a single rectangle
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			run := x
+			for run < len(row) && row[run] {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv%dh-%dz", offset+x*moduleSize, offset+y*moduleSize,
+				(run-x)*moduleSize, moduleSize, (run-x)*moduleSize)
+			x = run
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// fill returns the SVG fill attributes of the color
+func fill(c color.NRGBA) string {
+	attributes := fmt.Sprintf(` fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		attributes += fmt.Sprintf(` fill-opacity="%.3f"`, float64(c.A)/0xff)
+	}
+	return attributes
+}
@@ -0,0 +1,116 @@
+package qrcode
+
+import (
+	"bytes"
+	qr "github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestEncode_PNG(t *testing.T) {
+	t.Parallel()
+	options := DefaultOptions()
+	options.Foreground = color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}
+
+	content, err := Encode("http://localhost:8080/pfemZ9bl5w==", options)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSize, img.Bounds().Dx())
+	assert.Equal(t, DefaultSize, img.Bounds().Dy())
+
+	// the corner is in the quiet zone, the finder pattern starts right after it
+	modules := len(mustBitmap(t, "http://localhost:8080/pfemZ9bl5w==", options))
+	_, moduleSize, offset := layout(make([][]bool, modules), options)
+	assert.Equal(t, color.NRGBAModel.Convert(options.Background), color.NRGBAModel.Convert(img.At(0, 0)))
+	assert.Equal(t, color.NRGBAModel.Convert(options.Foreground), color.NRGBAModel.Convert(img.At(offset, offset)))
+	assert.Equal(t, color.NRGBAModel.Convert(options.Foreground), color.NRGBAModel.Convert(img.At(offset+moduleSize-1, offset+moduleSize-1)))
+}
+
+func TestEncode_SVG(t *testing.T) {
+	t.Parallel()
+	options := DefaultOptions()
+	options.Format = SVG
+	options.Background = color.NRGBA{R: 0xff, G: 0xff, B: 0xff}
+
+	content, err := Encode("http://localhost:8080/pfemZ9bl5w==", options)
+	require.NoError(t, err)
+	svg := string(content)
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`))
+	assert.Contains(t, svg, `fill="#ffffff" fill-opacity="0.000"`)
+	assert.Contains(t, svg, `<path fill="#000000" d="M`)
+}
+
+func TestEncode_SmallSize(t *testing.T) {
+	t.Parallel()
+	options := DefaultOptions()
+	options.Size = MinSize
+
+	content, err := Encode("http://localhost:8080/pfemZ9bl5w==", options)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(content))
+	require.NoError(t, err)
+	// the code does not fit, a module takes a single pixel
+	modules := len(mustBitmap(t, "http://localhost:8080/pfemZ9bl5w==", options))
+	assert.Equal(t, modules+2*DefaultMargin, img.Bounds().Dx())
+}
+
+func TestOptions_Validate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		modify    func(*Options)
+		expectErr error
+	}{
+		{name: "defaults", modify: func(*Options) {}},
+		{name: "unknown format", modify: func(o *Options) { o.Format = "gif" }, expectErr: ErrInvalidFormat},
+		{name: "unknown level", modify: func(o *Options) { o.Level = "X" }, expectErr: ErrInvalidLevel},
+		{name: "too small", modify: func(o *Options) { o.Size = MinSize - 1 }, expectErr: ErrInvalidSize},
+		{name: "too large", modify: func(o *Options) { o.Size = MaxSize + 1 }, expectErr: ErrInvalidSize},
+		{name: "no margin", modify: func(o *Options) { o.Margin = 0 }},
+		{name: "negative margin", modify: func(o *Options) { o.Margin = -1 }, expectErr: ErrInvalidMargin},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			options := DefaultOptions()
+			testCase.modify(&options)
+			assert.ErrorIs(t, options.Validate(), testCase.expectErr)
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		value     string
+		expected  color.NRGBA
+		expectErr error
+	}{
+		{value: "#1a2B3c", expected: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}},
+		{value: "1a2b3c80", expected: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0x80}},
+		{value: "fff", expectErr: ErrInvalidColor},
+		{value: "zzzzzz", expectErr: ErrInvalidColor},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.value, func(t *testing.T) {
+			t.Parallel()
+			parsed, err := ParseColor(testCase.value)
+			assert.ErrorIs(t, err, testCase.expectErr)
+			assert.Equal(t, testCase.expected, parsed)
+		})
+	}
+}
+
+func mustBitmap(t *testing.T, content string, options Options) [][]bool {
+	t.Helper()
+	code, err := qr.New(content, recoveryLevels[options.Level])
+	require.NoError(t, err)
+	code.DisableBorder = true
+	return code.Bitmap()
+}
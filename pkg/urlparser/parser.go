@@ -1,6 +1,11 @@
 package urlparser
 
-import "regexp"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+)
 
 // FindURLs finds all URLs in a text
 func FindURLs(text string) ([]string, error) {
@@ -9,3 +14,20 @@ func FindURLs(text string) ([]string, error) {
 	matches := re.FindAllString(text, -1)
 	return matches, nil
 }
+
+var ErrInvalidURL = errors.New("invalid url")
+
+// Validate parses an absolute http(s) URL
+func Validate(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, err.Error())
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme must be http or https", ErrInvalidURL)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("%w: host is missing", ErrInvalidURL)
+	}
+	return parsed, nil
+}
@@ -93,6 +93,22 @@ func TestApi_e2e(t *testing.T) {
 
 	})
 
+	t.Run("Create alias with the given key should be ok", func(t *testing.T) {
+		resp, err := client.Post(endpointAliasTarget+"?key=e2e-promo",
+			"application/json", strings.NewReader("{\"urls\": [\"http://www.ya.ru\"]}"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, err = client.Get(fmt.Sprintf("%s/%s/info", endpointAliasTarget, "e2e-promo"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = client.Post(endpointAliasTarget+"?key=e2e-promo",
+			"application/json", strings.NewReader("{\"urls\": [\"http://www.ya.ru\"]}"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
 	t.Run("Remove alias should fail: key not found", func(t *testing.T) {
 
 		key := "some-random-key"
@@ -18,6 +18,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	"testing"
+	"time"
 )
 
 const (
@@ -57,11 +58,22 @@ func SetupMongoDBContainer(t *testing.T, testData []domain.Alias) (*tc.MongoDBCo
 		docs := make([]interface{}, len(testData))
 		for index, alias := range testData {
 			docs[index] = bson.D{
-				{"key", alias.Key},
-				{"url", alias.URL},
-				{"is_active", alias.IsActive},
-				{"is_permanent", alias.Params.IsPermanent},
-				{"tries_left", alias.Params.TriesLeft},
+				{Key: "key", Value: alias.Key},
+				{Key: "url", Value: alias.URL},
+				{Key: "is_active", Value: alias.IsActive},
+				{Key: "is_permanent", Value: alias.Params.IsPermanent},
+				{Key: "tries_left", Value: alias.Params.TriesLeft},
+				{Key: "redirect_type", Value: int(alias.RedirectType)},
+				{Key: "passthrough", Value: string(alias.Passthrough.Mode)},
+				{Key: "query_conflict", Value: string(alias.Passthrough.OnConflict)},
+				{Key: "campaign", Value: alias.Campaign},
+				{Key: "password_hash", Value: alias.PasswordHash},
+				{Key: "interstitial", Value: alias.Interstitial},
+				{Key: "disabled", Value: alias.Disabled},
+				{Key: "activates_at", Value: alias.ActivatesAt},
+				{Key: "expires_at", Value: optionalTime(alias.ExpiresAt)},
+				{Key: "expired_at", Value: optionalTime(alias.ExpiredAt)},
+				{Key: "deleted_at", Value: alias.DeletedAt},
 			}
 		}
 		_, err := coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
@@ -70,20 +82,34 @@ func SetupMongoDBContainer(t *testing.T, testData []domain.Alias) (*tc.MongoDBCo
 	return mongodbContainer, db
 }
 
+// optionalTime stores the zero time as null, as the repository does
+func optionalTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
 func NewTestAliasService(ctx context.Context, db *mongo.Database) *aliassvc.Alias {
 	usedQ := squeue.New()
 	expiredQ := squeue.New()
+	clickedQ := squeue.New()
 
 	aliasRepo := mongodb.NewAliasRepository(db.Collection(mongodb.AliasCollectionName))
-	statsRepo := mongodb.NewStatisticsRepository(db.Collection(mongodb.StatsCollectionName))
+	statsRepo := mongodb.NewStatisticsRepository(
+		db.Collection(mongodb.StatsCollectionName),
+		db.Collection(mongodb.ClicksCollectionName),
+		db.Collection(mongodb.ClickEventsCollectionName),
+	)
+	campaignRepo := mongodb.NewCampaignRepository(db.Collection(mongodb.CampaignCollectionName))
 	managerSvc := managersvc.NewManager(aliasRepo, usedQ)
 	managerSvc.Process(ctx)
 
-	statsSvc := statssvc.NewStatistics(statsRepo, expiredQ)
+	statsSvc := statssvc.NewStatistics(statsRepo, expiredQ, clickedQ)
 	statsSvc.Process(ctx)
 
 	keyGen := keygen.NewURLSafeRandomStringGenerator()
 
-	aliasService := aliassvc.NewAlias(expiredQ, usedQ, aliasRepo, keyGen)
+	aliasService := aliassvc.NewAlias(expiredQ, squeue.NewFanout(usedQ, clickedQ), aliasRepo, keyGen, campaignRepo)
 	return aliasService
 }
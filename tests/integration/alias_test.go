@@ -5,13 +5,18 @@ package integration
 
 import (
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
+	"github.com/xloki21/alias/internal/controller/importer"
 	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/repository/mongodb"
 	"github.com/xloki21/alias/internal/services/aliassvc"
 	"github.com/xloki21/alias/tests"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAlias_Create_MongoDB(t *testing.T) {
@@ -153,9 +158,14 @@ func TestAlias_Use_MongoDB(t *testing.T) {
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := aliasService.Use(testCase.args.ctx, testCase.args.alias)
-			assert.Equal(t, testCase.wants, got)
+			got, err := aliasService.Use(testCase.args.ctx, testCase.args.alias, domain.Visitor{})
 			assert.ErrorIs(t, err, testCase.expectErr)
+			if testCase.wants != nil {
+				require.NotNil(t, got)
+				assert.Equal(t, testCase.wants, got.Alias)
+			} else {
+				assert.Nil(t, got)
+			}
 		})
 	}
 }
@@ -206,3 +216,129 @@ func TestAlias_Remove_MongoDB(t *testing.T) {
 		})
 	}
 }
+
+func TestAlias_Trash_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	removed := aliassvc.TestAlias(t, true)
+	removed.IsActive = false
+	removed.DeletedAt = time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Millisecond)
+	testData := []domain.Alias{aliassvc.TestAlias(t, true), removed}
+
+	container, db := tests.SetupMongoDBContainer(t, testData)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	aliasService := tests.NewTestAliasService(ctx, db)
+
+	// the removed alias is not found, but listed in the trash
+	_, err := aliasService.FindOriginalURL(ctx, removed.Key)
+	assert.ErrorIs(t, err, domain.ErrAliasNotFound)
+	page, err := aliasService.List(ctx, domain.ListFilter{Status: domain.StatusDeleted})
+	require.NoError(t, err)
+	require.Len(t, page.Aliases, 1)
+	assert.Equal(t, removed.Key, page.Aliases[0].Key)
+	assert.Equal(t, removed.DeletedAt, page.Aliases[0].DeletedAt)
+
+	// a freshly removed alias is kept by the purge, the one removed before the retention period is deleted
+	require.NoError(t, aliasService.Remove(ctx, testData[0].Key))
+	purged, err := aliasService.PurgeTrash(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.ErrorIs(t, aliasService.Restore(ctx, removed.Key), domain.ErrAliasNotFound)
+
+	require.NoError(t, aliasService.Restore(ctx, testData[0].Key))
+	restored, err := aliasService.FindOriginalURL(ctx, testData[0].Key)
+	require.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.ErrorIs(t, aliasService.Restore(ctx, testData[0].Key), domain.ErrAliasNotFound)
+}
+
+func TestAlias_ReapExpired_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// the outdated alias has uses left, its expiration time has passed
+	outdated := aliassvc.TestAlias(t, false)
+	outdated.ExpiresAt = time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
+	testData := []domain.Alias{aliassvc.TestAlias(t, true), aliassvc.TestExpiredAlias(t), outdated}
+
+	container, db := tests.SetupMongoDBContainer(t, testData)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	aliasService := tests.NewTestAliasService(ctx, db)
+
+	reaped, err := aliasService.ReapExpired(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reaped)
+	reaped, err = aliasService.ReapExpired(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, reaped)
+
+	// the reaped aliases stay out of the trash and survive its purge
+	purged, err := aliasService.PurgeTrash(ctx, 0)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	page, err := aliasService.List(ctx, domain.ListFilter{Status: domain.StatusExpired})
+	require.NoError(t, err)
+	require.Len(t, page.Aliases, 2)
+	for _, alias := range page.Aliases {
+		assert.False(t, alias.ExpiredAt.IsZero())
+	}
+	page, err = aliasService.List(ctx, domain.ListFilter{Status: domain.StatusActive})
+	require.NoError(t, err)
+	require.Len(t, page.Aliases, 1)
+	assert.Equal(t, testData[0].Key, page.Aliases[0].Key)
+
+	// the reaped alias cannot be followed even if its uses are not exhausted
+	aliasRepo := mongodb.NewAliasRepository(db.Collection(mongodb.AliasCollectionName))
+	assert.ErrorIs(t, aliasRepo.DecreaseTTLCounter(ctx, outdated.Key), domain.ErrAliasExpired)
+	assert.ErrorIs(t, aliasRepo.DecreaseTTLCounter(ctx, "missing"), domain.ErrAliasNotFound)
+}
+
+func TestAlias_Import_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	removed := aliassvc.TestAlias(t, true)
+	removed.IsActive = false
+	removed.DeletedAt = time.Now().UTC().Truncate(time.Millisecond)
+	testData := []domain.Alias{aliassvc.TestAlias(t, true), removed}
+
+	container, db := tests.SetupMongoDBContainer(t, testData)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	aliasService := tests.NewTestAliasService(ctx, db)
+
+	// the keys of the stored aliases are taken, including the ones in the trash
+	data := fmt.Sprintf(`{"key":%q,"url":"https://example.com"}`+"\n"+
+		`{"key":%q,"url":"https://example.com"}`+"\n"+
+		`{"key":"promo","url":"https://example.com","maxUsageCount":2}`+"\n"+
+		`{"url":"https://example.org"}`+"\n", testData[0].Key, removed.Key)
+	var results []domain.ImportResult
+	summary, err := aliasService.Import(ctx, importer.NewNDJSONSource(strings.NewReader(data)), domain.ImportOptions{},
+		func(batch []domain.ImportResult) error {
+			results = append(results, batch...)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, &domain.ImportSummary{Rows: 4, Imported: 2, Failed: 2}, summary)
+	require.Len(t, results, 4)
+	assert.ErrorIs(t, results[0].Err, domain.ErrKeyAlreadyExists)
+	assert.ErrorIs(t, results[1].Err, domain.ErrKeyAlreadyExists)
+
+	imported, err := aliasService.FindOriginalURL(ctx, "promo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported.Params.TriesLeft)
+	_, err = aliasService.FindOriginalURL(ctx, results[3].Key)
+	require.NoError(t, err)
+}
@@ -0,0 +1,61 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/xloki21/alias/internal/repository/mongodb"
+	"github.com/xloki21/alias/tests"
+	"testing"
+	"time"
+)
+
+func TestLock_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	container, db := tests.SetupMongoDBContainer(t, nil)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	first := mongodb.NewLockRepository(db.Collection(mongodb.LockCollectionName), "first")
+	second := mongodb.NewLockRepository(db.Collection(mongodb.LockCollectionName), "second")
+
+	acquired, err := first.Acquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// the holder extends the lease, the others wait for it to expire
+	acquired, err = first.Acquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = second.Acquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// the leases are independent of each other
+	acquired, err = second.Acquire(ctx, "other job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	time.Sleep(1100 * time.Millisecond)
+	acquired, err = second.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// releasing a lease held by another owner keeps it
+	require.NoError(t, first.Release(ctx, "job"))
+	acquired, err = first.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, second.Release(ctx, "job"))
+	acquired, err = first.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
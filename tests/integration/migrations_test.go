@@ -0,0 +1,78 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/xloki21/alias/internal/repository/mongodb"
+	"github.com/xloki21/alias/migrations"
+	"github.com/xloki21/alias/tests"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestMigrations_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	container, db := tests.SetupMongoDBContainer(t, nil)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	migrator, err := migrations.NewMongoDB(db.Client(), db.Name())
+	require.NoError(t, err)
+
+	// the container is set up with the first two migrations
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, migrations.Status{
+		Version: 2, Available: []uint{1, 2, 3, 4, 5, 6, 7}, Pending: []uint{3, 4, 5, 6, 7},
+	}, status)
+
+	locker := mongodb.NewLockRepository(db.Collection(mongodb.LockCollectionName), "first")
+	require.NoError(t, migrations.Exclusive(ctx, locker, time.Minute, migrator.Up))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, status.Version)
+	assert.Empty(t, status.Pending)
+	collections, err := db.ListCollectionNames(ctx, map[string]any{})
+	require.NoError(t, err)
+	assert.Subset(t, collections, []string{
+		mongodb.CampaignCollectionName, mongodb.ClicksCollectionName, mongodb.ClickEventsCollectionName,
+	})
+	// the default user created by the first migration is dropped by the last one
+	var users struct {
+		Users []bson.M `bson:"users"`
+	}
+	require.NoError(t, db.RunCommand(ctx, bson.D{{Key: "usersInfo", Value: "user"}}).Decode(&users))
+	assert.Empty(t, users.Users)
+
+	// applying the migrations again changes nothing
+	require.NoError(t, migrator.Up())
+
+	// another replica waits for the lease
+	other := mongodb.NewLockRepository(db.Collection(mongodb.LockCollectionName), "second")
+	acquired, err := other.Acquire(ctx, migrations.LockName, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	assert.ErrorIs(t, migrations.Exclusive(ctx, locker, 10*time.Millisecond, migrator.Up), migrations.ErrLocked)
+	require.NoError(t, other.Release(ctx, migrations.LockName))
+
+	require.NoError(t, migrator.Down(1))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, status.Version)
+	assert.Equal(t, []uint{7}, status.Pending)
+
+	require.NoError(t, migrator.Force(7))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, status.Version)
+	assert.Error(t, migrator.Force(42))
+}
@@ -0,0 +1,74 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/repository/mongodb"
+	"github.com/xloki21/alias/tests"
+	"testing"
+	"time"
+)
+
+func TestStatistics_ListClicks_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	container, db := tests.SetupMongoDBContainer(t, nil)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	repo := mongodb.NewStatisticsRepository(
+		db.Collection(mongodb.StatsCollectionName),
+		db.Collection(mongodb.ClicksCollectionName),
+		db.Collection(mongodb.ClickEventsCollectionName),
+	)
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for index, key := range []string{"a", "b", "a"} {
+		event := domain.AliasUsed{Alias: domain.Alias{Key: key, Campaign: "spring"}, Rule: "ios",
+			OccurredAt: noon.Add(time.Duration(index) * time.Hour)}
+		require.NoError(t, repo.RecordClick(ctx, event))
+	}
+
+	stats, err := repo.Clicks(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+
+	// the clicks are listed in the order of recording, page by page
+	first, err := repo.ListClicks(ctx, domain.ClickFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, "a", first[0].Key)
+	assert.Equal(t, "ios", first[0].Rule)
+	assert.Equal(t, "spring", first[0].Campaign)
+	next, err := repo.ListClicks(ctx, domain.ClickFilter{After: first[1].ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+	assert.Equal(t, noon.Add(2*time.Hour), next[0].OccurredAt)
+
+	filtered, err := repo.ListClicks(ctx, domain.ClickFilter{Key: "a", From: noon.Add(time.Minute), To: noon.Add(3 * time.Hour), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, next[0].ID, filtered[0].ID)
+
+	_, err = repo.ListClicks(ctx, domain.ClickFilter{After: "not an id", Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrInvalidListFilter)
+
+	// the purge deletes the old clicks of the log, the counters are kept
+	purged, err := repo.PurgeClicks(ctx, noon.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, purged)
+	rest, err := repo.ListClicks(ctx, domain.ClickFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, next[0].ID, rest[0].ID)
+	stats, err = repo.Clicks(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+}
@@ -0,0 +1,72 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/xloki21/alias/internal/domain"
+	"github.com/xloki21/alias/internal/repository/mongodb"
+	"github.com/xloki21/alias/internal/services/transfersvc"
+	"github.com/xloki21/alias/tests"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTransferStorage(db *mongo.Database) transfersvc.Storage {
+	return transfersvc.Storage{
+		Aliases:   mongodb.NewAliasRepository(db.Collection(mongodb.AliasCollectionName)),
+		Campaigns: mongodb.NewCampaignRepository(db.Collection(mongodb.CampaignCollectionName)),
+		Stats: mongodb.NewStatisticsRepository(
+			db.Collection(mongodb.StatsCollectionName),
+			db.Collection(mongodb.ClicksCollectionName),
+			db.Collection(mongodb.ClickEventsCollectionName),
+		),
+	}
+}
+
+func TestTransfer_MongoDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	target, _ := url.Parse("https://example.com")
+	testData := []domain.Alias{
+		{Key: "active", URL: target, IsActive: true, Params: domain.TTLParams{TriesLeft: 3}, CreatedAt: noon},
+		{Key: "removed", URL: target, IsActive: false, Params: domain.TTLParams{IsPermanent: true}, DeletedAt: noon},
+	}
+	container, db := tests.SetupMongoDBContainer(t, testData)
+	defer func(container testcontainers.Container, ctx context.Context) {
+		err := container.Terminate(ctx)
+		require.NoError(t, err)
+	}(container, ctx)
+
+	source := newTransferStorage(db)
+	copied := newTransferStorage(db.Client().Database("target"))
+	stats := source.Stats.(*mongodb.StatisticsRepository)
+	for index := 0; index < 3; index++ {
+		event := domain.AliasUsed{Alias: testData[0], OccurredAt: noon.Add(time.Duration(index) * time.Minute)}
+		require.NoError(t, stats.RecordClick(ctx, event))
+	}
+	require.NoError(t, stats.PushStats(ctx, domain.AliasExpired{Alias: testData[1], OccurredAt: noon}))
+
+	transfer := transfersvc.NewTransfer(source, copied, 2)
+	checkpoint, err := transfer.Copy(ctx, transfersvc.Checkpoint{}, func(transfersvc.Checkpoint) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 2, checkpoint.Copied[transfersvc.StageAliases])
+	assert.Equal(t, 3, checkpoint.Copied[transfersvc.StageClicks])
+
+	// copying again keeps the IDs of the clicks, so nothing is duplicated
+	_, err = transfer.Copy(ctx, transfersvc.Checkpoint{}, func(transfersvc.Checkpoint) error { return nil })
+	require.NoError(t, err)
+
+	comparisons, err := transfer.Verify(ctx)
+	require.NoError(t, err)
+	for _, comparison := range comparisons {
+		assert.True(t, comparison.Match(), "stage %s: %+v", comparison.Stage, comparison)
+	}
+}